@@ -15,263 +15,415 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/car/message": {
-            "post": {
+        "/v1/admin/payment/refunds": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Send Message",
+                "description": "List captured payments that must be refunded through the payment provider (paid changes that could not be applied,\npayments of cancelled promotions), oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "MESSAGES"
+                    "Admin"
                 ],
-                "summary": "SendMessage",
+                "summary": "Payment refunds",
                 "parameters": [
                     {
-                        "description": "info",
-                        "name": "info",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.SendMessageBody"
-                        }
+                        "type": "string",
+                        "description": "Status filter (required, refunded), default required",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Skip results for pagination",
+                        "name": "skip",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/cruds.Message"
+                            "$ref": "#/definitions/handler.PaymentRefundsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/v1/car/message/disconnectwebsocket": {
+        "/v1/admin/payment/refunds/{payment_id}/refunded": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Disconnect WebSocket",
+                "description": "Record that the payment was refunded through the payment provider",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "MESSAGES"
+                    "Admin"
+                ],
+                "summary": "Mark payment refunded",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "payment_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "DisconnectWebSocket",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/model.PaymentRefund"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/v1/car/message/store-user-as-typing/{user_id}": {
-            "post": {
+        "/v1/admin/topcar": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Store User As Typing",
+                "description": "List promotions in any state, including deleted, expired, scheduled and unpaid ones, with owner details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "MESSAGES"
+                    "Admin"
                 ],
-                "summary": "StoreUserAsTyping",
+                "summary": "List all top cars (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user_id",
+                        "description": "Category filter (daily, weekly, monthly, custom)",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID filter",
                         "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Car ID filter",
+                        "name": "car_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by (finished_at_asc, finished_at_desc, created_at_asc, created_at_desc)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Skip results for pagination",
+                        "name": "skip",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.AdminGetTopCarsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/v1/car/message/user-typing": {
-            "delete": {
+        "/v1/admin/topcar/audit": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete User Typing Status",
+                "description": "List admin actions on top car promotions, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "MESSAGES"
+                    "Admin"
+                ],
+                "summary": "Admin audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin user ID filter",
+                        "name": "admin_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action filter (topcar_cleanup, topcar_restore, topcar_grant, payment_refunded)",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Top Car ID filter",
+                        "name": "top_car_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Skip results for pagination",
+                        "name": "skip",
+                        "in": "query"
+                    }
                 ],
-                "summary": "DeleteUserTypingStatus",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.AdminAuditLogResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/v1/car/message/{message_id}": {
-            "post": {
+        "/v1/admin/topcar/cleanup": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "mark message as read",
-                "tags": [
-                    "MESSAGES"
+                "description": "Soft delete every finished promotion right away instead of waiting for the background job.\nDELETE /v1/topcar/cleanup is kept as an alias of this endpoint",
+                "consumes": [
+                    "application/json"
                 ],
-                "summary": "MarkMessageAsRead",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "message_id",
-                        "name": "message_id",
-                        "in": "path",
-                        "required": true
-                    }
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
                 ],
+                "summary": "Force top car cleanup (admin)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.DeleteResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/v1/admin/topcar/grant": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete Message",
+                "description": "Create a free promotion for any car on behalf of its owner. It skips payment but still waits for a free category slot",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "MESSAGES"
+                    "Admin"
                 ],
-                "summary": "Delete Message",
+                "summary": "Grant complimentary top car (admin)",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "message_id",
-                        "name": "message_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Grant data",
+                        "name": "grant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.GrantTopCarRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.TopCarResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/v1/car/photo/car/{car_id}": {
-            "delete": {
-                "security": [
-                    {
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/topcar/{id}/restore": {
+            "post": {
+                "security": [
+                    {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete Images By Car Id",
+                "description": "Undo a soft delete. Fails when the promotion window overlaps with another promotion of the same car",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "IMAGES"
+                    "Admin"
                 ],
-                "summary": "DeleteImagesByCarId",
+                "summary": "Restore deleted top car (admin)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "car_id",
-                        "name": "car_id",
+                        "description": "Top Car ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -280,100 +432,99 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.TopCarResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/v1/car/photo/{car_id}": {
-            "get": {
-                "description": "it will Get Car Photos",
+        "/v1/car/message": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send Message",
                 "tags": [
-                    "IMAGES"
+                    "MESSAGES"
                 ],
-                "summary": "Get Car Photos",
+                "summary": "SendMessage",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "car_id",
-                        "name": "car_id",
-                        "in": "path",
-                        "required": true
+                        "description": "info",
+                        "name": "info",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SendMessageBody"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/cruds.Image"
-                            }
+                            "$ref": "#/definitions/cruds.Message"
                         }
                     },
                     "400": {
-                        "description": "Invalid data",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Server error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/v1/car/message/disconnectwebsocket": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Upload Car Photo",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "Disconnect WebSocket",
                 "tags": [
-                    "IMAGES"
-                ],
-                "summary": "CreatePhoto",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "car_id",
-                        "name": "car_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "UploadMediaForm",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
+                    "MESSAGES"
                 ],
+                "summary": "DisconnectWebSocket",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -387,12 +538,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -402,23 +547,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/car/photo/{id}": {
-            "delete": {
+        "/v1/car/message/store-user-as-typing/{user_id}": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete Image",
+                "description": "Store User As Typing",
                 "tags": [
-                    "IMAGES"
+                    "MESSAGES"
                 ],
-                "summary": "DeleteImage",
+                "summary": "StoreUserAsTyping",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "id",
-                        "name": "id",
+                        "description": "user_id",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
@@ -436,12 +581,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -451,174 +590,140 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/topcar": {
-            "get": {
-                "description": "Get filtered list of top cars",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "TopCars"
-                ],
-                "summary": "Get list of top cars",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Category filter (daily, weekly, monthly)",
-                        "name": "category",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID filter",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Car ID filter",
-                        "name": "car_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Sort by (finished_at_asc, finished_at_desc, created_at_asc, created_at_desc)",
-                        "name": "sort_by",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Show expired top cars",
-                        "name": "show_expired",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Show deleted top cars",
-                        "name": "show_deleted",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit results (default 50)",
-                        "name": "limit",
-                        "in": "query"
-                    },
+        "/v1/car/message/user-typing": {
+            "delete": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Skip results for pagination",
-                        "name": "skip",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     }
                 ],
+                "description": "Delete User Typing Status",
+                "tags": [
+                    "MESSAGES"
+                ],
+                "summary": "DeleteUserTypingStatus",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.GetTopCarsResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/v1/car/message/{message_id}": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add a new car to top cars list",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "mark message as read",
                 "tags": [
-                    "TopCars"
+                    "MESSAGES"
                 ],
-                "summary": "Create a new top car",
+                "summary": "MarkMessageAsRead",
                 "parameters": [
                     {
-                        "description": "Top Car data",
-                        "name": "topcar",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.CreateTopCarRequest"
-                        }
+                        "type": "string",
+                        "description": "message_id",
+                        "name": "message_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.CreateTopCarResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete Message",
+                "tags": [
+                    "MESSAGES"
+                ],
+                "summary": "Delete Message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "message_id",
+                        "name": "message_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/v1/topcar/car/{car_id}": {
+        "/v1/car/photo/car/{car_id}": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete all top cars for a specific car",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Delete Images By Car Id",
                 "tags": [
-                    "TopCars"
+                    "IMAGES"
                 ],
-                "summary": "Delete top cars by car ID",
+                "summary": "DeleteImagesByCarId",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Car ID",
+                        "description": "car_id",
                         "name": "car_id",
                         "in": "path",
                         "required": true
@@ -628,53 +733,50 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.DeleteResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/v1/topcar/user/{user_id}": {
-            "delete": {
+        "/v1/car/photo/uploads/{upload_id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete all top cars belonging to a specific user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the current offset of a resumable upload to continue it after a connection loss",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "TopCars"
+                    "IMAGES"
                 ],
-                "summary": "Delete top cars by user ID",
+                "summary": "GetResumableUpload",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
+                        "description": "upload_id",
+                        "name": "upload_id",
                         "in": "path",
                         "required": true
                     }
@@ -683,17 +785,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.DeleteResponse"
+                            "$ref": "#/definitions/handler.ResumableUploadResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -705,26 +807,26 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/v1/topcar/{id}": {
-            "get": {
-                "description": "Get a specific top car by its ID",
-                "consumes": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Cancel a resumable upload and delete the chunks uploaded so far",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "TopCars"
+                    "IMAGES"
                 ],
-                "summary": "Get top car by ID",
+                "summary": "AbortResumableUpload",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Top Car ID",
-                        "name": "id",
+                        "description": "upload_id",
+                        "name": "upload_id",
                         "in": "path",
                         "required": true
                     }
@@ -733,11 +835,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.TopCarResponse"
+                            "$ref": "#/definitions/handler.MessageResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -756,37 +858,44 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a specific top car by its ID",
+                "description": "Upload the next chunk. Upload-Offset must equal the current offset; every chunk except the last must be exactly chunk_size bytes",
                 "consumes": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "TopCars"
+                    "IMAGES"
                 ],
-                "summary": "Delete top car by ID",
+                "summary": "UploadChunk",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Top Car ID",
-                        "name": "id",
+                        "description": "upload_id",
+                        "name": "upload_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset of the first byte of this chunk",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.MessageResponse"
+                            "$ref": "#/definitions/handler.ResumableUploadResponse"
                         }
                     },
                     "400": {
@@ -807,77 +916,2604 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ResumableUploadConflictResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "cruds.Image": {
-            "type": "object",
-            "properties": {
-                "car_id": {
-                    "type": "string"
-                },
-                "filename": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "uploaded_at": {
-                    "type": "string"
-                }
-            }
-        },
-        "cruds.Message": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "read": {
-                    "type": "boolean"
-                },
-                "recipient_id": {
-                    "type": "string"
-                },
-                "sender_id": {
-                    "type": "string"
-                }
-            }
         },
-        "handler.CreateTopCarRequest": {
-            "type": "object",
-            "required": [
-                "car_id",
-                "category"
-            ],
-            "properties": {
-                "car_id": {
-                    "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
-                },
-                "category": {
-                    "type": "string",
-                    "example": "daily,weekly,monthly"
+        "/v1/car/photo/uploads/{upload_id}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Finish a resumable upload once all bytes are sent: the file is validated, cleaned from metadata, resized into variants and attached to the car.\nAfter a 5xx response the upload is kept and the request can be retried",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "CompleteResumableUpload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "upload_id",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadedPhotoResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ResumableUploadConflictResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Same photo is being deleted, retry",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handler.CreateTopCarResponse": {
-            "type": "object",
+        "/v1/car/photo/{car_id}": {
+            "get": {
+                "description": "it will Get Car Photos in display order, cover first. variants holds URLs of the resized copies (thumb, medium, large) when they exist",
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "Get Car Photos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car_id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.CarImageResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Upload Car Photos. Send one photo in \"file\" or several photos in \"files\" (repeat the field).\nA single file keeps the old response, several files return per-file results (207 when only some of them failed).\nIdentical files are stored once: \"duplicate\" is true when the same content was already uploaded",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "CreatePhoto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car_id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "UploadMediaForm",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "file"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Multiple photos",
+                        "name": "files",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadPhotosResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadPhotosResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "File is larger than the allowed size",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "File content is not an allowed image format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Corrupt image or dimensions too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Same photo is being deleted, retry",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/car/photo/{car_id}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Finish a presigned upload: the stored object is validated, cleaned from metadata, resized into variants and attached to the car",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "CompletePhotoUpload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car_id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "upload_id from the upload-url response",
+                        "name": "upload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CompletePhotoUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadedPhotoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Same photo is being deleted, retry",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/car/photo/{car_id}/cover": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Choose the cover photo of a car. The cover is returned first by the photo list and shown in listings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "SetPhotoCover",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car_id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Photo id",
+                        "name": "cover",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SetPhotoCoverRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.CarImageResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/car/photo/{car_id}/order": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Set the display order of car photos. Every id must belong to the car; photos not listed keep their upload order after the listed ones",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "SetPhotoOrder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car_id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Photo ids in display order",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SetPhotoOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.CarImageResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/car/photo/{car_id}/upload-url": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a presigned URL to PUT a photo directly to storage. Send the returned headers unchanged, then call the complete endpoint with upload_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "CreatePhotoUploadURL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car_id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Photo content type and size in bytes",
+                        "name": "upload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.PhotoUploadURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PhotoUploadURLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/car/photo/{car_id}/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start a resumable photo upload. Send the file with PATCH in chunks of chunk_size bytes (the last one may be smaller), then call complete",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "CreateResumableUpload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car_id",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Photo content type and total size in bytes",
+                        "name": "upload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateResumableUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ResumableUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/car/photo/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete Image",
+                "tags": [
+                    "IMAGES"
+                ],
+                "summary": "DeleteImage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/internal/topcar/car/{car_id}/retire": {
+            "post": {
+                "description": "Called by the cruds service when a car is deleted or marked unavailable. Soft-deletes all promotions of the car with the given reason",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Internal"
+                ],
+                "summary": "Retire top cars of a car (internal)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Internal API key",
+                        "name": "X-Internal-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Car ID",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Retire reason",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RetireTopCarsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeleteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/media/{bucket}/{key}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Redirect to a stored object after checking the bucket visibility. Public buckets redirect to the permanent URL\nand need no token, other buckets (authenticated, owner, private) redirect to a signed URL that expires.\nSend redirect=false to get the URL as JSON instead of a redirect",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MEDIA"
+                ],
+                "summary": "GetMedia",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket name",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Object key, may contain slashes",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "false returns the URL as JSON (default true)",
+                        "name": "redirect",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MediaURLResponse"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the object URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notification/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get notification preferences of the current user (defaults are returned if nothing is saved yet)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "NotificationPreferences"
+                ],
+                "summary": "Get notification preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.NotificationPreferences"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update notification preferences of the current user. Omitted fields keep their current values",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "NotificationPreferences"
+                ],
+                "summary": "Update notification preferences",
+                "parameters": [
+                    {
+                        "description": "Notification preferences",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateNotificationPreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.NotificationPreferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/payment/webhook/{provider}": {
+            "post": {
+                "description": "Callback endpoint for the payment provider. The body must be signed (X-Signature header) and confirms or rejects the payment of a pending top car promotion\nor of a pending reschedule/extension of a promotion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payments"
+                ],
+                "summary": "Payment provider webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex HMAC-SHA256 signature of the body",
+                        "name": "X-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.WebhookEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar": {
+            "get": {
+                "description": "Get filtered list of top cars. By default only promotions whose window covers the current time are returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Get list of top cars",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category filter (daily, weekly, monthly)",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID filter",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Car ID filter",
+                        "name": "car_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by (finished_at_asc, finished_at_desc, created_at_asc, created_at_desc, rotation). rotation shuffles promotions fairly and keeps the order stable within a time bucket",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Show expired top cars",
+                        "name": "show_expired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Show top cars whose promotion has not started yet",
+                        "name": "show_scheduled",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Show deleted top cars",
+                        "name": "show_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Show top cars that are not paid yet",
+                        "name": "show_unpaid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit results (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Skip results for pagination (ignored when cursor is set)",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to car to embed car details (promotions of deleted or unavailable cars are dropped)",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.GetTopCarsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add a new car to top cars list. Promotion starts at starts_at (or now) and lasts for the category preset or duration_hours for the custom category.\nstarts_at in the past is rejected; up to 5 minutes behind the server clock is treated as now\nThe promotion is created as pending and becomes visible only after the payment is confirmed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Create a new top car",
+                "parameters": [
+                    {
+                        "description": "Top Car data",
+                        "name": "topcar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateTopCarRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateTopCarResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/analytics": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Counts of created, active (started), expired and early deleted promotions grouped by category and by day, week or month over [from, to), plus top sellers by promotion count",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Top car analytics (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Range start, RFC3339 or YYYY-MM-DD (default 30 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Range end (exclusive), RFC3339 or YYYY-MM-DD (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Grouping interval: day, week (ISO) or month (default day)",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA timezone used for dates and periods (default UTC)",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of top sellers (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarAnalyticsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/car/{car_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete all top cars for a specific car",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Delete top cars by car ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Car ID",
+                        "name": "car_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeleteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/cleanup": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Soft delete every finished promotion right away instead of waiting for the background job.\nDELETE /v1/topcar/cleanup is kept as an alias of this endpoint",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Force top car cleanup (admin)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeleteResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/user/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete all top cars belonging to a specific user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Delete top cars by user ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeleteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/{id}": {
+            "get": {
+                "description": "Get a specific top car by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Get top car by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Top Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change category/duration of an owned promotion. starts_at can only be changed while the promotion has not started yet.\nIf the new price is higher than the paid amount, a payment for the difference is opened (202) and the new window\nis applied only after the payment webhook confirms it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Update top car",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Top Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Top Car data",
+                        "name": "topcar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateTopCarRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarChangeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a specific top car by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Delete top car by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Top Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/{id}/click": {
+            "post": {
+                "description": "Record a click on a promoted car. Repeated clicks from the same session (X-Session-Id header)\nor client IP are counted once per promotion within TOPCAR_CLICK_DEDUP_MINUTES.\nOnly active promotions within their window are counted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Record top car click",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Top Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client session ID, client IP is used when not set",
+                        "name": "X-Session-Id",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/{id}/extend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Push finished_at of an owned, not deleted and not expired promotion forward by a category duration.\nPaid extensions open a payment (202) and finished_at moves only after the payment webhook confirms it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Extend top car",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Top Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Extension data",
+                        "name": "extension",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ExtendTopCarRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarChangeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Undo the owner's own delete within the restore grace period. Promotions retired by the system or already finished can not be restored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Restore deleted top car",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Top Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/topcar/{id}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get impressions, clicks and CTR per day for an owned promotion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TopCars"
+                ],
+                "summary": "Get top car stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Top Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From day (YYYY-MM-DD), default 30 days ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To day (YYYY-MM-DD), default today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TopCarStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "cruds.Message": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "read": {
+                    "type": "boolean"
+                },
+                "recipient_id": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.AdminAuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AdminAuditLog"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "skip": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "handler.AdminGetTopCarsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "skip": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "top_cars": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.AdminTopCarResponse"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "handler.AdminTopCarResponse": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "description": "jami to'langan, minor birliklarda (cent, tiyin)",
+                    "type": "integer",
+                    "example": 500
+                },
+                "base_amount_minor": {
+                    "description": "uzaytirishlarsiz asosiy oraliq narxi",
+                    "type": "integer",
+                    "example": 500
+                },
+                "car": {
+                    "$ref": "#/definitions/model.CarSummary"
+                },
+                "car_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "daily"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "delete_reason": {
+                    "type": "string",
+                    "example": "car_deleted"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "example": "2024-01-03T12:00:00Z"
+                },
+                "extensions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TopCarExtension"
+                    }
+                },
+                "finished_at": {
+                    "type": "string",
+                    "example": "2024-01-02T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "owner": {
+                    "$ref": "#/definitions/handler.TopCarOwner"
+                },
+                "paid_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "payment_id": {
+                    "type": "string",
+                    "example": "pay_123"
+                },
+                "pending_change": {
+                    "$ref": "#/definitions/model.TopCarChange"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                }
+            }
+        },
+        "handler.CarImageResponse": {
+            "type": "object",
+            "properties": {
+                "car_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "http://localhost:9000/photos/123e4567_original.jpg"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "is_cover": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "uploaded_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "variants": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.CompletePhotoUploadRequest": {
+            "type": "object",
+            "required": [
+                "upload_id"
+            ],
+            "properties": {
+                "upload_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handler.CreateResumableUploadRequest": {
+            "type": "object",
+            "required": [
+                "content_type",
+                "size"
+            ],
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 8388608
+                }
+            }
+        },
+        "handler.CreateTopCarRequest": {
+            "type": "object",
+            "required": [
+                "car_id",
+                "category"
+            ],
+            "properties": {
+                "car_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "daily,weekly,monthly,custom"
+                },
+                "duration_hours": {
+                    "description": "faqat custom category uchun",
+                    "type": "integer",
+                    "example": 72
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                }
+            }
+        },
+        "handler.CreateTopCarResponse": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "description": "minor birliklarda (cent, tiyin)",
+                    "type": "integer",
+                    "example": 500
+                },
+                "car_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "daily"
+                },
+                "checkout_url": {
+                    "type": "string",
+                    "example": "https://pay.example.com/checkout/3f6c2a7e"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "finished_at": {
+                    "type": "string",
+                    "example": "2024-01-02T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Top car created successfully"
+                },
+                "payment_id": {
+                    "type": "string",
+                    "example": "3f6c2a7e-1b2d-4c5e-8f9a-0b1c2d3e4f5a"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                },
+                "waitlisted": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.DeleteResponse": {
+            "type": "object",
+            "properties": {
+                "deleted_count": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Top cars deleted successfully"
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Error description"
+                }
+            }
+        },
+        "handler.ExtendTopCarRequest": {
+            "type": "object",
+            "required": [
+                "category"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "weekly"
+                },
+                "duration_hours": {
+                    "description": "faqat custom category uchun",
+                    "type": "integer",
+                    "example": 72
+                }
+            }
+        },
+        "handler.GetTopCarsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJmIjoiZmluaXNoZWRfYXQiLCJkIjoxfQ"
+                },
+                "skip": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "top_cars": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.TopCarResponse"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "handler.GrantTopCarRequest": {
+            "type": "object",
+            "required": [
+                "car_id",
+                "category"
+            ],
+            "properties": {
+                "car_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "weekly"
+                },
+                "duration_hours": {
+                    "description": "faqat custom category uchun",
+                    "type": "integer",
+                    "example": 72
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "compensation for outage"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                }
+            }
+        },
+        "handler.MediaURLResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "public bucketlarda yo'q",
+                    "type": "string",
+                    "example": "2024-01-01T12:10:00Z"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:9000/chat/123e4567.jpg?X-Amz-Signature=..."
+                }
+            }
+        },
+        "handler.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Operation completed successfully"
+                }
+            }
+        },
+        "handler.PaymentRefundsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "refunds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PaymentRefund"
+                    }
+                },
+                "skip": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "handler.PhotoUploadResult": {
+            "type": "object",
+            "properties": {
+                "duplicate": {
+                    "description": "Duplicate - xuddi shu fayl avval yuklangan, storage'dagi mavjud obyekt ishlatildi",
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Error creating photo"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "front.jpg"
+                },
+                "photo_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:9000/photos/123e4567.jpg"
+                }
+            }
+        },
+        "handler.PhotoUploadURLRequest": {
+            "type": "object",
+            "required": [
+                "content_type",
+                "size"
+            ],
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 2048000
+                }
+            }
+        },
+        "handler.PhotoUploadURLResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:15:00Z"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "PUT"
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:9000/photos-staging/..."
+                }
+            }
+        },
+        "handler.ResumableUploadConflictResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "upload offset does not match the session offset"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 5242880
+                }
+            }
+        },
+        "handler.ResumableUploadResponse": {
+            "type": "object",
+            "properties": {
+                "car_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "chunk_size": {
+                    "type": "integer",
+                    "example": 5242880
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-02T12:00:00Z"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 5242880
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handler.RetireTopCarsRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "car_deleted, car_unavailable",
+                    "type": "string",
+                    "example": "car_deleted"
+                }
+            }
+        },
+        "handler.SetPhotoCoverRequest": {
+            "type": "object",
+            "required": [
+                "image_id"
+            ],
+            "properties": {
+                "image_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handler.SetPhotoOrderRequest": {
+            "type": "object",
+            "required": [
+                "image_ids"
+            ],
+            "properties": {
+                "image_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.TopCarAnalyticsResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "day"
+                },
+                "series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TopCarAnalyticsBucket"
+                    }
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "UTC"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2024-01-31T00:00:00Z"
+                },
+                "top_sellers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.TopCarSellerResponse"
+                    }
+                },
+                "totals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TopCarAnalyticsBucket"
+                    }
+                }
+            }
+        },
+        "handler.TopCarChangeResponse": {
+            "type": "object",
             "properties": {
+                "amount_minor": {
+                    "description": "jami to'langan, minor birliklarda (cent, tiyin)",
+                    "type": "integer",
+                    "example": 500
+                },
+                "base_amount_minor": {
+                    "description": "uzaytirishlarsiz asosiy oraliq narxi",
+                    "type": "integer",
+                    "example": 500
+                },
+                "car": {
+                    "$ref": "#/definitions/model.CarSummary"
+                },
                 "car_id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
@@ -886,10 +3522,32 @@ const docTemplate = `{
                     "type": "string",
                     "example": "daily"
                 },
+                "checkout_url": {
+                    "type": "string",
+                    "example": "https://pay.example.com/checkout/3f6c2a7e"
+                },
                 "created_at": {
                     "type": "string",
                     "example": "2024-01-01T12:00:00Z"
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "delete_reason": {
+                    "type": "string",
+                    "example": "car_deleted"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "example": "2024-01-03T12:00:00Z"
+                },
+                "extensions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TopCarExtension"
+                    }
+                },
                 "finished_at": {
                     "type": "string",
                     "example": "2024-01-02T12:00:00Z"
@@ -900,7 +3558,143 @@ const docTemplate = `{
                 },
                 "message": {
                     "type": "string",
-                    "example": "Top car created successfully"
+                    "example": "Change is waiting for payment"
+                },
+                "paid_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "pending_change": {
+                    "$ref": "#/definitions/model.TopCarChange"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                }
+            }
+        },
+        "handler.TopCarDayStats": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer",
+                    "example": 36
+                },
+                "ctr": {
+                    "type": "number",
+                    "example": 0.03
+                },
+                "day": {
+                    "type": "string",
+                    "example": "2024-01-01"
+                },
+                "impressions": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "handler.TopCarOwner": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "phone_number": {
+                    "type": "string",
+                    "example": "+998901234567"
+                },
+                "surname": {
+                    "type": "string",
+                    "example": "Doe"
+                }
+            }
+        },
+        "handler.TopCarResponse": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "description": "jami to'langan, minor birliklarda (cent, tiyin)",
+                    "type": "integer",
+                    "example": 500
+                },
+                "base_amount_minor": {
+                    "description": "uzaytirishlarsiz asosiy oraliq narxi",
+                    "type": "integer",
+                    "example": 500
+                },
+                "car": {
+                    "$ref": "#/definitions/model.CarSummary"
+                },
+                "car_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "daily"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "delete_reason": {
+                    "type": "string",
+                    "example": "car_deleted"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "example": "2024-01-03T12:00:00Z"
+                },
+                "extensions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TopCarExtension"
+                    }
+                },
+                "finished_at": {
+                    "type": "string",
+                    "example": "2024-01-02T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "paid_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "pending_change": {
+                    "$ref": "#/definitions/model.TopCarChange"
+                },
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
                 },
                 "user_id": {
                     "type": "string",
@@ -908,90 +3702,308 @@ const docTemplate = `{
                 }
             }
         },
-        "handler.DeleteResponse": {
+        "handler.TopCarSellerResponse": {
             "type": "object",
             "properties": {
-                "deleted_count": {
-                    "type": "integer",
-                    "example": 5
+                "amount_minor": {
+                    "type": "integer"
                 },
-                "message": {
-                    "type": "string",
-                    "example": "Top cars deleted successfully"
+                "owner": {
+                    "$ref": "#/definitions/handler.TopCarOwner"
+                },
+                "promotions": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "handler.ErrorResponse": {
+        "handler.TopCarStatsResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "ctr": {
+                    "type": "number",
+                    "example": 0.03
+                },
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.TopCarDayStats"
+                    }
+                },
+                "top_car_id": {
                     "type": "string",
-                    "example": "Error description"
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "total_clicks": {
+                    "type": "integer",
+                    "example": 36
+                },
+                "total_impressions": {
+                    "type": "integer",
+                    "example": 1200
                 }
             }
         },
-        "handler.GetTopCarsResponse": {
+        "handler.UpdateNotificationPreferencesRequest": {
             "type": "object",
             "properties": {
-                "limit": {
+                "chat_message": {
+                    "$ref": "#/definitions/model.ChannelPreferences"
+                },
+                "quiet_hours": {
+                    "$ref": "#/definitions/model.QuietHours"
+                },
+                "saved_car_price_change": {
+                    "$ref": "#/definitions/model.ChannelPreferences"
+                },
+                "top_car_expiry": {
+                    "$ref": "#/definitions/model.ChannelPreferences"
+                }
+            }
+        },
+        "handler.UpdateTopCarRequest": {
+            "type": "object",
+            "required": [
+                "category"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "weekly"
+                },
+                "duration_hours": {
+                    "description": "faqat custom category uchun",
                     "type": "integer",
-                    "example": 50
+                    "example": 72
                 },
-                "skip": {
+                "starts_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                }
+            }
+        },
+        "handler.UploadPhotosResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
                     "type": "integer",
-                    "example": 0
+                    "example": 1
                 },
-                "top_cars": {
+                "results": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handler.TopCarResponse"
+                        "$ref": "#/definitions/handler.PhotoUploadResult"
                     }
                 },
-                "total": {
+                "uploaded": {
                     "type": "integer",
-                    "example": 100
+                    "example": 19
                 }
             }
         },
-        "handler.MessageResponse": {
+        "handler.UploadedPhotoResponse": {
             "type": "object",
             "properties": {
-                "message": {
+                "car_id": {
                     "type": "string",
-                    "example": "Operation completed successfully"
+                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                },
+                "duplicate": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "http://localhost:9000/photos/123e4567_original.jpg"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "is_cover": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "uploaded_at": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "variants": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "handler.TopCarResponse": {
+        "model.AdminAuditLog": {
             "type": "object",
             "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "admin_id": {
+                    "type": "string"
+                },
                 "car_id": {
-                    "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                    "type": "string"
                 },
-                "category": {
-                    "type": "string",
-                    "example": "daily"
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "top_car_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CarSummary": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "description": "birinchi rasm",
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "make": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ChannelPreferences": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "boolean"
+                },
+                "in_app": {
+                    "type": "boolean"
+                },
+                "push": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.NotificationPreferences": {
+            "type": "object",
+            "properties": {
+                "chat_message": {
+                    "$ref": "#/definitions/model.ChannelPreferences"
                 },
                 "created_at": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00Z"
+                    "type": "string"
                 },
-                "deleted_at": {
-                    "type": "string",
-                    "example": "2024-01-03T12:00:00Z"
+                "id": {
+                    "type": "string"
                 },
-                "finished_at": {
-                    "type": "string",
-                    "example": "2024-01-02T12:00:00Z"
+                "quiet_hours": {
+                    "$ref": "#/definitions/model.QuietHours"
+                },
+                "saved_car_price_change": {
+                    "$ref": "#/definitions/model.ChannelPreferences"
+                },
+                "top_car_expiry": {
+                    "$ref": "#/definitions/model.ChannelPreferences"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PaymentRefund": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "description": "minor birliklarda (cent, tiyin)",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
                 },
                 "id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "refunded_at": {
+                    "type": "string"
+                },
+                "refunded_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"required\", \"refunded\"",
+                    "type": "string"
+                },
+                "top_car_id": {
+                    "type": "string"
                 },
                 "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.QuietHours": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "end": {
+                    "description": "\"HH:MM\"",
                     "type": "string",
-                    "example": "123e4567-e89b-12d3-a456-426614174001"
+                    "example": "07:00"
+                },
+                "start": {
+                    "description": "\"HH:MM\"",
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "timezone": {
+                    "description": "IANA timezone",
+                    "type": "string",
+                    "example": "Asia/Tashkent"
                 }
             }
         },
@@ -1009,6 +4021,108 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "model.TopCarAnalyticsBucket": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "deleted": {
+                    "type": "integer"
+                },
+                "expired": {
+                    "type": "integer"
+                },
+                "period": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TopCarChange": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "description": "narx farqi, minor birliklarda",
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "\"reschedule\", \"extend\"",
+                    "type": "string"
+                },
+                "paid_at": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "previous_finished_at": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TopCarExtension": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "extended_at": {
+                    "type": "string"
+                },
+                "extended_by": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "previous_finished_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "payment.WebhookEvent": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "description": "minor birliklarda (cent, tiyin)",
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"succeeded\", \"failed\"",
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
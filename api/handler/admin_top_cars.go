@@ -0,0 +1,461 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wegugin/api/auth"
+	"wegugin/genproto/cruds"
+	"wegugin/genproto/user"
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminCleanupTopCars godoc
+// @Summary Force top car cleanup (admin)
+// @Description Soft delete every finished promotion right away instead of waiting for the background job.
+// @Description DELETE /v1/topcar/cleanup is kept as an alias of this endpoint
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} DeleteResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/admin/topcar/cleanup [delete]
+// @Router /v1/topcar/cleanup [delete]
+func (h *Handler) AdminCleanupTopCars(c *gin.Context) {
+	adminID, ok := h.getAdminID(c)
+	if !ok {
+		return
+	}
+
+	deletedCount, err := h.Cruds.TopCars().DeleteFinishedTopCars(c.Request.Context())
+	if err != nil {
+		h.Log.Error("Failed to cleanup finished top cars", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to cleanup top cars",
+		})
+		return
+	}
+
+	h.writeAdminAudit(c.Request.Context(), &model.AdminAuditLog{
+		AdminId: adminID,
+		Action:  model.AdminActionTopCarCleanup,
+		Details: map[string]string{"deleted_count": strconv.FormatInt(deletedCount, 10)},
+	})
+
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message:      "Finished top cars deleted successfully",
+		DeletedCount: deletedCount,
+	})
+}
+
+// AdminGetTopCars godoc
+// @Summary List all top cars (admin)
+// @Description List promotions in any state, including deleted, expired, scheduled and unpaid ones, with owner details
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param category query string false "Category filter (daily, weekly, monthly, custom)"
+// @Param user_id query string false "User ID filter"
+// @Param car_id query string false "Car ID filter"
+// @Param sort_by query string false "Sort by (finished_at_asc, finished_at_desc, created_at_asc, created_at_desc)"
+// @Param limit query int false "Limit results (default 50)"
+// @Param skip query int false "Skip results for pagination"
+// @Success 200 {object} AdminGetTopCarsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/admin/topcar [get]
+func (h *Handler) AdminGetTopCars(c *gin.Context) {
+	filter := model.TopCarsFilter{
+		Category:      c.Query("category"),
+		UserID:        c.Query("user_id"),
+		CarID:         c.Query("car_id"),
+		SortBy:        c.Query("sort_by"),
+		ShowExpired:   true,
+		ShowScheduled: true,
+		ShowDeleted:   true,
+		ShowUnpaid:    true,
+	}
+	if filter.SortBy == "rotation" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "rotation sort is not supported in admin listing",
+		})
+		return
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.ParseInt(limitStr, 10, 64); err == nil {
+			filter.Limit = limit
+		}
+	}
+	if skipStr := c.Query("skip"); skipStr != "" {
+		if skip, err := strconv.ParseInt(skipStr, 10, 64); err == nil {
+			filter.Skip = skip
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50 // default limit
+	}
+
+	topCars, err := h.Cruds.TopCars().GetListOfTopCars(c.Request.Context(), filter)
+	if err != nil {
+		h.Log.Error("Failed to get top cars", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get top cars",
+		})
+		return
+	}
+
+	count, err := h.Cruds.TopCars().CountTopCars(c.Request.Context(), filter)
+	if err != nil {
+		h.Log.Error("Failed to count top cars", "error", err)
+		count = 0
+	}
+
+	userIDs := make([]string, 0, len(topCars))
+	for _, topCar := range topCars {
+		userIDs = append(userIDs, topCar.UserId)
+	}
+	owners := h.getTopCarOwners(c.Request.Context(), userIDs)
+
+	response := make([]AdminTopCarResponse, 0, len(topCars))
+	for _, topCar := range topCars {
+		response = append(response, AdminTopCarResponse{
+			TopCarResponse: toTopCarResponse(topCar),
+			PaymentId:      topCar.PaymentId,
+			Owner:          owners[topCar.UserId],
+		})
+	}
+
+	c.JSON(http.StatusOK, AdminGetTopCarsResponse{
+		TopCars: response,
+		Total:   count,
+		Limit:   filter.Limit,
+		Skip:    filter.Skip,
+	})
+}
+
+// AdminRestoreTopCar godoc
+// @Summary Restore deleted top car (admin)
+// @Description Undo a soft delete. Fails when the promotion window overlaps with another promotion of the same car
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Top Car ID"
+// @Success 200 {object} TopCarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/admin/topcar/{id}/restore [post]
+func (h *Handler) AdminRestoreTopCar(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid ID format",
+		})
+		return
+	}
+
+	adminID, ok := h.getAdminID(c)
+	if !ok {
+		return
+	}
+
+	restored, err := h.Cruds.TopCars().RestoreTopCar(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deleted top car not found",
+			})
+		case errors.Is(err, repo.ErrTopCarOverlap):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Car already has a top promotion overlapping this time window",
+			})
+		case errors.Is(err, repo.ErrTopCarBusy):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Promotions of this car are being changed, please retry",
+			})
+		default:
+			h.Log.Error("Failed to restore top car", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to restore top car",
+			})
+		}
+		return
+	}
+
+	h.writeAdminAudit(c.Request.Context(), &model.AdminAuditLog{
+		AdminId:  adminID,
+		Action:   model.AdminActionTopCarRestore,
+		TopCarId: restored.ID.Hex(),
+		CarId:    restored.CarId,
+	})
+
+	c.JSON(http.StatusOK, toTopCarResponse(restored))
+}
+
+// AdminGrantTopCar godoc
+// @Summary Grant complimentary top car (admin)
+// @Description Create a free promotion for any car on behalf of its owner. It skips payment but still waits for a free category slot
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param grant body GrantTopCarRequest true "Grant data"
+// @Success 201 {object} TopCarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/admin/topcar/grant [post]
+func (h *Handler) AdminGrantTopCar(c *gin.Context) {
+	var req GrantTopCarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	adminID, ok := h.getAdminID(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil && req.StartsAt.After(now) {
+		startsAt = *req.StartsAt
+	}
+
+	duration, err := h.topCarDuration(req.Category, req.DurationHours)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	// Promotion car egasi nomidan yaratiladi
+	car, err := h.Crud.GetCarById(c.Request.Context(), &cruds.Id{Id: req.CarId})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Car not found",
+			})
+			return
+		}
+		h.Log.Error("Failed to get car", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get car",
+		})
+		return
+	}
+
+	topCar := &model.TopCars{
+		CarId:      car.Id,
+		UserId:     car.OwnerId,
+		Category:   req.Category,
+		CreatedAt:  now,
+		StartsAt:   startsAt,
+		FinishedAt: startsAt.Add(duration),
+		Status:     model.TopCarStatusPaid,
+		Amount:     0,
+		Currency:   h.Config.TopCar.CURRENCY,
+		PaidAt:     &now,
+	}
+
+	err = h.Cruds.TopCars().CreateTopCar(c.Request.Context(), topCar)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrTopCarOverlap):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Car already has a top promotion overlapping this time window",
+			})
+		case errors.Is(err, repo.ErrTopCarBusy):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Promotions of this car are being changed, please retry",
+			})
+		default:
+			h.Log.Error("Failed to grant top car", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to grant top car",
+			})
+		}
+		return
+	}
+
+	h.activateTopCars(c.Request.Context())
+
+	details := map[string]string{
+		"category":    topCar.Category,
+		"starts_at":   topCar.StartsAt.Format(time.RFC3339),
+		"finished_at": topCar.FinishedAt.Format(time.RFC3339),
+	}
+	if req.Reason != "" {
+		details["reason"] = req.Reason
+	}
+	h.writeAdminAudit(c.Request.Context(), &model.AdminAuditLog{
+		AdminId:  adminID,
+		Action:   model.AdminActionTopCarGrant,
+		TopCarId: topCar.ID.Hex(),
+		CarId:    topCar.CarId,
+		Details:  details,
+	})
+
+	c.JSON(http.StatusCreated, toTopCarResponse(topCar))
+}
+
+// AdminGetAuditLog godoc
+// @Summary Admin audit log
+// @Description List admin actions on top car promotions, newest first
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param admin_id query string false "Admin user ID filter"
+// @Param action query string false "Action filter (topcar_cleanup, topcar_restore, topcar_grant, payment_refunded)"
+// @Param top_car_id query string false "Top Car ID filter"
+// @Param limit query int false "Limit results (default 50)"
+// @Param skip query int false "Skip results for pagination"
+// @Success 200 {object} AdminAuditLogResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/admin/topcar/audit [get]
+func (h *Handler) AdminGetAuditLog(c *gin.Context) {
+	filter := model.AdminAuditFilter{
+		AdminID:  c.Query("admin_id"),
+		Action:   c.Query("action"),
+		TopCarID: c.Query("top_car_id"),
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.ParseInt(limitStr, 10, 64); err == nil {
+			filter.Limit = limit
+		}
+	}
+	if skipStr := c.Query("skip"); skipStr != "" {
+		if skip, err := strconv.ParseInt(skipStr, 10, 64); err == nil {
+			filter.Skip = skip
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50 // default limit
+	}
+
+	entries, total, err := h.Cruds.AdminAudit().GetAuditLogs(c.Request.Context(), filter)
+	if err != nil {
+		h.Log.Error("Failed to get admin audit log", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get audit log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, AdminAuditLogResponse{
+		Entries: entries,
+		Total:   total,
+		Limit:   filter.Limit,
+		Skip:    filter.Skip,
+	})
+}
+
+// getAdminID - tokendan admin user ID olish. Xatolik bo'lsa javob yozilgan bo'ladi va ok=false qaytadi
+func (h *Handler) getAdminID(c *gin.Context) (string, bool) {
+	adminID, _, err := auth.GetUserIdFromToken(c.GetHeader("Authorization"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Invalid token",
+		})
+		return "", false
+	}
+	return adminID, true
+}
+
+// writeAdminAudit - admin amalini audit logga yozish. Amal bajarilib bo'lgani uchun
+// yozishdagi xatolik faqat logga chiqariladi
+func (h *Handler) writeAdminAudit(ctx context.Context, entry *model.AdminAuditLog) {
+	if err := h.Cruds.AdminAudit().CreateAuditLog(ctx, entry); err != nil {
+		h.Log.Error("Failed to write admin audit log", "action", entry.Action, "error", err)
+	}
+}
+
+// getTopCarOwners - user servisidan promotion egalari ma'lumotlarini olish (har bir user bir marta).
+// Topilmagan yoki xatolik bergan userlar natijaga kirmaydi
+func (h *Handler) getTopCarOwners(ctx context.Context, userIDs []string) map[string]*TopCarOwner {
+	owners := make(map[string]*TopCarOwner, len(userIDs))
+	for _, id := range userIDs {
+		if _, done := owners[id]; done || id == "" {
+			continue
+		}
+
+		info, err := h.User.GetUserById(ctx, &user.UserId{Id: id})
+		if err != nil {
+			h.Log.Warn("Failed to get top car owner", "user_id", id, "error", err)
+			owners[id] = nil
+			continue
+		}
+		owners[id] = &TopCarOwner{
+			Id:          info.Id,
+			Name:        info.Name,
+			Surname:     info.Surname,
+			Email:       info.Email,
+			PhoneNumber: info.PhoneNumber,
+		}
+	}
+	return owners
+}
+
+type TopCarOwner struct {
+	Id          string `json:"id" example:"123e4567-e89b-12d3-a456-426614174001"`
+	Name        string `json:"name" example:"John"`
+	Surname     string `json:"surname" example:"Doe"`
+	Email       string `json:"email" example:"john@example.com"`
+	PhoneNumber string `json:"phone_number" example:"+998901234567"`
+}
+
+type AdminTopCarResponse struct {
+	TopCarResponse
+	PaymentId string       `json:"payment_id,omitempty" example:"pay_123"`
+	Owner     *TopCarOwner `json:"owner,omitempty"`
+}
+
+type AdminGetTopCarsResponse struct {
+	TopCars []AdminTopCarResponse `json:"top_cars"`
+	Total   int64                 `json:"total" example:"100"`
+	Limit   int64                 `json:"limit" example:"50"`
+	Skip    int64                 `json:"skip" example:"0"`
+}
+
+type GrantTopCarRequest struct {
+	CarId         string     `json:"car_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Category      string     `json:"category" binding:"required" example:"weekly"`
+	StartsAt      *time.Time `json:"starts_at,omitempty" example:"2024-01-01T12:00:00Z"`
+	DurationHours int        `json:"duration_hours,omitempty" example:"72"` // faqat custom category uchun
+	Reason        string     `json:"reason,omitempty" example:"compensation for outage"`
+}
+
+type AdminAuditLogResponse struct {
+	Entries []*model.AdminAuditLog `json:"entries"`
+	Total   int64                  `json:"total" example:"10"`
+	Limit   int64                  `json:"limit" example:"50"`
+	Skip    int64                  `json:"skip" example:"0"`
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"wegugin/config"
+	"wegugin/genproto/cruds"
+	"wegugin/model"
+	"wegugin/storage"
+	"wegugin/storage/repo"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeTopCars - webhook ishlatadigan metodlarning xotiradagi implementatsiyasi
+type fakeTopCars struct {
+	repo.ITopCarsStorage
+	cars     []*model.TopCars
+	applyErr error // berilsa ApplyPendingChange shu xatoni qaytaradi
+}
+
+func (f *fakeTopCars) GetByPaymentID(ctx context.Context, paymentID string) (*model.TopCars, error) {
+	for _, car := range f.cars {
+		if car.PaymentId == paymentID || (car.PendingChange != nil && car.PendingChange.PaymentId == paymentID) {
+			return car, nil
+		}
+		for _, change := range car.AppliedChanges {
+			if change.PaymentId == paymentID {
+				return car, nil
+			}
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTopCars) MarkTopCarPaid(ctx context.Context, paymentID string, paidAt time.Time) (*model.TopCars, error) {
+	for _, car := range f.cars {
+		if car.PaymentId == paymentID && car.Status == model.TopCarStatusPending && car.DeletedAt == nil {
+			car.Status = model.TopCarStatusPaid
+			car.PaidAt = &paidAt
+			return car, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTopCars) CancelTopCarPayment(ctx context.Context, paymentID string) error {
+	for _, car := range f.cars {
+		if car.PaymentId == paymentID && car.Status == model.TopCarStatusPending && car.DeletedAt == nil {
+			now := time.Now()
+			car.Status = model.TopCarStatusCancelled
+			car.DeletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *fakeTopCars) ApplyPendingChange(ctx context.Context, paymentID string, paidAt time.Time) (*model.TopCars, error) {
+	if f.applyErr != nil {
+		return nil, f.applyErr
+	}
+	for _, car := range f.cars {
+		change := car.PendingChange
+		if change == nil || change.PaymentId != paymentID {
+			continue
+		}
+		change.PaidAt = &paidAt
+		car.Category, car.StartsAt, car.FinishedAt = change.Category, change.StartsAt, change.FinishedAt
+		car.Amount += change.Amount
+		if change.Kind == model.TopCarChangeReschedule {
+			car.BaseAmount += change.Amount
+		}
+		car.AppliedChanges = append(car.AppliedChanges, *change)
+		car.PendingChange = nil
+		return car, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTopCars) ClearPendingChange(ctx context.Context, paymentID string) error {
+	for _, car := range f.cars {
+		if car.PendingChange != nil && car.PendingChange.PaymentId == paymentID {
+			car.PendingChange = nil
+		}
+	}
+	return nil
+}
+
+func (f *fakeTopCars) GetByID(ctx context.Context, id primitive.ObjectID) (*model.TopCars, error) {
+	for _, car := range f.cars {
+		if car.ID == id && car.DeletedAt == nil {
+			return car, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTopCars) UpdateTopCar(ctx context.Context, id primitive.ObjectID, updateData bson.M) error {
+	car, err := f.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if paymentID, ok := updateData["payment_id"].(string); ok {
+		car.PaymentId = paymentID
+	}
+	return nil
+}
+
+func (f *fakeTopCars) ActivateStartedTopCars(ctx context.Context, slots map[string]int) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeTopCars) HasOverlappingTopCar(ctx context.Context, carID string, startsAt, finishedAt time.Time, excludeID primitive.ObjectID) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeTopCars) SetPendingChange(ctx context.Context, id primitive.ObjectID, change model.TopCarChange, staleBefore time.Time) (*model.TopCars, error) {
+	car, err := f.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	car.PendingChange = &change
+	return car, nil
+}
+
+func (f *fakeTopCars) CreateTopCar(ctx context.Context, topCar *model.TopCars) error {
+	topCar.ID = primitive.NewObjectID()
+	f.cars = append(f.cars, topCar)
+	return nil
+}
+
+// fakePaymentRefunds - RequireRefund yozuvlari (payment_id bo'yicha bir marta)
+type fakePaymentRefunds struct {
+	repo.IPaymentRefundsStorage
+	refunds map[string]*model.PaymentRefund
+	err     error // berilsa RequireRefund shu xatoni qaytaradi
+}
+
+func (f *fakePaymentRefunds) RequireRefund(ctx context.Context, refund *model.PaymentRefund) error {
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.refunds[refund.PaymentId]; !ok {
+		refund.Status = model.PaymentRefundStatusRequired
+		f.refunds[refund.PaymentId] = refund
+	}
+	return nil
+}
+
+// fakeCruds - cruds servisi: har bir user har bir carning egasi
+type fakeCruds struct {
+	cruds.CrudsServiceClient
+	images    map[string]*cruds.Image
+	deleteErr error // berilsa DeleteImage shu xatoni qaytaradi
+}
+
+func (f *fakeCruds) CheckCarOwnership(ctx context.Context, in *cruds.BoolCheckCar, opts ...grpc.CallOption) (*cruds.BoolCheck, error) {
+	return &cruds.BoolCheck{Result: true}, nil
+}
+
+func (f *fakeCruds) GetImageByID(ctx context.Context, in *cruds.ImageId, opts ...grpc.CallOption) (*cruds.Image, error) {
+	image, ok := f.images[in.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "image not found")
+	}
+	return image, nil
+}
+
+func (f *fakeCruds) DeleteImage(ctx context.Context, in *cruds.ImageId, opts ...grpc.CallOption) (*cruds.Empty, error) {
+	if f.deleteErr != nil {
+		return nil, f.deleteErr
+	}
+	delete(f.images, in.Id)
+	return &cruds.Empty{}, nil
+}
+
+func (f *fakeCruds) SendMessage(ctx context.Context, in *cruds.SendMessageRequest, opts ...grpc.CallOption) (*cruds.Message, error) {
+	return &cruds.Message{SenderId: in.SenderId, RecipientId: in.RecipientId, Content: in.Content}, nil
+}
+
+// fakePhotoOrders - rasm tartibi saqlanmaydi
+type fakePhotoOrders struct {
+	repo.IPhotoOrdersStorage
+}
+
+func (fakePhotoOrders) RemoveImage(ctx context.Context, carID, imageID string) error { return nil }
+
+type fakeStorage struct {
+	storage.IStorage
+	topCars *fakeTopCars
+	refunds *fakePaymentRefunds
+	redis   *fakeRedis
+}
+
+func (f *fakeStorage) PhotoOrders() repo.IPhotoOrdersStorage { return fakePhotoOrders{} }
+
+func (f *fakeStorage) TopCars() repo.ITopCarsStorage { return f.topCars }
+
+func (f *fakeStorage) PaymentRefunds() repo.IPaymentRefundsStorage { return f.refunds }
+
+func (f *fakeStorage) Redis() repo.IRedisStorage { return f.redis }
+
+// fakeRedis - upload sessiyalarining xotiradagi implementatsiyasi (offset tekshiruvi Redis'dagidek)
+type fakeRedis struct {
+	repo.IRedisStorage
+	mu       sync.Mutex
+	sessions map[string]model.UploadSession
+	clicks   int // hisoblangan clicklar
+	// beforeAdvance - parallel so'rovni simulyatsiya qilish uchun AdvanceUploadSession boshida chaqiriladi
+	beforeAdvance func()
+}
+
+func (f *fakeRedis) GetUploadSession(ctx context.Context, id string) (*model.UploadSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[id]
+	if !ok {
+		return nil, repo.ErrUploadSessionNotFound
+	}
+	session.Parts = append([]model.UploadPart(nil), session.Parts...)
+	return &session, nil
+}
+
+func (f *fakeRedis) IncrTopCarClick(ctx context.Context, topCarID, visitor string, window time.Duration, at time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clicks++
+	return true, nil
+}
+
+func (f *fakeRedis) DeleteCarSummary(ctx context.Context, carID string) error { return nil }
+
+func (f *fakeRedis) AdvanceUploadSession(ctx context.Context, id string, expectedOffset, chunkSize int64, part model.UploadPart, expiresAt time.Time) (*model.UploadSession, error) {
+	if f.beforeAdvance != nil {
+		f.beforeAdvance()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[id]
+	if !ok {
+		return nil, repo.ErrUploadSessionNotFound
+	}
+	if session.Offset != expectedOffset {
+		return nil, repo.ErrUploadOffsetMismatch
+	}
+	session.Offset += chunkSize
+	session.Parts = append(session.Parts, part)
+	session.ExpiresAt = expiresAt
+	f.sessions[id] = session
+	return &session, nil
+}
+
+func (f *fakeRedis) CompleteUploadSession(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[id]
+	if !ok {
+		return repo.ErrUploadSessionNotFound
+	}
+	session.Completed = true
+	f.sessions[id] = session
+	return nil
+}
+
+func (f *fakeRedis) DeleteUploadSession(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions, id)
+	return nil
+}
+
+// testToken - handler'lar tekshiradigan access token
+func testToken(t *testing.T, userID string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": userID, "role": "user"}).
+		SignedString([]byte(config.Load().Token.ACCES_KEY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
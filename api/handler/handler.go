@@ -2,8 +2,11 @@ package handler
 
 import (
 	"log/slog"
+	"wegugin/config"
 	"wegugin/genproto/cruds"
 	"wegugin/genproto/user"
+	"wegugin/notification"
+	"wegugin/payment"
 	"wegugin/storage"
 	"wegugin/upload"
 
@@ -16,5 +19,11 @@ type Handler struct {
 	Crud     cruds.CrudsServiceClient
 	Log      *slog.Logger
 	Enforcer *casbin.Enforcer
-	MINIO    *upload.MinioUploader
+	Store    upload.ObjectStore
+	Images   *upload.ImageStore    // rasmlar va variantlari, content hash bo'yicha dedup bilan
+	Media    *upload.MediaSigner   // private bucketlar uchun imzolangan URL'lar
+	MINIO    *upload.MinioUploader // faqat MinIO driverida (resumable yuklashlar uchun), aks holda nil
+	Config   *config.Config
+	Payment  payment.Provider
+	Notifier *notification.Notifier // user sozlamalarini tekshirib notification yuboradi
 }
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wegugin/api/auth"
+	"wegugin/upload"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary GetMedia
+// @Security ApiKeyAuth
+// @Description Redirect to a stored object after checking the bucket visibility. Public buckets redirect to the permanent URL
+// @Description and need no token, other buckets (authenticated, owner, private) redirect to a signed URL that expires.
+// @Description Send redirect=false to get the URL as JSON instead of a redirect
+// @Tags MEDIA
+// @Produce json
+// @Param bucket path string true "Bucket name"
+// @Param key path string true "Object key, may contain slashes"
+// @Param redirect query bool false "false returns the URL as JSON (default true)"
+// @Success 200 {object} MediaURLResponse
+// @Success 302 {string} string "Redirect to the object URL"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Router /v1/media/{bucket}/{key} [get]
+func (h *Handler) GetMedia(c *gin.Context) {
+	bucket := c.Param("bucket")
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if bucket == "" || key == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "bucket and key are required",
+		})
+		return
+	}
+
+	redirect := true
+	if v := c.Query("redirect"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid redirect value",
+			})
+			return
+		}
+		redirect = parsed
+	}
+
+	// Token ixtiyoriy: public bucketlar anonim beriladi, qolganlari uchun token tekshiriladi
+	var access upload.MediaAccess
+	if token := c.GetHeader("Authorization"); token != "" {
+		userID, role, err := auth.GetUserIdFromToken(token)
+		if err != nil || userID == "" {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Invalid token provided",
+			})
+			return
+		}
+		access = upload.MediaAccess{UserID: userID, Role: role}
+	}
+
+	signed, err := h.Media.SignGet(c.Request.Context(), bucket, key, access)
+	if err != nil {
+		switch {
+		case errors.Is(err, upload.ErrMediaUnauthorized):
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, upload.ErrMediaForbidden):
+			h.Log.Warn("Media access denied", "bucket", bucket, "key", key, "user_id", access.UserID)
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		case upload.IsNotFound(err):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Object not found"})
+		case errors.Is(err, upload.ErrPresignNotSupported):
+			c.JSON(http.StatusNotImplemented, ErrorResponse{
+				Error: "Signed URLs are not supported by the configured storage",
+			})
+		default:
+			h.Log.Error("Failed to sign media URL", "bucket", bucket, "key", key, "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get media URL"})
+		}
+		return
+	}
+
+	// Imzolangan URL muddatli, shuning uchun redirect keshlanmaydi
+	if !signed.ExpiresAt.IsZero() {
+		c.Header("Cache-Control", "private, no-store")
+	}
+
+	if !redirect {
+		response := MediaURLResponse{URL: signed.URL}
+		if !signed.ExpiresAt.IsZero() {
+			response.ExpiresAt = &signed.ExpiresAt
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	c.Redirect(http.StatusFound, signed.URL)
+}
+
+type MediaURLResponse struct {
+	URL       string     `json:"url" example:"http://localhost:9000/chat/123e4567.jpg?X-Amz-Signature=..."`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-01-01T12:10:00Z"` // public bucketlarda yo'q
+}
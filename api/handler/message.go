@@ -10,6 +10,7 @@ import (
 	"wegugin/genproto/cruds"
 	"wegugin/genproto/user"
 	"wegugin/model"
+	"wegugin/notification"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -20,6 +21,9 @@ var onlineUsers = struct {
 	connections map[string]*websocket.Conn
 }{connections: make(map[string]*websocket.Conn)}
 
+// notifyTimeout - javobdan keyin yuboriladigan notification uchun vaqt chegarasi
+const notifyTimeout = 30 * time.Second
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true
@@ -71,6 +75,9 @@ func (h *Handler) ChatWebSocket(c *gin.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if !h.chatUpdatesAllowed(ctx, userID) {
+				continue
+			}
 			messages, err := h.Crud.GetMessagesByUser(ctx, &cruds.GetMessagesByUserRequest{UserId: userID})
 			if err != nil {
 				log.Println("Error fetching messages:", err)
@@ -156,6 +163,9 @@ func (h *Handler) ChatWebSocketByUserAndId(c *gin.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if !h.chatUpdatesAllowed(ctx, userID) {
+				continue
+			}
 			// Ikki user orasidagi xabarlarni olish
 			messages, err := h.Crud.GetMessageByUserAndId(ctx, &cruds.GetMessageByUserAndIdReq{
 				FirstUserId:  userID,
@@ -202,6 +212,17 @@ func (h *Handler) ChatWebSocketByUserAndId(c *gin.Context) {
 	}
 }
 
+// chatUpdatesAllowed - websocket orqali yuboriladigan chat xabarlari in-app kanal hisoblanadi,
+// shuning uchun SendMessage notificationlari kabi user sozlamalari tekshiriladi
+func (h *Handler) chatUpdatesAllowed(ctx context.Context, userID string) bool {
+	allowed, err := h.Notifier.Allows(ctx, userID, model.NotificationTypeChatMessage, model.NotificationChannelInApp)
+	if err != nil {
+		log.Println("Error getting notification preferences:", err)
+		return false
+	}
+	return allowed
+}
+
 func (h *Handler) disconnectUser(userID string) {
 	onlineUsers.Lock()
 	conn, exists := onlineUsers.connections[userID]
@@ -273,6 +294,15 @@ func (h *Handler) SendMessage(c *gin.Context) {
 		return
 	}
 	h.Log.Info("Message sent successfully")
+
+	// Xabar yuborilgan bo'lsa, notification xatosi javobga ta'sir qilmaydi
+	h.notifyAsync(c.Request.Context(), notification.Notification{
+		UserID: req.RecipientID,
+		Type:   model.NotificationTypeChatMessage,
+		Title:  "New message",
+		Body:   req.Content,
+		Data:   map[string]string{"sender_id": userId},
+	})
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -427,3 +457,15 @@ func (h *Handler) DeleteUserTypingStatus(c *gin.Context) {
 	h.Log.Info("User typing status deleted successfully")
 	c.JSON(http.StatusOK, gin.H{"message": "User typing status deleted successfully"})
 }
+
+// notifyAsync - push va email provayderlari sekin bo'lishi mumkin, shuning uchun notification
+// javobni kutdirmasdan alohida goroutine'da yuboriladi. So'rov tugagach ham bekor qilinmaydi
+func (h *Handler) notifyAsync(ctx context.Context, msg notification.Notification) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	go func() {
+		defer cancel()
+		if _, err := h.Notifier.Notify(ctx, msg); err != nil {
+			h.Log.Error("Failed to send notification", "type", msg.Type, "user_id", msg.UserID, "error", err)
+		}
+	}()
+}
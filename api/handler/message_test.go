@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wegugin/model"
+	"wegugin/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPrefs - hamma userlar standart sozlamalarda
+type defaultPrefs struct{}
+
+func (defaultPrefs) GetByUserID(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	return model.DefaultNotificationPreferences(userID), nil
+}
+
+// blockingSender - release yopilguncha yuborishni kutadi
+type blockingSender struct {
+	release chan struct{}
+	sent    chan notification.Notification
+}
+
+func (s *blockingSender) Channel() string { return model.NotificationChannelPush }
+
+func (s *blockingSender) Send(ctx context.Context, n notification.Notification) error {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.sent <- n
+	return nil
+}
+
+func TestSendMessageDoesNotWaitForNotification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sender := &blockingSender{release: make(chan struct{}), sent: make(chan notification.Notification, 1)}
+	h := &Handler{
+		Crud:     &fakeCruds{},
+		Log:      log,
+		Notifier: notification.NewNotifier(defaultPrefs{}, log, sender),
+	}
+	r := gin.New()
+	r.POST("/v1/car/message", h.SendMessage)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/car/message", strings.NewReader(`{"recipient_id":"user-2","content":"hi"}`))
+	req.Header.Set("Authorization", testToken(t, "user-1"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	// Javob qaytgandan keyin ham notification yuboriladi (so'rov konteksti bekor qilingan bo'lsa ham)
+	close(sender.release)
+	select {
+	case n := <-sender.sent:
+		if n.UserID != "user-2" || n.Type != model.NotificationTypeChatMessage {
+			t.Fatalf("notification = %+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not sent after the response")
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"wegugin/api/auth"
+	"wegugin/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotificationPreferences godoc
+// @Summary Get notification preferences
+// @Description Get notification preferences of the current user (defaults are returned if nothing is saved yet)
+// @Tags NotificationPreferences
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} model.NotificationPreferences
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/notification/preferences [get]
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	token := c.GetHeader("Authorization")
+	userID, _, err := auth.GetUserIdFromToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Invalid token",
+		})
+		return
+	}
+
+	prefs, err := h.Cruds.NotificationPreferences().GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		h.Log.Error("Failed to get notification preferences", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get notification preferences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary Update notification preferences
+// @Description Update notification preferences of the current user. Omitted fields keep their current values
+// @Tags NotificationPreferences
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param preferences body UpdateNotificationPreferencesRequest true "Notification preferences"
+// @Success 200 {object} model.NotificationPreferences
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/notification/preferences [put]
+func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	token := c.GetHeader("Authorization")
+	userID, _, err := auth.GetUserIdFromToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Invalid token",
+		})
+		return
+	}
+
+	if req.QuietHours != nil {
+		if err := validateQuietHours(*req.QuietHours); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+	}
+
+	prefs, err := h.Cruds.NotificationPreferences().GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		h.Log.Error("Failed to get notification preferences", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get notification preferences",
+		})
+		return
+	}
+
+	if req.ChatMessage != nil {
+		prefs.ChatMessage = *req.ChatMessage
+	}
+	if req.TopCarExpiry != nil {
+		prefs.TopCarExpiry = *req.TopCarExpiry
+	}
+	if req.SavedCarPriceChange != nil {
+		prefs.SavedCarPriceChange = *req.SavedCarPriceChange
+	}
+	if req.QuietHours != nil {
+		prefs.QuietHours = *req.QuietHours
+	}
+
+	err = h.Cruds.NotificationPreferences().Upsert(c.Request.Context(), prefs)
+	if err != nil {
+		h.Log.Error("Failed to update notification preferences", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to update notification preferences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+func validateQuietHours(q model.QuietHours) error {
+	if _, err := time.Parse("15:04", q.Start); err != nil {
+		return errors.New("Invalid quiet hours: start must be in HH:MM format")
+	}
+	if _, err := time.Parse("15:04", q.End); err != nil {
+		return errors.New("Invalid quiet hours: end must be in HH:MM format")
+	}
+	if q.Timezone == "" {
+		return errors.New("Invalid quiet hours: timezone is required")
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return fmt.Errorf("Invalid quiet hours: unknown timezone %s", q.Timezone)
+	}
+	return nil
+}
+
+// Request/Response structures
+type UpdateNotificationPreferencesRequest struct {
+	ChatMessage         *model.ChannelPreferences `json:"chat_message,omitempty"`
+	TopCarExpiry        *model.ChannelPreferences `json:"top_car_expiry,omitempty"`
+	SavedCarPriceChange *model.ChannelPreferences `json:"saved_car_price_change,omitempty"`
+	QuietHours          *model.QuietHours         `json:"quiet_hours,omitempty"`
+}
@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wegugin/model"
+	"wegugin/payment"
+	"wegugin/storage/repo"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PaymentWebhook godoc
+// @Summary Payment provider webhook
+// @Description Callback endpoint for the payment provider. The body must be signed (X-Signature header) and confirms or rejects the payment of a pending top car promotion
+// @Description or of a pending reschedule/extension of a promotion
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param provider path string true "Payment provider name"
+// @Param X-Signature header string true "Hex HMAC-SHA256 signature of the body"
+// @Param event body payment.WebhookEvent true "Webhook event"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /v1/payment/webhook/{provider} [post]
+func (h *Handler) PaymentWebhook(c *gin.Context) {
+	// To'lovlar o'chirilgan bo'lsa hech qaysi provider qabul qilinmaydi
+	if h.Payment == nil || c.Param("provider") != h.Payment.Name() {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Unknown payment provider",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Failed to read request body",
+		})
+		return
+	}
+
+	event, err := h.Payment.ParseWebhook(c.Request.Header, body)
+	if err != nil {
+		h.Log.Warn("Rejected payment webhook", "error", err, "provider", h.Payment.Name())
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Invalid webhook",
+		})
+		return
+	}
+
+	topCar, err := h.Cruds.TopCars().GetByPaymentID(c.Request.Context(), event.PaymentID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Payment not found",
+			})
+			return
+		}
+		h.Log.Error("Failed to get top car by payment ID", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to process webhook",
+		})
+		return
+	}
+
+	// Reschedule/extend uchun narx farqi to'lovi
+	if topCar.PaymentId != event.PaymentID {
+		h.processTopCarChangePayment(c, topCar, event)
+		return
+	}
+
+	// Promotion to'lov tasdiqlanishidan oldin bekor qilingan yoki o'chirilgan: olingan pul qaytarilishi kerak
+	if event.Status == payment.StatusSucceeded && topCar.PaidAt == nil &&
+		(topCar.Status != model.TopCarStatusPending || topCar.DeletedAt != nil) {
+		h.refundCancelledTopCarPayment(c, topCar, event)
+		return
+	}
+
+	// Provider bir xil eventni qayta yuborishi mumkin
+	if topCar.Status != model.TopCarStatusPending || topCar.DeletedAt != nil {
+		c.JSON(http.StatusOK, MessageResponse{
+			Message: "Payment already processed",
+		})
+		return
+	}
+
+	switch event.Status {
+	case payment.StatusSucceeded:
+		if event.Amount != topCar.Amount || event.Currency != topCar.Currency {
+			h.Log.Error("Payment amount mismatch", "payment_id", event.PaymentID,
+				"expected", topCar.Amount, "got", event.Amount,
+				"expected_currency", topCar.Currency, "got_currency", event.Currency)
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Payment amount mismatch",
+			})
+			return
+		}
+
+		_, err = h.Cruds.TopCars().MarkTopCarPaid(c.Request.Context(), event.PaymentID, time.Now())
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Shu orada bekor qilingan yoki parallel webhook to'langan deb belgilagan
+			current, getErr := h.Cruds.TopCars().GetByPaymentID(c.Request.Context(), event.PaymentID)
+			if getErr == nil && current.PaidAt == nil {
+				h.refundCancelledTopCarPayment(c, current, event)
+				return
+			}
+			err = getErr
+		}
+		if err != nil {
+			h.Log.Error("Failed to mark top car as paid", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to process webhook",
+			})
+			return
+		}
+		h.Log.Info("Top car payment confirmed", "payment_id", event.PaymentID, "top_car_id", topCar.ID.Hex())
+		h.activateTopCars(c.Request.Context())
+	case payment.StatusFailed:
+		err = h.Cruds.TopCars().CancelTopCarPayment(c.Request.Context(), event.PaymentID)
+		if err != nil {
+			h.Log.Error("Failed to cancel top car payment", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to process webhook",
+			})
+			return
+		}
+		h.Log.Info("Top car payment failed", "payment_id", event.PaymentID, "top_car_id", topCar.ID.Hex())
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Unknown payment status",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Webhook processed successfully",
+	})
+}
+
+// refundCancelledTopCarPayment - bekor qilingan promotion uchun kelgan to'lovni qaytarilishi kerak deb yozish
+func (h *Handler) refundCancelledTopCarPayment(c *gin.Context, topCar *model.TopCars, event *payment.WebhookEvent) {
+	h.Log.Warn("Payment of a cancelled top car succeeded, refund required",
+		"payment_id", event.PaymentID, "top_car_id", topCar.ID.Hex(),
+		"amount", event.Amount, "currency", event.Currency)
+
+	err := h.Cruds.PaymentRefunds().RequireRefund(c.Request.Context(), &model.PaymentRefund{
+		PaymentId: event.PaymentID,
+		TopCarId:  topCar.ID.Hex(),
+		UserId:    topCar.UserId,
+		Amount:    event.Amount,
+		Currency:  event.Currency,
+		Reason:    model.PaymentRefundReasonPromotionCancelled,
+	})
+	if err != nil {
+		// Provider webhookni qayta yuboradi
+		h.Log.Error("Failed to record payment refund", "error", err, "payment_id", event.PaymentID)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to process webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Payment received, but the promotion was cancelled. The payment will be refunded",
+	})
+}
+
+// processTopCarChangePayment - pending o'zgarish to'lovi natijasini qo'llash
+func (h *Handler) processTopCarChangePayment(c *gin.Context, topCar *model.TopCars, event *payment.WebhookEvent) {
+	ctx := c.Request.Context()
+
+	// Provider bir xil eventni qayta yuborishi mumkin (o'zgarish allaqachon qo'llangan)
+	change := topCar.PendingChange
+	if change == nil || change.PaymentId != event.PaymentID {
+		c.JSON(http.StatusOK, MessageResponse{
+			Message: "Payment already processed",
+		})
+		return
+	}
+
+	switch event.Status {
+	case payment.StatusSucceeded:
+		if event.Amount != change.Amount || event.Currency != change.Currency {
+			h.Log.Error("Payment amount mismatch", "payment_id", event.PaymentID,
+				"expected", change.Amount, "got", event.Amount,
+				"expected_currency", change.Currency, "got_currency", event.Currency)
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Payment amount mismatch",
+			})
+			return
+		}
+
+		_, err := h.Cruds.TopCars().ApplyPendingChange(ctx, event.PaymentID, time.Now())
+		switch {
+		case err == nil:
+			h.Log.Info("Top car change payment confirmed", "payment_id", event.PaymentID,
+				"top_car_id", topCar.ID.Hex(), "kind", change.Kind)
+		case errors.Is(err, repo.ErrTopCarOverlap), errors.Is(err, mongo.ErrNoDocuments):
+			// To'lov olingan, lekin oraliq shu orada band bo'lgan yoki promotion o'chirilgan.
+			// Pending o'zgarish refund yozilgandan keyingina tozalanadi, aks holda provider webhookni qayta yuboradi
+			h.Log.Warn("Paid top car change can not be applied, refund required",
+				"payment_id", event.PaymentID, "top_car_id", topCar.ID.Hex(),
+				"amount", change.Amount, "currency", change.Currency, "error", err)
+			err = h.Cruds.PaymentRefunds().RequireRefund(ctx, &model.PaymentRefund{
+				PaymentId: event.PaymentID,
+				TopCarId:  topCar.ID.Hex(),
+				UserId:    topCar.UserId,
+				Amount:    event.Amount,
+				Currency:  event.Currency,
+				Reason:    model.PaymentRefundReasonChangeNotApplied,
+			})
+			if err != nil {
+				h.Log.Error("Failed to record payment refund", "error", err, "payment_id", event.PaymentID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error: "Failed to process webhook",
+				})
+				return
+			}
+			if err := h.Cruds.TopCars().ClearPendingChange(ctx, event.PaymentID); err != nil {
+				h.Log.Error("Failed to clear pending top car change", "error", err)
+			}
+			c.JSON(http.StatusOK, MessageResponse{
+				Message: "Payment received, but the change could not be applied. The payment will be refunded",
+			})
+			return
+		case errors.Is(err, repo.ErrTopCarBusy):
+			// Provider webhookni qayta yuboradi
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+				Error: "Top car is being changed, please retry",
+			})
+			return
+		default:
+			h.Log.Error("Failed to apply top car change", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to process webhook",
+			})
+			return
+		}
+	case payment.StatusFailed:
+		if err := h.Cruds.TopCars().ClearPendingChange(ctx, event.PaymentID); err != nil {
+			h.Log.Error("Failed to clear pending top car change", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to process webhook",
+			})
+			return
+		}
+		h.Log.Info("Top car change payment failed", "payment_id", event.PaymentID, "top_car_id", topCar.ID.Hex())
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Unknown payment status",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Webhook processed successfully",
+	})
+}
+
+// AdminGetPaymentRefunds godoc
+// @Summary Payment refunds
+// @Description List captured payments that must be refunded through the payment provider (paid changes that could not be applied,
+// @Description payments of cancelled promotions), oldest first
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Status filter (required, refunded), default required"
+// @Param limit query int false "Limit results (default 50)"
+// @Param skip query int false "Skip results for pagination"
+// @Success 200 {object} PaymentRefundsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/admin/payment/refunds [get]
+func (h *Handler) AdminGetPaymentRefunds(c *gin.Context) {
+	status := c.DefaultQuery("status", model.PaymentRefundStatusRequired)
+	if status != model.PaymentRefundStatusRequired && status != model.PaymentRefundStatusRefunded {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid status. Must be required or refunded",
+		})
+		return
+	}
+
+	var limit, skip int64
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.ParseInt(limitStr, 10, 64); err == nil {
+			limit = l
+		}
+	}
+	if skipStr := c.Query("skip"); skipStr != "" {
+		if s, err := strconv.ParseInt(skipStr, 10, 64); err == nil {
+			skip = s
+		}
+	}
+	if limit <= 0 {
+		limit = 50 // default limit
+	}
+
+	refunds, total, err := h.Cruds.PaymentRefunds().GetRefunds(c.Request.Context(), status, limit, skip)
+	if err != nil {
+		h.Log.Error("Failed to get payment refunds", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get payment refunds",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaymentRefundsResponse{
+		Refunds: refunds,
+		Total:   total,
+		Limit:   limit,
+		Skip:    skip,
+	})
+}
+
+// AdminMarkPaymentRefunded godoc
+// @Summary Mark payment refunded
+// @Description Record that the payment was refunded through the payment provider
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payment_id path string true "Payment ID"
+// @Success 200 {object} model.PaymentRefund
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/admin/payment/refunds/{payment_id}/refunded [post]
+func (h *Handler) AdminMarkPaymentRefunded(c *gin.Context) {
+	adminID, ok := h.getAdminID(c)
+	if !ok {
+		return
+	}
+
+	refund, err := h.Cruds.PaymentRefunds().MarkRefunded(c.Request.Context(), c.Param("payment_id"), adminID, time.Now())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Refund waiting for this payment not found",
+			})
+			return
+		}
+		h.Log.Error("Failed to mark payment refunded", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to mark payment refunded",
+		})
+		return
+	}
+
+	h.writeAdminAudit(c.Request.Context(), &model.AdminAuditLog{
+		AdminId:  adminID,
+		Action:   model.AdminActionPaymentRefunded,
+		TopCarId: refund.TopCarId,
+		Details: map[string]string{
+			"payment_id": refund.PaymentId,
+			"amount":     strconv.FormatInt(refund.Amount, 10),
+			"currency":   refund.Currency,
+		},
+	})
+
+	c.JSON(http.StatusOK, refund)
+}
+
+type PaymentRefundsResponse struct {
+	Refunds []*model.PaymentRefund `json:"refunds"`
+	Total   int64                  `json:"total" example:"10"`
+	Limit   int64                  `json:"limit" example:"50"`
+	Skip    int64                  `json:"skip" example:"0"`
+}
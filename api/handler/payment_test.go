@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wegugin/config"
+	"wegugin/model"
+	"wegugin/payment"
+	"wegugin/storage/repo"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testWebhookSecret = "secret"
+
+func newWebhookTest(cars ...*model.TopCars) (*gin.Engine, *fakeStorage) {
+	gin.SetMode(gin.TestMode)
+
+	st := &fakeStorage{
+		topCars: &fakeTopCars{cars: cars},
+		refunds: &fakePaymentRefunds{refunds: make(map[string]*model.PaymentRefund)},
+	}
+	h := &Handler{
+		Cruds:   st,
+		Log:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config:  &config.Config{},
+		Payment: payment.NewLocalProvider(testWebhookSecret),
+	}
+
+	r := gin.New()
+	r.POST("/v1/payment/webhook/:provider", h.PaymentWebhook)
+	return r, st
+}
+
+func sendWebhook(r *gin.Engine, provider string, event payment.WebhookEvent, secret string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(event)
+	req := httptest.NewRequest(http.MethodPost, "/v1/payment/webhook/"+provider, strings.NewReader(string(body)))
+	req.Header.Set(payment.SignatureHeader, payment.Sign(secret, body))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func pendingTopCar(paymentID string) *model.TopCars {
+	return &model.TopCars{
+		ID:        primitive.NewObjectID(),
+		Category:  "daily",
+		Status:    model.TopCarStatusPending,
+		PaymentId: paymentID,
+		Amount:    500,
+		Currency:  "USD",
+	}
+}
+
+func TestPaymentWebhookRejectsInvalidRequests(t *testing.T) {
+	r, st := newWebhookTest(pendingTopCar("p1"))
+	event := payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusSucceeded, Amount: 500, Currency: "USD"}
+
+	if w := sendWebhook(r, "acme", event, testWebhookSecret); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown provider: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := sendWebhook(r, "local", event, "wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	unknown := event
+	unknown.PaymentID = "p2"
+	if w := sendWebhook(r, "local", unknown, testWebhookSecret); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown payment: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if got := st.topCars.cars[0].Status; got != model.TopCarStatusPending {
+		t.Fatalf("status = %s after rejected webhooks, want %s", got, model.TopCarStatusPending)
+	}
+}
+
+func TestPaymentWebhookTopCarPayment(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      payment.WebhookEvent
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name:       "succeeded",
+			event:      payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusSucceeded, Amount: 500, Currency: "USD"},
+			wantCode:   http.StatusOK,
+			wantStatus: model.TopCarStatusPaid,
+		},
+		{
+			name:       "amount mismatch",
+			event:      payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusSucceeded, Amount: 499, Currency: "USD"},
+			wantCode:   http.StatusBadRequest,
+			wantStatus: model.TopCarStatusPending,
+		},
+		{
+			name:       "currency mismatch",
+			event:      payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusSucceeded, Amount: 500, Currency: "EUR"},
+			wantCode:   http.StatusBadRequest,
+			wantStatus: model.TopCarStatusPending,
+		},
+		{
+			name:       "failed",
+			event:      payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusFailed},
+			wantCode:   http.StatusOK,
+			wantStatus: model.TopCarStatusCancelled,
+		},
+		{
+			name:       "unknown status",
+			event:      payment.WebhookEvent{PaymentID: "p1", Status: "refunded"},
+			wantCode:   http.StatusBadRequest,
+			wantStatus: model.TopCarStatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, st := newWebhookTest(pendingTopCar("p1"))
+
+			if w := sendWebhook(r, "local", tt.event, testWebhookSecret); w.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d (%s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			if got := st.topCars.cars[0].Status; got != tt.wantStatus {
+				t.Fatalf("top car status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPaymentWebhookIsIdempotent(t *testing.T) {
+	r, st := newWebhookTest(pendingTopCar("p1"))
+	succeeded := payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusSucceeded, Amount: 500, Currency: "USD"}
+
+	for i := 0; i < 2; i++ {
+		if w := sendWebhook(r, "local", succeeded, testWebhookSecret); w.Code != http.StatusOK {
+			t.Fatalf("attempt %d: status code = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+
+	// To'langan promotion keyin kelgan failed event bilan bekor qilinmaydi
+	failed := payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusFailed}
+	if w := sendWebhook(r, "local", failed, testWebhookSecret); w.Code != http.StatusOK {
+		t.Fatalf("late failed event: status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := st.topCars.cars[0].Status; got != model.TopCarStatusPaid {
+		t.Fatalf("top car status = %s, want %s", got, model.TopCarStatusPaid)
+	}
+}
+
+func TestPaymentWebhookRefundsCancelledTopCar(t *testing.T) {
+	now := time.Now()
+	cancelled := pendingTopCar("p1")
+	cancelled.Status = model.TopCarStatusCancelled
+	cancelled.DeletedAt = &now
+	deleted := pendingTopCar("p2")
+	deleted.DeletedAt = &now
+	paid := pendingTopCar("p3")
+	paid.Status = model.TopCarStatusActive
+	paid.PaidAt = &now
+	r, st := newWebhookTest(cancelled, deleted, paid)
+
+	for _, id := range []string{"p1", "p2", "p3"} {
+		event := payment.WebhookEvent{PaymentID: id, Status: payment.StatusSucceeded, Amount: 500, Currency: "USD"}
+		// Qayta kelgan webhook refundni takrorlamaydi
+		for i := 0; i < 2; i++ {
+			if w := sendWebhook(r, "local", event, testWebhookSecret); w.Code != http.StatusOK {
+				t.Fatalf("%s: status code = %d, want %d", id, w.Code, http.StatusOK)
+			}
+		}
+	}
+
+	if len(st.refunds.refunds) != 2 {
+		t.Fatalf("refunds = %d, want 2", len(st.refunds.refunds))
+	}
+	for _, id := range []string{"p1", "p2"} {
+		refund := st.refunds.refunds[id]
+		if refund == nil || refund.Reason != model.PaymentRefundReasonPromotionCancelled || refund.Amount != 500 {
+			t.Fatalf("%s: refund = %+v", id, refund)
+		}
+	}
+	if cancelled.Status != model.TopCarStatusCancelled || deleted.PaidAt != nil {
+		t.Fatal("cancelled promotions must not be revived by a late payment")
+	}
+}
+
+func TestPaymentWebhookPendingChange(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	newTopCar := func() *model.TopCars {
+		topCar := pendingTopCar("p1")
+		topCar.Status = model.TopCarStatusActive
+		topCar.StartsAt = now
+		topCar.FinishedAt = now.Add(24 * time.Hour)
+		topCar.PendingChange = &model.TopCarChange{
+			Kind:               model.TopCarChangeExtend,
+			Category:           "custom",
+			StartsAt:           now,
+			FinishedAt:         now.Add(48 * time.Hour),
+			PreviousFinishedAt: now.Add(24 * time.Hour),
+			Amount:             600,
+			Currency:           "USD",
+			PaymentId:          "c1",
+		}
+		return topCar
+	}
+	succeeded := payment.WebhookEvent{PaymentID: "c1", Status: payment.StatusSucceeded, Amount: 600, Currency: "USD"}
+
+	t.Run("succeeded", func(t *testing.T) {
+		r, st := newWebhookTest(newTopCar())
+
+		for i := 0; i < 2; i++ {
+			if w := sendWebhook(r, "local", succeeded, testWebhookSecret); w.Code != http.StatusOK {
+				t.Fatalf("attempt %d: status code = %d, want %d", i+1, w.Code, http.StatusOK)
+			}
+		}
+		topCar := st.topCars.cars[0]
+		if topCar.PendingChange != nil || len(topCar.AppliedChanges) != 1 {
+			t.Fatalf("pending = %v, applied = %d, want the change applied once", topCar.PendingChange, len(topCar.AppliedChanges))
+		}
+		if !topCar.FinishedAt.Equal(now.Add(48 * time.Hour)) {
+			t.Fatalf("finished_at = %v, want %v", topCar.FinishedAt, now.Add(48*time.Hour))
+		}
+	})
+
+	t.Run("amount mismatch", func(t *testing.T) {
+		r, st := newWebhookTest(newTopCar())
+		event := succeeded
+		event.Amount = 500
+
+		if w := sendWebhook(r, "local", event, testWebhookSecret); w.Code != http.StatusBadRequest {
+			t.Fatalf("status code = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		if st.topCars.cars[0].PendingChange == nil {
+			t.Fatal("pending change cleared after amount mismatch")
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		r, st := newWebhookTest(newTopCar())
+		event := payment.WebhookEvent{PaymentID: "c1", Status: payment.StatusFailed}
+
+		if w := sendWebhook(r, "local", event, testWebhookSecret); w.Code != http.StatusOK {
+			t.Fatalf("status code = %d, want %d", w.Code, http.StatusOK)
+		}
+		topCar := st.topCars.cars[0]
+		if topCar.PendingChange != nil || !topCar.FinishedAt.Equal(now.Add(24*time.Hour)) {
+			t.Fatal("failed change payment must clear the change and keep the window")
+		}
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		r, st := newWebhookTest(newTopCar())
+		st.topCars.applyErr = repo.ErrTopCarOverlap
+
+		if w := sendWebhook(r, "local", succeeded, testWebhookSecret); w.Code != http.StatusOK {
+			t.Fatalf("status code = %d, want %d", w.Code, http.StatusOK)
+		}
+		if st.topCars.cars[0].PendingChange != nil {
+			t.Fatal("change that can not be applied must be cleared")
+		}
+		refund := st.refunds.refunds["c1"]
+		if refund == nil || refund.Amount != 600 || refund.Reason != model.PaymentRefundReasonChangeNotApplied {
+			t.Fatalf("refund = %+v, want the captured change payment recorded", refund)
+		}
+	})
+
+	t.Run("overlap refund not recorded", func(t *testing.T) {
+		r, st := newWebhookTest(newTopCar())
+		st.topCars.applyErr = repo.ErrTopCarOverlap
+		st.refunds.err = errors.New("mongo is down")
+
+		if w := sendWebhook(r, "local", succeeded, testWebhookSecret); w.Code != http.StatusInternalServerError {
+			t.Fatalf("status code = %d, want %d", w.Code, http.StatusInternalServerError)
+		}
+		if st.topCars.cars[0].PendingChange == nil {
+			t.Fatal("change must stay pending until the refund is recorded")
+		}
+	})
+
+	t.Run("busy", func(t *testing.T) {
+		r, st := newWebhookTest(newTopCar())
+		st.topCars.applyErr = repo.ErrTopCarBusy
+
+		if w := sendWebhook(r, "local", succeeded, testWebhookSecret); w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status code = %d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+		if st.topCars.cars[0].PendingChange == nil {
+			t.Fatal("change must stay pending so the provider can retry")
+		}
+	})
+}
+
+func TestPaymentsDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.TopCar.PRICE_DAILY = 500
+	cfg.TopCar.MAX_SCHEDULE_AHEAD_DAYS = 30
+	h := &Handler{
+		Cruds:  &fakeStorage{topCars: &fakeTopCars{}},
+		Log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: cfg,
+	}
+	r := gin.New()
+	r.POST("/v1/topcar", h.CreateTopCar)
+	r.POST("/v1/payment/webhook/:provider", h.PaymentWebhook)
+
+	// Pullik promotion to'lov ochilmasdan rad etiladi
+	req := httptest.NewRequest(http.MethodPost, "/v1/topcar", strings.NewReader(`{"car_id":"car-1","category":"daily"}`))
+	req.Header.Set("Authorization", testToken(t, "user-1"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("create: status code = %d, want %d (%s)", w.Code, http.StatusServiceUnavailable, w.Body.String())
+	}
+
+	event := payment.WebhookEvent{PaymentID: "p1", Status: payment.StatusSucceeded, Amount: 500, Currency: "USD"}
+	if w := sendWebhook(r, "local", event, testWebhookSecret); w.Code != http.StatusNotFound {
+		t.Fatalf("webhook: status code = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	pb "wegugin/genproto/cruds"
+	"wegugin/model"
+	"wegugin/upload"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary SetPhotoOrder
+// @Security ApiKeyAuth
+// @Description Set the display order of car photos. Every id must belong to the car; photos not listed keep their upload order after the listed ones
+// @Tags IMAGES
+// @Accept json
+// @Produce json
+// @Param car_id path string true "car_id"
+// @Param order body SetPhotoOrderRequest true "Photo ids in display order"
+// @Success 200 {object} []CarImageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/car/photo/{car_id}/order [put]
+func (h *Handler) SetPhotoOrder(c *gin.Context) {
+	var req SetPhotoOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	carID, _, ok := h.checkPhotoCarOwnership(c)
+	if !ok {
+		return
+	}
+
+	images, ok := h.getCarImagesForOrder(c, carID)
+	if !ok {
+		return
+	}
+
+	seen := make(map[string]bool, len(req.ImageIds))
+	for _, id := range req.ImageIds {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: fmt.Sprintf("Image %s is listed more than once", id),
+			})
+			return
+		}
+		seen[id] = true
+		if !containsImage(images, id) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: fmt.Sprintf("Image %s does not belong to the car", id),
+			})
+			return
+		}
+	}
+
+	order, err := h.Cruds.PhotoOrders().SetOrder(c.Request.Context(), carID, req.ImageIds)
+	if err != nil {
+		h.Log.Error("Failed to save photo order", "car_id", carID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to save photo order",
+		})
+		return
+	}
+	h.invalidateCarSummary(c, carID)
+
+	c.JSON(http.StatusOK, h.carImageResponses(orderCarImages(images, order), order))
+}
+
+// @Summary SetPhotoCover
+// @Security ApiKeyAuth
+// @Description Choose the cover photo of a car. The cover is returned first by the photo list and shown in listings
+// @Tags IMAGES
+// @Accept json
+// @Produce json
+// @Param car_id path string true "car_id"
+// @Param cover body SetPhotoCoverRequest true "Photo id"
+// @Success 200 {object} []CarImageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/car/photo/{car_id}/cover [put]
+func (h *Handler) SetPhotoCover(c *gin.Context) {
+	var req SetPhotoCoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	carID, _, ok := h.checkPhotoCarOwnership(c)
+	if !ok {
+		return
+	}
+
+	images, ok := h.getCarImagesForOrder(c, carID)
+	if !ok {
+		return
+	}
+	if !containsImage(images, req.ImageId) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Image does not belong to the car",
+		})
+		return
+	}
+
+	order, err := h.Cruds.PhotoOrders().SetCover(c.Request.Context(), carID, req.ImageId)
+	if err != nil {
+		h.Log.Error("Failed to save cover photo", "car_id", carID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to save cover photo",
+		})
+		return
+	}
+	h.invalidateCarSummary(c, carID)
+
+	c.JSON(http.StatusOK, h.carImageResponses(orderCarImages(images, order), order))
+}
+
+func (h *Handler) getCarImagesForOrder(c *gin.Context, carID string) ([]*pb.Image, bool) {
+	res, err := h.Crud.GetImagesByCar(c, &pb.CarId{CarId: carID})
+	if err != nil {
+		h.Log.Error("Error getting images by car", "car_id", carID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Error getting images by car",
+		})
+		return nil, false
+	}
+	return res.Images, true
+}
+
+// invalidateCarSummary - top car ro'yxatidagi rasm cover bo'yicha qayta olinishi uchun
+func (h *Handler) invalidateCarSummary(c *gin.Context, carID string) {
+	if err := h.Cruds.Redis().DeleteCarSummary(c.Request.Context(), carID); err != nil {
+		h.Log.Warn("Failed to invalidate car summary cache", "car_id", carID, "error", err)
+	}
+}
+
+// orderCarImages - rasmlarni saqlangan tartib bo'yicha saralash: avval cover, keyin tartibdagilar,
+// oxirida tartibda yo'qlar cruds qaytargan ketma-ketlikda. order nil bo'lsa o'zgarmaydi
+func orderCarImages(images []*pb.Image, order *model.PhotoOrder) []*pb.Image {
+	if order == nil || (order.CoverId == "" && len(order.ImageIds) == 0) {
+		return images
+	}
+
+	byID := make(map[string]*pb.Image, len(images))
+	for _, image := range images {
+		byID[image.Id] = image
+	}
+
+	sorted := make([]*pb.Image, 0, len(images))
+	added := make(map[string]bool, len(images))
+	add := func(id string) {
+		if image, ok := byID[id]; ok && !added[id] {
+			sorted = append(sorted, image)
+			added[id] = true
+		}
+	}
+
+	add(order.CoverId)
+	for _, id := range order.ImageIds {
+		add(id)
+	}
+	for _, image := range images {
+		add(image.Id)
+	}
+
+	return sorted
+}
+
+func (h *Handler) carImageResponses(images []*pb.Image, order *model.PhotoOrder) []CarImageResponse {
+	coverID := ""
+	if order != nil {
+		coverID = order.CoverId
+	}
+	// Cover belgilanmagan bo'lsa birinchi rasm cover hisoblanadi
+	if coverID == "" && len(images) > 0 {
+		coverID = images[0].Id
+	}
+
+	responses := make([]CarImageResponse, 0, len(images))
+	for i, image := range images {
+		responses = append(responses, CarImageResponse{
+			Id:         image.Id,
+			Filename:   image.Filename,
+			CarId:      image.CarId,
+			UploadedAt: image.UploadedAt,
+			Position:   i,
+			IsCover:    image.Id == coverID,
+			Variants:   upload.VariantURLs(image.Filename),
+		})
+	}
+	return responses
+}
+
+func containsImage(images []*pb.Image, id string) bool {
+	for _, image := range images {
+		if image.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+type SetPhotoOrderRequest struct {
+	ImageIds []string `json:"image_ids" binding:"required,min=1"`
+}
+
+type SetPhotoCoverRequest struct {
+	ImageId string `json:"image_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"wegugin/api/auth"
+	pb "wegugin/genproto/cruds"
+	"wegugin/upload"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary CreatePhotoUploadURL
+// @Security ApiKeyAuth
+// @Description Get a presigned URL to PUT a photo directly to storage. Send the returned headers unchanged, then call the complete endpoint with upload_id
+// @Tags IMAGES
+// @Accept json
+// @Produce json
+// @Param car_id path string true "car_id"
+// @Param upload body PhotoUploadURLRequest true "Photo content type and size in bytes"
+// @Success 200 {object} PhotoUploadURLResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Router /v1/car/photo/{car_id}/upload-url [post]
+func (h *Handler) CreatePhotoUploadURL(c *gin.Context) {
+	var req PhotoUploadURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	carID, userID, ok := h.checkPhotoCarOwnership(c)
+	if !ok {
+		return
+	}
+
+	if !h.checkDeclaredPhoto(c, req.ContentType, req.Size) {
+		return
+	}
+
+	uploadID := uuid.NewString()
+	expiry := time.Duration(h.Config.Photo.PRESIGN_EXPIRY_MINUTES) * time.Minute
+	presigned, err := upload.PresignUpload(c.Request.Context(), h.Store, h.Config.Photo.STAGING_BUCKET,
+		stagedPhotoKey(carID, userID, uploadID), req.ContentType, req.Size, expiry)
+	if errors.Is(err, upload.ErrPresignNotSupported) {
+		c.JSON(http.StatusNotImplemented, ErrorResponse{
+			Error: "Direct uploads are not supported by the configured storage, use the multipart upload endpoint",
+		})
+		return
+	}
+	if err != nil {
+		h.Log.Error("Failed to presign photo upload", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to create upload URL",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PhotoUploadURLResponse{
+		UploadId:  uploadID,
+		URL:       presigned.URL,
+		Method:    http.MethodPut,
+		Headers:   presigned.Headers,
+		ExpiresAt: presigned.ExpiresAt,
+	})
+}
+
+// @Summary CompletePhotoUpload
+// @Security ApiKeyAuth
+// @Description Finish a presigned upload: the stored object is validated, cleaned from metadata, resized into variants and attached to the car
+// @Tags IMAGES
+// @Accept json
+// @Produce json
+// @Param car_id path string true "car_id"
+// @Param upload body CompletePhotoUploadRequest true "upload_id from the upload-url response"
+// @Success 200 {object} UploadedPhotoResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "Same photo is being deleted, retry"
+// @Router /v1/car/photo/{car_id}/complete [post]
+func (h *Handler) CompletePhotoUpload(c *gin.Context) {
+	var req CompletePhotoUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if _, err := uuid.Parse(req.UploadId); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid upload_id",
+		})
+		return
+	}
+
+	carID, userID, ok := h.checkPhotoCarOwnership(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	bucket := h.Config.Photo.STAGING_BUCKET
+	key := stagedPhotoKey(carID, userID, req.UploadId)
+
+	result := h.attachStagedPhoto(ctx, carID, bucket, key)
+	if result.Error != "" {
+		c.JSON(result.Status, ErrorResponse{
+			Error: result.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadedPhotoResponse{
+		CarImageResponse: CarImageResponse{
+			Id:       result.PhotoId,
+			Filename: result.storedURL,
+			CarId:    carID,
+			Variants: upload.VariantURLs(result.URL),
+		},
+		Duplicate: result.Duplicate,
+	})
+}
+
+// attachStagedPhoto - staging'dagi obyektni tekshirib photos bucketga yuklash va cruds'ga qo'shish.
+// Staging obyekti muvaffaqiyatli yoki rad etilganda o'chiriladi
+func (h *Handler) attachStagedPhoto(ctx context.Context, carID, bucket, key string) PhotoUploadResult {
+	var result PhotoUploadResult
+	rules := h.imageRules()
+
+	data, err := upload.ReadObject(ctx, h.Store, bucket, key, rules.MaxBytes)
+	if err != nil {
+		switch {
+		case upload.IsNotFound(err):
+			result.Status, result.Error = http.StatusNotFound, "Uploaded file not found, upload it first or request a new URL"
+		case errors.Is(err, upload.ErrImageTooLarge):
+			h.removeStagedPhoto(bucket, key)
+			result.Status, result.Error = http.StatusRequestEntityTooLarge, err.Error()
+		default:
+			h.Log.Error("Failed to read staged photo", "key", key, "error", err)
+			result.Status, result.Error = http.StatusInternalServerError, "Failed to read uploaded file"
+		}
+		return result
+	}
+
+	info, err := upload.ValidateImage(bytes.NewReader(data), int64(len(data)), rules)
+	if err != nil {
+		h.Log.Warn("Rejected staged photo", "key", key, "error", err)
+		h.removeStagedPhoto(bucket, key)
+		result.Status, result.Error = imageErrorStatus(err), err.Error()
+		return result
+	}
+
+	stored, err := h.storePhoto(ctx, data, info)
+	if err != nil {
+		h.Log.Error("Error uploading the file to storage", "key", key, "error", err)
+		result.Status, result.Error = imageErrorStatus(err), err.Error()
+		return result
+	}
+
+	res, err := h.Crud.AddImage(ctx, &pb.AddImageRequest{
+		CarId:    carID,
+		Filename: stored.URL,
+	})
+	if err != nil {
+		h.Log.Error("Error creating photo", "key", key, "error", err)
+		h.deleteStoredPhoto(stored.URL)
+		result.Status, result.Error = http.StatusInternalServerError, "Error creating photo"
+		return result
+	}
+
+	h.removeStagedPhoto(bucket, key)
+
+	result.Status = http.StatusOK
+	result.PhotoId = res.Id
+	result.URL = stored.URL
+	result.Duplicate = stored.Duplicate
+	result.storedURL = res.Filename
+	return result
+}
+
+func (h *Handler) removeStagedPhoto(bucket, key string) {
+	if err := h.Store.Delete(context.Background(), bucket, key); err != nil {
+		h.Log.Warn("Failed to remove staged photo", "key", key, "error", err)
+	}
+}
+
+// checkDeclaredPhoto - client e'lon qilgan hajm va content type'ni yuklashdan oldin tekshirish.
+// Haqiqiy fayl baribir complete bosqichida mazmuni bo'yicha qayta tekshiriladi
+func (h *Handler) checkDeclaredPhoto(c *gin.Context, contentType string, size int64) bool {
+	rules := h.imageRules()
+	if rules.MaxBytes > 0 && size > rules.MaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error: fmt.Sprintf("File is too large, max %d bytes", rules.MaxBytes),
+		})
+		return false
+	}
+	format, known := upload.ContentTypeFormat(contentType)
+	if !known || !upload.FormatAllowed(format, rules.AllowedFormats) {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+			Error: "Content type is not an allowed image format",
+		})
+		return false
+	}
+	return true
+}
+
+// checkPhotoCarOwnership - path'dagi car token egasiga tegishli ekanligini tekshirish.
+// Xatolik bo'lsa javob yozilgan bo'ladi va ok=false qaytadi
+func (h *Handler) checkPhotoCarOwnership(c *gin.Context) (carID, userID string, ok bool) {
+	carID = c.Param("car_id")
+	if carID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "car_id is required",
+		})
+		return "", "", false
+	}
+
+	userID, _, err := auth.GetUserIdFromToken(c.GetHeader("Authorization"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return "", "", false
+	}
+
+	if !h.checkCarOwner(c, userID, carID) {
+		return "", "", false
+	}
+
+	return carID, userID, true
+}
+
+// checkCarOwner - car userID'ga tegishli ekanligini tekshirish, aks holda javob yoziladi
+func (h *Handler) checkCarOwner(c *gin.Context, userID, carID string) bool {
+	check, err := h.Crud.CheckCarOwnership(c, &pb.BoolCheckCar{UserId: userID, CarId: carID})
+	if err != nil {
+		h.Log.Error("Error checking car ownership", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Error checking car ownership",
+		})
+		return false
+	}
+	if !check.Result {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User does not own the car",
+		})
+		return false
+	}
+	return true
+}
+
+// stagedPhotoKey - staging obyekt nomi. Car va user nomga kiradi, shuning uchun boshqa
+// foydalanuvchining upload_id'si bilan complete qilib bo'lmaydi
+func stagedPhotoKey(carID, userID, uploadID string) string {
+	return carID + "/" + userID + "/" + uploadID
+}
+
+type PhotoUploadURLRequest struct {
+	ContentType string `json:"content_type" binding:"required" example:"image/jpeg"`
+	Size        int64  `json:"size" binding:"required,gt=0" example:"2048000"`
+}
+
+type PhotoUploadURLResponse struct {
+	UploadId  string            `json:"upload_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	URL       string            `json:"url" example:"http://localhost:9000/photos-staging/..."`
+	Method    string            `json:"method" example:"PUT"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at" example:"2024-01-01T12:15:00Z"`
+}
+
+type CompletePhotoUploadRequest struct {
+	UploadId string `json:"upload_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
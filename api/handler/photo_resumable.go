@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wegugin/api/auth"
+	"wegugin/model"
+	"wegugin/storage/repo"
+	"wegugin/upload"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Bo'lak qaysi baytdan boshlanishini bildiruvchi header (tus protokolidagidek)
+const uploadOffsetHeader = "Upload-Offset"
+
+// @Summary CreateResumableUpload
+// @Security ApiKeyAuth
+// @Description Start a resumable photo upload. Send the file with PATCH in chunks of chunk_size bytes (the last one may be smaller), then call complete
+// @Tags IMAGES
+// @Accept json
+// @Produce json
+// @Param car_id path string true "car_id"
+// @Param upload body CreateResumableUploadRequest true "Photo content type and total size in bytes"
+// @Success 201 {object} ResumableUploadResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Router /v1/car/photo/{car_id}/uploads [post]
+func (h *Handler) CreateResumableUpload(c *gin.Context) {
+	var req CreateResumableUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if !h.resumableUploadsSupported(c) {
+		return
+	}
+	carID, userID, ok := h.checkPhotoCarOwnership(c)
+	if !ok {
+		return
+	}
+	if !h.checkDeclaredPhoto(c, req.ContentType, req.Size) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	id := uuid.NewString()
+	bucket := h.Config.Photo.STAGING_BUCKET
+	key := stagedPhotoKey(carID, userID, id)
+
+	multipartID, err := h.MINIO.StartMultipartUpload(ctx, bucket, key, req.ContentType)
+	if err != nil {
+		h.Log.Error("Failed to start resumable upload", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to start upload",
+		})
+		return
+	}
+
+	now := time.Now()
+	session := &model.UploadSession{
+		Id:          id,
+		UserId:      userID,
+		CarId:       carID,
+		Bucket:      bucket,
+		ObjectKey:   key,
+		MultipartId: multipartID,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		ChunkSize:   max(h.Config.Photo.CHUNK_SIZE, upload.MinChunkSize),
+		CreatedAt:   now,
+	}
+	session.ExpiresAt = h.uploadSessionExpiry(session, now)
+	if err := h.Cruds.Redis().CreateUploadSession(ctx, session); err != nil {
+		h.Log.Error("Failed to save upload session", "error", err)
+		if abortErr := h.MINIO.AbortMultipartUpload(ctx, bucket, key, multipartID); abortErr != nil {
+			h.Log.Warn("Failed to abort multipart upload", "key", key, "error", abortErr)
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to start upload",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newResumableUploadResponse(session))
+}
+
+// @Summary GetResumableUpload
+// @Security ApiKeyAuth
+// @Description Get the current offset of a resumable upload to continue it after a connection loss
+// @Tags IMAGES
+// @Produce json
+// @Param upload_id path string true "upload_id"
+// @Success 200 {object} ResumableUploadResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/car/photo/uploads/{upload_id} [get]
+func (h *Handler) GetResumableUpload(c *gin.Context) {
+	session, ok := h.getOwnUploadSession(c)
+	if !ok {
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	c.JSON(http.StatusOK, newResumableUploadResponse(session))
+}
+
+// @Summary UploadChunk
+// @Security ApiKeyAuth
+// @Description Upload the next chunk. Upload-Offset must equal the current offset; every chunk except the last must be exactly chunk_size bytes
+// @Tags IMAGES
+// @Accept octet-stream
+// @Produce json
+// @Param upload_id path string true "upload_id"
+// @Param Upload-Offset header int true "Offset of the first byte of this chunk"
+// @Success 200 {object} ResumableUploadResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ResumableUploadConflictResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Router /v1/car/photo/uploads/{upload_id} [patch]
+func (h *Handler) UploadChunk(c *gin.Context) {
+	if !h.resumableUploadsSupported(c) {
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Upload-Offset header is required",
+		})
+		return
+	}
+
+	session, ok := h.getOwnUploadSession(c)
+	if !ok {
+		return
+	}
+	if offset != session.Offset || session.Offset >= session.Size {
+		h.uploadOffsetConflict(c, session.Offset)
+		return
+	}
+
+	chunkSize := min(session.ChunkSize, session.Size-session.Offset)
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, chunkSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Failed to read chunk",
+		})
+		return
+	}
+	if int64(len(data)) != chunkSize {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("Chunk must be exactly %d bytes", chunkSize),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	partNumber := int(session.Offset/session.ChunkSize) + 1
+	part, err := h.MINIO.UploadPart(ctx, session.Bucket, session.ObjectKey, session.MultipartId, partNumber, bytes.NewReader(data), chunkSize)
+	if err != nil {
+		h.Log.Error("Failed to upload chunk", "upload_id", session.Id, "part", partNumber, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to store chunk",
+		})
+		return
+	}
+
+	session, err = h.Cruds.Redis().AdvanceUploadSession(ctx, session.Id, offset, chunkSize,
+		model.UploadPart{Number: part.Number, ETag: part.ETag}, h.uploadSessionExpiry(session, time.Now()))
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrUploadSessionNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: err.Error(),
+			})
+		case errors.Is(err, repo.ErrUploadOffsetMismatch):
+			// Parallel so'rov shu bo'lakni allaqachon yozgan
+			current, getErr := h.Cruds.Redis().GetUploadSession(ctx, c.Param("upload_id"))
+			if getErr != nil {
+				c.JSON(http.StatusConflict, ErrorResponse{
+					Error: err.Error(),
+				})
+				return
+			}
+			h.uploadOffsetConflict(c, current.Offset)
+		default:
+			h.Log.Error("Failed to update upload session", "upload_id", c.Param("upload_id"), "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to store chunk",
+			})
+		}
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	c.JSON(http.StatusOK, newResumableUploadResponse(session))
+}
+
+// @Summary CompleteResumableUpload
+// @Security ApiKeyAuth
+// @Description Finish a resumable upload once all bytes are sent: the file is validated, cleaned from metadata, resized into variants and attached to the car.
+// @Description After a 5xx response the upload is kept and the request can be retried
+// @Tags IMAGES
+// @Produce json
+// @Param upload_id path string true "upload_id"
+// @Success 200 {object} UploadedPhotoResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ResumableUploadConflictResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "Same photo is being deleted, retry"
+// @Router /v1/car/photo/uploads/{upload_id}/complete [post]
+func (h *Handler) CompleteResumableUpload(c *gin.Context) {
+	if !h.resumableUploadsSupported(c) {
+		return
+	}
+	session, ok := h.getOwnUploadSession(c)
+	if !ok {
+		return
+	}
+	if session.Offset != session.Size {
+		h.uploadOffsetConflict(c, session.Offset)
+		return
+	}
+	// Sessiya ochilgandan beri car boshqa userga o'tgan yoki o'chirilgan bo'lishi mumkin
+	if !h.checkCarOwner(c, session.UserId, session.CarId) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	// Oldingi complete so'rovi multipart upload'ni yig'ib, rasmni biriktirishda xato olgan bo'lishi mumkin
+	if !session.Completed {
+		parts := make([]upload.UploadedPart, len(session.Parts))
+		for i, p := range session.Parts {
+			parts[i] = upload.UploadedPart{Number: p.Number, ETag: p.ETag}
+		}
+		if err := h.MINIO.CompleteMultipartUpload(ctx, session.Bucket, session.ObjectKey, session.MultipartId, parts); err != nil {
+			h.Log.Error("Failed to complete resumable upload", "upload_id", session.Id, "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to assemble uploaded file",
+			})
+			return
+		}
+		if err := h.Cruds.Redis().CompleteUploadSession(ctx, session.Id); err != nil {
+			h.Log.Error("Failed to mark upload session completed", "upload_id", session.Id, "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to assemble uploaded file",
+			})
+			return
+		}
+	}
+
+	result := h.attachStagedPhoto(ctx, session.CarId, session.Bucket, session.ObjectKey)
+	// Server xatosida sessiya qoldiriladi, complete qayta yuborilishi mumkin. Qolgan hollarda
+	// sessiya endi kerak emas; o'chirilmay qolgan staging obyektni lifecycle qoidasi tozalaydi
+	if result.Status < http.StatusInternalServerError {
+		if err := h.Cruds.Redis().DeleteUploadSession(ctx, session.Id); err != nil {
+			h.Log.Warn("Failed to delete upload session", "upload_id", session.Id, "error", err)
+		}
+	}
+	if result.Error != "" {
+		c.JSON(result.Status, ErrorResponse{
+			Error: result.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadedPhotoResponse{
+		CarImageResponse: CarImageResponse{
+			Id:       result.PhotoId,
+			Filename: result.storedURL,
+			CarId:    session.CarId,
+			Variants: upload.VariantURLs(result.URL),
+		},
+		Duplicate: result.Duplicate,
+	})
+}
+
+// @Summary AbortResumableUpload
+// @Security ApiKeyAuth
+// @Description Cancel a resumable upload and delete the chunks uploaded so far
+// @Tags IMAGES
+// @Produce json
+// @Param upload_id path string true "upload_id"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/car/photo/uploads/{upload_id} [delete]
+func (h *Handler) AbortResumableUpload(c *gin.Context) {
+	session, ok := h.getOwnUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if err := h.AbortUploadSession(c.Request.Context(), session); err != nil {
+		h.Log.Error("Failed to abort resumable upload", "upload_id", session.Id, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to abort upload",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Upload aborted",
+	})
+}
+
+// AbortUploadSession - MinIO'dagi part'larni va Redis'dagi sessiyani o'chirish
+// (muddati tugagan sessiyalarni tozalovchi job ham shuni ishlatadi)
+func (h *Handler) AbortUploadSession(ctx context.Context, session *model.UploadSession) error {
+	if h.MINIO == nil {
+		return h.Cruds.Redis().DeleteUploadSession(ctx, session.Id)
+	}
+	if session.Completed {
+		// Multipart upload allaqachon yig'ilgan, staging obyektning o'zi o'chiriladi
+		if err := h.Store.Delete(ctx, session.Bucket, session.ObjectKey); err != nil {
+			return err
+		}
+	} else if err := h.MINIO.AbortMultipartUpload(ctx, session.Bucket, session.ObjectKey, session.MultipartId); err != nil {
+		return err
+	}
+	return h.Cruds.Redis().DeleteUploadSession(ctx, session.Id)
+}
+
+// resumableUploadsSupported - multipart yuklash faqat MinIO driverida mavjud
+func (h *Handler) resumableUploadsSupported(c *gin.Context) bool {
+	if h.MINIO != nil {
+		return true
+	}
+	c.JSON(http.StatusNotImplemented, ErrorResponse{
+		Error: "Resumable uploads are not supported by the configured storage",
+	})
+	return false
+}
+
+// getOwnUploadSession - path'dagi sessiyani olish. Boshqa userning sessiyasi topilmagan deb qaytadi
+func (h *Handler) getOwnUploadSession(c *gin.Context) (*model.UploadSession, bool) {
+	userID, _, err := auth.GetUserIdFromToken(c.GetHeader("Authorization"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return nil, false
+	}
+
+	session, err := h.Cruds.Redis().GetUploadSession(c.Request.Context(), c.Param("upload_id"))
+	if err == nil && session.UserId != userID {
+		err = repo.ErrUploadSessionNotFound
+	}
+	if err != nil {
+		if errors.Is(err, repo.ErrUploadSessionNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: err.Error(),
+			})
+			return nil, false
+		}
+		h.Log.Error("Failed to get upload session", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get upload session",
+		})
+		return nil, false
+	}
+
+	return session, true
+}
+
+func (h *Handler) uploadOffsetConflict(c *gin.Context, offset int64) {
+	c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	c.JSON(http.StatusConflict, ResumableUploadConflictResponse{
+		Error:  repo.ErrUploadOffsetMismatch.Error(),
+		Offset: offset,
+	})
+}
+
+// uploadSessionExpiry - oxirgi bo'lakdan UPLOAD_SESSION_HOURS keyin, lekin staging lifecycle
+// multipart upload'ni abort qilishidan oldin
+func (h *Handler) uploadSessionExpiry(session *model.UploadSession, now time.Time) time.Time {
+	expiresAt := now.Add(time.Duration(h.Config.Photo.UPLOAD_SESSION_HOURS) * time.Hour)
+	if limit := session.CreatedAt.Add(upload.MaxStagedUploadAge); expiresAt.After(limit) {
+		return limit
+	}
+	return expiresAt
+}
+
+func newResumableUploadResponse(session *model.UploadSession) ResumableUploadResponse {
+	return ResumableUploadResponse{
+		UploadId:  session.Id,
+		CarId:     session.CarId,
+		Size:      session.Size,
+		ChunkSize: session.ChunkSize,
+		Offset:    session.Offset,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+type CreateResumableUploadRequest struct {
+	ContentType string `json:"content_type" binding:"required" example:"image/jpeg"`
+	Size        int64  `json:"size" binding:"required,gt=0" example:"8388608"`
+}
+
+type ResumableUploadResponse struct {
+	UploadId  string    `json:"upload_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	CarId     string    `json:"car_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Size      int64     `json:"size" example:"8388608"`
+	ChunkSize int64     `json:"chunk_size" example:"5242880"`
+	Offset    int64     `json:"offset" example:"5242880"`
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-02T12:00:00Z"`
+}
+
+type ResumableUploadConflictResponse struct {
+	Error  string `json:"error" example:"upload offset does not match the session offset"`
+	Offset int64  `json:"offset" example:"5242880"`
+}
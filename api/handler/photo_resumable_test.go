@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wegugin/config"
+	"wegugin/model"
+	"wegugin/upload"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeS3 - PutObjectPart so'rovlarini qabul qiladigan minimal S3 server
+type fakeS3 struct {
+	mu        sync.Mutex
+	parts     map[int]string
+	completed int // CompleteMultipartUpload so'rovlari soni
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := r.URL.Query()["location"]; ok {
+		w.Header().Set("Content-Type", "application/xml")
+		io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`)
+		return
+	}
+	if r.Method == http.MethodPost && r.URL.Query().Get("uploadId") != "" {
+		s.mu.Lock()
+		s.completed++
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/xml")
+		io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><CompleteMultipartUploadResult><Bucket>staging</Bucket><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		return
+	}
+	if r.Method != http.MethodPut || r.URL.Query().Get("uploadId") == "" {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	number, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	data, _ := io.ReadAll(r.Body)
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		data = decodeAWSChunked(data)
+	}
+	s.mu.Lock()
+	s.parts[number] = string(data)
+	s.mu.Unlock()
+	w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+}
+
+// decodeAWSChunked - imzolangan streaming body'dan ("<hex size>;chunk-signature=...\r\n<data>\r\n") ma'lumotni ajratish
+func decodeAWSChunked(body []byte) []byte {
+	var data []byte
+	rest := string(body)
+	for {
+		header, after, ok := strings.Cut(rest, "\r\n")
+		if !ok {
+			return data
+		}
+		sizeHex, _, _ := strings.Cut(header, ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil || size == 0 || int64(len(after)) < size {
+			return data
+		}
+		data = append(data, after[:size]...)
+		rest = strings.TrimPrefix(after[size:], "\r\n")
+	}
+}
+
+const testUploadID = "upload-1"
+
+// unavailableStore - staging bucketni o'qib bo'lmaydi (MinIO vaqtincha ishlamayapti)
+type unavailableStore struct {
+	upload.ObjectStore
+}
+
+func (unavailableStore) Stat(ctx context.Context, bucket, key string) (*upload.ObjectStat, error) {
+	return nil, errors.New("connection refused")
+}
+
+type resumableTest struct {
+	router *gin.Engine
+	redis  *fakeRedis
+	s3     *fakeS3
+	token  string
+}
+
+func newResumableTest(t *testing.T, size, chunkSize int64) *resumableTest {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	s3 := &fakeS3{parts: make(map[int]string)}
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.Minio.MINIO_ENDPOINT = strings.TrimPrefix(server.URL, "http://")
+	cfg.Minio.MINIO_ACCESS_KEY_ID = "access"
+	cfg.Minio.MINIO_SECRET_ACCESS_KEY = "secret"
+	cfg.Photo.UPLOAD_SESSION_HOURS = 24
+	minioUploader, err := upload.NewMinioUploader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redis := &fakeRedis{sessions: map[string]model.UploadSession{
+		testUploadID: {
+			Id:          testUploadID,
+			UserId:      "user-1",
+			CarId:       "car-1",
+			Bucket:      "staging",
+			ObjectKey:   "car-1/user-1/" + testUploadID,
+			MultipartId: "multipart-1",
+			Size:        size,
+			ChunkSize:   chunkSize,
+			CreatedAt:   time.Now(),
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+	}}
+	h := &Handler{
+		Cruds:  &fakeStorage{redis: redis},
+		Crud:   &fakeCruds{},
+		Store:  unavailableStore{},
+		Log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: cfg,
+		MINIO:  minioUploader,
+	}
+
+	r := gin.New()
+	r.PATCH("/v1/car/photo/uploads/:upload_id", h.UploadChunk)
+	r.POST("/v1/car/photo/uploads/:upload_id/complete", h.CompleteResumableUpload)
+	return &resumableTest{router: r, redis: redis, s3: s3, token: testToken(t, "user-1")}
+}
+
+func (rt *resumableTest) sendChunk(offset string, chunk string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/v1/car/photo/uploads/"+testUploadID, strings.NewReader(chunk))
+	req.Header.Set("Authorization", rt.token)
+	if offset != "" {
+		req.Header.Set(uploadOffsetHeader, offset)
+	}
+
+	w := httptest.NewRecorder()
+	rt.router.ServeHTTP(w, req)
+	return w
+}
+
+func (rt *resumableTest) complete() *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/car/photo/uploads/"+testUploadID+"/complete", nil)
+	req.Header.Set("Authorization", rt.token)
+
+	w := httptest.NewRecorder()
+	rt.router.ServeHTTP(w, req)
+	return w
+}
+
+func (rt *resumableTest) session(t *testing.T) *model.UploadSession {
+	t.Helper()
+	session, err := rt.redis.GetUploadSession(context.Background(), testUploadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func assertConflict(t *testing.T, w *httptest.ResponseRecorder, offset int64) {
+	t.Helper()
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status code = %d, want %d (%s)", w.Code, http.StatusConflict, w.Body.String())
+	}
+	var res ResumableUploadConflictResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Offset != offset || w.Header().Get(uploadOffsetHeader) != strconv.FormatInt(offset, 10) {
+		t.Fatalf("conflict offset = %d (header %q), want %d", res.Offset, w.Header().Get(uploadOffsetHeader), offset)
+	}
+}
+
+func TestUploadChunkAdvancesOffset(t *testing.T) {
+	rt := newResumableTest(t, 10, 4)
+
+	for _, chunk := range []struct {
+		offset string
+		data   string
+		want   int64
+	}{
+		{"0", "abcd", 4},
+		{"4", "efgh", 8},
+		{"8", "ij", 10}, // oxirgi bo'lak kichikroq
+	} {
+		w := rt.sendChunk(chunk.offset, chunk.data)
+		if w.Code != http.StatusOK {
+			t.Fatalf("offset %s: status code = %d, want %d (%s)", chunk.offset, w.Code, http.StatusOK, w.Body.String())
+		}
+		if got := w.Header().Get(uploadOffsetHeader); got != strconv.FormatInt(chunk.want, 10) {
+			t.Fatalf("offset %s: Upload-Offset = %s, want %d", chunk.offset, got, chunk.want)
+		}
+	}
+
+	session := rt.session(t)
+	if session.Offset != 10 || len(session.Parts) != 3 {
+		t.Fatalf("offset = %d, parts = %d, want 10 and 3", session.Offset, len(session.Parts))
+	}
+	for i, part := range session.Parts {
+		if part.Number != i+1 || part.ETag != fmt.Sprintf("etag-%d", i+1) {
+			t.Fatalf("part %d = %+v", i, part)
+		}
+	}
+	if rt.s3.parts[1]+rt.s3.parts[2]+rt.s3.parts[3] != "abcdefghij" {
+		t.Fatalf("uploaded parts = %v", rt.s3.parts)
+	}
+
+	// Fayl to'liq yuklangandan keyin bo'lak qabul qilinmaydi
+	assertConflict(t, rt.sendChunk("10", "k"), 10)
+}
+
+func TestUploadChunkRejectsWrongOffset(t *testing.T) {
+	rt := newResumableTest(t, 10, 4)
+	if w := rt.sendChunk("0", "abcd"); w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Qayta yuborilgan va oldinga sakragan bo'laklar joriy offset bilan rad etiladi
+	assertConflict(t, rt.sendChunk("0", "abcd"), 4)
+	assertConflict(t, rt.sendChunk("8", "ij"), 4)
+
+	for _, offset := range []string{"", "-4", "four"} {
+		if w := rt.sendChunk(offset, "efgh"); w.Code != http.StatusBadRequest {
+			t.Fatalf("offset %q: status code = %d, want %d", offset, w.Code, http.StatusBadRequest)
+		}
+	}
+
+	if session := rt.session(t); session.Offset != 4 || len(session.Parts) != 1 {
+		t.Fatalf("offset = %d, parts = %d, want 4 and 1", session.Offset, len(session.Parts))
+	}
+}
+
+func TestUploadChunkRejectsWrongChunkSize(t *testing.T) {
+	rt := newResumableTest(t, 10, 4)
+
+	for _, chunk := range []string{"abc", "abcde"} {
+		if w := rt.sendChunk("0", chunk); w.Code != http.StatusBadRequest {
+			t.Fatalf("chunk %q: status code = %d, want %d", chunk, w.Code, http.StatusBadRequest)
+		}
+	}
+	if len(rt.s3.parts) != 0 {
+		t.Fatalf("%d parts uploaded for rejected chunks", len(rt.s3.parts))
+	}
+	if session := rt.session(t); session.Offset != 0 {
+		t.Fatalf("offset = %d, want 0", session.Offset)
+	}
+}
+
+func TestUploadChunkConcurrentAdvanceConflicts(t *testing.T) {
+	rt := newResumableTest(t, 10, 4)
+
+	// Parallel so'rov shu bo'lakni part yozilgandan keyin, sessiya yangilanishidan oldin yozib ulgurgan
+	rt.redis.beforeAdvance = func() {
+		rt.redis.beforeAdvance = nil
+		if _, err := rt.redis.AdvanceUploadSession(context.Background(), testUploadID, 0, 4,
+			model.UploadPart{Number: 1, ETag: "etag-1"}, time.Now().Add(time.Hour)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	assertConflict(t, rt.sendChunk("0", "abcd"), 4)
+	if session := rt.session(t); session.Offset != 4 || len(session.Parts) != 1 {
+		t.Fatalf("offset = %d, parts = %d, want the chunk recorded once", session.Offset, len(session.Parts))
+	}
+}
+
+func TestUploadChunkHidesOtherUsersSession(t *testing.T) {
+	rt := newResumableTest(t, 10, 4)
+	rt.token = testToken(t, "user-2")
+
+	if w := rt.sendChunk("0", "abcd"); w.Code != http.StatusNotFound {
+		t.Fatalf("status code = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if len(rt.s3.parts) != 0 {
+		t.Fatal("chunk of another user's session was uploaded")
+	}
+}
+
+func TestUploadChunkCapsSessionLifetime(t *testing.T) {
+	rt := newResumableTest(t, 10, 4)
+	// Sessiya lifecycle qoidasi multipart upload'ni abort qilishiga yaqin ochilgan
+	createdAt := time.Now().Add(-upload.MaxStagedUploadAge + time.Hour)
+	rt.redis.mu.Lock()
+	session := rt.redis.sessions[testUploadID]
+	session.CreatedAt = createdAt
+	rt.redis.sessions[testUploadID] = session
+	rt.redis.mu.Unlock()
+
+	if w := rt.sendChunk("0", "abcd"); w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+	if got, limit := rt.session(t).ExpiresAt, createdAt.Add(upload.MaxStagedUploadAge); got.After(limit) {
+		t.Fatalf("expires_at = %v, want at most %v", got, limit)
+	}
+}
+
+func TestCompleteResumableUploadIsRetryable(t *testing.T) {
+	rt := newResumableTest(t, 4, 4)
+	if w := rt.sendChunk("0", "abcd"); w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Rasmni biriktirish server xatosi bilan tugadi: sessiya saqlanib qoladi
+	for i := 0; i < 2; i++ {
+		if w := rt.complete(); w.Code != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: status code = %d, want %d (%s)", i+1, w.Code, http.StatusInternalServerError, w.Body.String())
+		}
+		if !rt.session(t).Completed {
+			t.Fatalf("attempt %d: session is not marked completed", i+1)
+		}
+	}
+	// Yig'ilgan multipart upload qayta yig'ilmaydi
+	if rt.s3.completed != 1 {
+		t.Fatalf("multipart upload completed %d times, want 1", rt.s3.completed)
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"wegugin/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxAnalyticsRangeDays = 366
+
+// GetTopCarAnalytics godoc
+// @Summary Top car analytics (admin)
+// @Description Counts of created, active (started), expired and early deleted promotions grouped by category and by day, week or month over [from, to), plus top sellers by promotion count
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param from query string false "Range start, RFC3339 or YYYY-MM-DD (default 30 days before to)"
+// @Param to query string false "Range end (exclusive), RFC3339 or YYYY-MM-DD (default now)"
+// @Param interval query string false "Grouping interval: day, week (ISO) or month (default day)"
+// @Param timezone query string false "IANA timezone used for dates and periods (default UTC)"
+// @Param top query int false "Number of top sellers (default 10, max 100)"
+// @Success 200 {object} TopCarAnalyticsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/topcar/analytics [get]
+func (h *Handler) GetTopCarAnalytics(c *gin.Context) {
+	filter := model.TopCarAnalyticsFilter{
+		Interval:        c.DefaultQuery("interval", "day"),
+		Timezone:        c.DefaultQuery("timezone", "UTC"),
+		TopSellersLimit: 10,
+	}
+	if filter.Interval != "day" && filter.Interval != "week" && filter.Interval != "month" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid interval. Must be day, week or month",
+		})
+		return
+	}
+
+	loc, err := time.LoadLocation(filter.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid timezone",
+		})
+		return
+	}
+
+	filter.To = time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		if filter.To, err = parseAnalyticsDate(toStr, loc); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid to, use RFC3339 or YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	filter.From = filter.To.AddDate(0, 0, -30)
+	if fromStr := c.Query("from"); fromStr != "" {
+		if filter.From, err = parseAnalyticsDate(fromStr, loc); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid from, use RFC3339 or YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if !filter.From.Before(filter.To) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "from must be before to",
+		})
+		return
+	}
+	if filter.To.Sub(filter.From) > maxAnalyticsRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Date range can not be longer than 366 days",
+		})
+		return
+	}
+
+	if topStr := c.Query("top"); topStr != "" {
+		top, err := strconv.ParseInt(topStr, 10, 64)
+		if err != nil || top <= 0 || top > 100 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "top must be between 1 and 100",
+			})
+			return
+		}
+		filter.TopSellersLimit = top
+	}
+
+	analytics, err := h.Cruds.TopCars().GetTopCarAnalytics(c.Request.Context(), filter)
+	if err != nil {
+		h.Log.Error("Failed to get top car analytics", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get top car analytics",
+		})
+		return
+	}
+
+	userIDs := make([]string, 0, len(analytics.TopSellers))
+	for _, seller := range analytics.TopSellers {
+		userIDs = append(userIDs, seller.UserId)
+	}
+	owners := h.getTopCarOwners(c.Request.Context(), userIDs)
+
+	sellers := make([]TopCarSellerResponse, 0, len(analytics.TopSellers))
+	for _, seller := range analytics.TopSellers {
+		sellers = append(sellers, TopCarSellerResponse{
+			TopCarSeller: seller,
+			Owner:        owners[seller.UserId],
+		})
+	}
+
+	c.JSON(http.StatusOK, TopCarAnalyticsResponse{
+		From:       filter.From,
+		To:         filter.To,
+		Interval:   filter.Interval,
+		Timezone:   filter.Timezone,
+		Series:     analytics.Series,
+		Totals:     analytics.Totals,
+		TopSellers: sellers,
+	})
+}
+
+// parseAnalyticsDate - RFC3339 yoki berilgan vaqt zonasidagi YYYY-MM-DD sana
+func parseAnalyticsDate(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+type TopCarSellerResponse struct {
+	model.TopCarSeller
+	Owner *TopCarOwner `json:"owner,omitempty"`
+}
+
+type TopCarAnalyticsResponse struct {
+	From       time.Time                     `json:"from" example:"2024-01-01T00:00:00Z"`
+	To         time.Time                     `json:"to" example:"2024-01-31T00:00:00Z"`
+	Interval   string                        `json:"interval" example:"day"`
+	Timezone   string                        `json:"timezone" example:"UTC"`
+	Series     []model.TopCarAnalyticsBucket `json:"series"`
+	Totals     []model.TopCarAnalyticsBucket `json:"totals"`
+	TopSellers []TopCarSellerResponse        `json:"top_sellers"`
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"wegugin/model"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RecordTopCarClick godoc
+// @Summary Record top car click
+// @Description Record a click on a promoted car. Repeated clicks from the same session (X-Session-Id header)
+// @Description or client IP are counted once per promotion within TOPCAR_CLICK_DEDUP_MINUTES.
+// @Description Only active promotions within their window are counted
+// @Tags TopCars
+// @Accept json
+// @Produce json
+// @Param id path string true "Top Car ID"
+// @Param X-Session-Id header string false "Client session ID, client IP is used when not set"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/topcar/{id}/click [post]
+func (h *Handler) RecordTopCarClick(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid ID format",
+		})
+		return
+	}
+
+	topCar, err := h.Cruds.TopCars().GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Top car not found",
+			})
+			return
+		}
+		h.Log.Error("Failed to get top car by ID", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to record click",
+		})
+		return
+	}
+
+	now := time.Now()
+	// To'lanmagan, bekor qilingan yoki hali boshlanmagan promotion ro'yxatda ko'rinmaydi
+	if !isTopCarRunning(topCar, now) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Top car is not active",
+		})
+		return
+	}
+
+	window := time.Duration(h.Config.TopCar.CLICK_DEDUP_MINUTES) * time.Minute
+	if window <= 0 {
+		window = 30 * time.Minute
+	}
+	counted, err := h.Cruds.Redis().IncrTopCarClick(c.Request.Context(), id.Hex(), clickVisitor(c), window, now)
+	if err != nil {
+		h.Log.Error("Failed to record top car click", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to record click",
+		})
+		return
+	}
+
+	message := "Click recorded successfully"
+	if !counted {
+		message = "Click already recorded"
+	}
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: message,
+	})
+}
+
+// isTopCarRunning - promotion active va oynasi hozirgi vaqtni o'z ichiga oladi.
+// Status bo'lmasa status qo'shilishidan oldingi bepul yozuv (active)
+func isTopCarRunning(topCar *model.TopCars, now time.Time) bool {
+	if topCar.Status != "" && topCar.Status != model.TopCarStatusActive {
+		return false
+	}
+	return !topCar.StartsAt.After(now) && topCar.FinishedAt.After(now)
+}
+
+// clickVisitor - clicklarni dedup qilish uchun visitor kaliti: sessiya ID'si, bo'lmasa client IP.
+// Redis'da xom IP saqlanmasligi uchun hash qilinadi
+func clickVisitor(c *gin.Context) string {
+	visitor := "ip:" + c.ClientIP()
+	if session := c.GetHeader("X-Session-Id"); session != "" {
+		visitor = "session:" + session
+	}
+	sum := sha256.Sum256([]byte(visitor))
+	return hex.EncodeToString(sum[:16])
+}
+
+// GetTopCarStats godoc
+// @Summary Get top car stats
+// @Description Get impressions, clicks and CTR per day for an owned promotion
+// @Tags TopCars
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Top Car ID"
+// @Param from query string false "From day (YYYY-MM-DD), default 30 days ago"
+// @Param to query string false "To day (YYYY-MM-DD), default today"
+// @Success 200 {object} TopCarStatsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/topcar/{id}/stats [get]
+func (h *Handler) GetTopCarStats(c *gin.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -29), today
+	var err error
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid from date, must be YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid to date, must be YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid date range, must be at most 366 days",
+		})
+		return
+	}
+
+	// Cleanup job muddati tugaganlarni soft delete qiladi, ularning statistikasi ham ko'rinishi kerak
+	topCar, _, ok := h.getOwnedTopCar(c, true)
+	if !ok {
+		return
+	}
+	topCarID := topCar.ID.Hex()
+
+	stored, err := h.Cruds.TopCarStats().GetDailyStats(c.Request.Context(), topCarID, from, to)
+	if err != nil {
+		h.Log.Error("Failed to get top car stats", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get top car stats",
+		})
+		return
+	}
+
+	var days []time.Time
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	// Hali flush qilinmagan hisoblagichlar ham qo'shiladi
+	pending, err := h.Cruds.Redis().GetTopCarCounters(c.Request.Context(), topCarID, days)
+	if err != nil {
+		h.Log.Warn("Failed to get pending top car counters", "error", err)
+	}
+
+	byDay := make(map[time.Time]*TopCarDayStats, len(days))
+	response := TopCarStatsResponse{TopCarId: topCarID, Days: make([]TopCarDayStats, 0, len(days))}
+	for _, day := range days {
+		response.Days = append(response.Days, TopCarDayStats{Day: day.Format("2006-01-02")})
+		byDay[day] = &response.Days[len(response.Days)-1]
+	}
+	for _, list := range [][]model.TopCarDailyStats{stored, pending} {
+		for _, st := range list {
+			if d, ok := byDay[st.Day.UTC()]; ok {
+				d.Impressions += st.Impressions
+				d.Clicks += st.Clicks
+			}
+		}
+	}
+
+	for i := range response.Days {
+		d := &response.Days[i]
+		d.CTR = ctr(d.Impressions, d.Clicks)
+		response.TotalImpressions += d.Impressions
+		response.TotalClicks += d.Clicks
+	}
+	response.CTR = ctr(response.TotalImpressions, response.TotalClicks)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func ctr(impressions, clicks int64) float64 {
+	if impressions == 0 {
+		return 0
+	}
+	return float64(clicks) / float64(impressions)
+}
+
+// Request/Response structures
+type TopCarDayStats struct {
+	Day         string  `json:"day" example:"2024-01-01"`
+	Impressions int64   `json:"impressions" example:"1200"`
+	Clicks      int64   `json:"clicks" example:"36"`
+	CTR         float64 `json:"ctr" example:"0.03"`
+}
+
+type TopCarStatsResponse struct {
+	TopCarId         string           `json:"top_car_id" example:"507f1f77bcf86cd799439011"`
+	Days             []TopCarDayStats `json:"days"`
+	TotalImpressions int64            `json:"total_impressions" example:"1200"`
+	TotalClicks      int64            `json:"total_clicks" example:"36"`
+	CTR              float64          `json:"ctr" example:"0.03"`
+}
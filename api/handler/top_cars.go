@@ -1,21 +1,36 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"wegugin/api/auth"
 	"wegugin/genproto/cruds"
 	"wegugin/model"
+	"wegugin/payment"
+	"wegugin/storage/repo"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// startsAtClockSkew - client soati shuncha orqada qolgan bo'lsa ham starts_at qabul qilinadi (hozirgi vaqtga tenglanadi)
+const startsAtClockSkew = 5 * time.Minute
+
 // CreateTopCar godoc
 // @Summary Create a new top car
-// @Description Add a new car to top cars list
+// @Description Add a new car to top cars list. Promotion starts at starts_at (or now) and lasts for the category preset or duration_hours for the custom category.
+// @Description starts_at in the past is rejected; up to 5 minutes behind the server clock is treated as now
+// @Description The promotion is created as pending and becomes visible only after the payment is confirmed
 // @Tags TopCars
 // @Accept json
 // @Produce json
@@ -26,6 +41,8 @@ import (
 // @Failure 401 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
 // @Router /v1/topcar [post]
 func (h *Handler) CreateTopCar(c *gin.Context) {
 	var req CreateTopCarRequest
@@ -46,19 +63,31 @@ func (h *Handler) CreateTopCar(c *gin.Context) {
 		return
 	}
 
-	// FinishedAt ni hisoblash
-	var finishedAt time.Time
+	// StartsAt va FinishedAt ni hisoblash
 	now := time.Now()
-	switch req.Category {
-	case "daily":
-		finishedAt = now.Add(24 * time.Hour)
-	case "weekly":
-		finishedAt = now.Add(7 * 24 * time.Hour)
-	case "monthly":
-		finishedAt = now.Add(30 * 24 * time.Hour)
-	default:
+	startsAt := now
+	if req.StartsAt != nil {
+		if req.StartsAt.Before(now.Add(-startsAtClockSkew)) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "starts_at must not be in the past",
+			})
+			return
+		}
+		if req.StartsAt.After(now) {
+			startsAt = *req.StartsAt
+		}
+	}
+	if startsAt.After(now.AddDate(0, 0, h.Config.TopCar.MAX_SCHEDULE_AHEAD_DAYS)) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Invalid category. Must be daily, weekly, or monthly",
+			Error: fmt.Sprintf("starts_at can not be more than %d days ahead", h.Config.TopCar.MAX_SCHEDULE_AHEAD_DAYS),
+		})
+		return
+	}
+
+	duration, err := h.topCarDuration(req.Category, req.DurationHours)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
 		})
 		return
 	}
@@ -68,7 +97,24 @@ func (h *Handler) CreateTopCar(c *gin.Context) {
 		UserId:     userID,
 		Category:   req.Category,
 		CreatedAt:  now,
-		FinishedAt: finishedAt,
+		StartsAt:   startsAt,
+		FinishedAt: startsAt.Add(duration),
+		Status:     model.TopCarStatusPending,
+		Amount:     h.topCarPrice(req.Category, duration),
+		Currency:   h.Config.TopCar.CURRENCY,
+	}
+	topCar.BaseAmount = topCar.Amount
+
+	if topCar.Amount > 0 && h.Payment == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error: "Paid promotions are not available, payments are not configured",
+		})
+		return
+	}
+	// Narxi 0 bo'lsa to'lov talab qilinmaydi
+	if topCar.Amount == 0 {
+		topCar.PaidAt = &now
+		topCar.Status = model.TopCarStatusPaid
 	}
 
 	resbool, err := h.Crud.CheckCarOwnership(c, &cruds.BoolCheckCar{UserId: userID, CarId: req.CarId})
@@ -84,9 +130,15 @@ func (h *Handler) CreateTopCar(c *gin.Context) {
 	}
 	err = h.Cruds.TopCars().CreateTopCar(c.Request.Context(), topCar)
 	if err != nil {
-		if err.Error() == "topCar already exists in this category and is still active" {
+		if errors.Is(err, repo.ErrTopCarOverlap) {
 			c.JSON(http.StatusConflict, ErrorResponse{
-				Error: "Car is already in active top list for this category",
+				Error: "Car already has a top promotion overlapping this time window",
+			})
+			return
+		}
+		if errors.Is(err, repo.ErrTopCarBusy) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Promotions of this car are being changed, please retry",
 			})
 			return
 		}
@@ -97,43 +149,100 @@ func (h *Handler) CreateTopCar(c *gin.Context) {
 		return
 	}
 
+	message := "Top car created successfully"
+	var checkoutURL string
+	if topCar.Status == model.TopCarStatusPaid {
+		h.activateTopCars(c.Request.Context())
+	}
+	if topCar.Status == model.TopCarStatusPending {
+		pay, err := h.Payment.CreatePayment(c.Request.Context(), payment.PaymentRequest{
+			OrderID:     topCar.ID.Hex(),
+			UserID:      userID,
+			Amount:      topCar.Amount,
+			Currency:    topCar.Currency,
+			Description: fmt.Sprintf("Top car promotion (%s) for car %s", topCar.Category, topCar.CarId),
+		})
+		if err == nil {
+			err = h.Cruds.TopCars().UpdateTopCar(c.Request.Context(), topCar.ID, bson.M{"payment_id": pay.ID})
+		}
+		if err != nil {
+			h.Log.Error("Failed to create payment for top car", "error", err, "top_car_id", topCar.ID.Hex())
+			// To'lovsiz qolgan promotion vaqt oralig'ini band qilmasligi uchun o'chiramiz
+			if delErr := h.Cruds.TopCars().DeleteByID(c.Request.Context(), topCar.ID); delErr != nil {
+				h.Log.Error("Failed to delete unpaid top car", "error", delErr, "top_car_id", topCar.ID.Hex())
+			}
+			c.JSON(http.StatusBadGateway, ErrorResponse{
+				Error: "Failed to create payment",
+			})
+			return
+		}
+		topCar.PaymentId = pay.ID
+		checkoutURL = pay.CheckoutURL
+		message = "Top car created, waiting for payment"
+	}
+
+	// Slotlar band bo'lsa promotion to'langandan keyin navbatda kutadi
+	waitlisted := false
+	if limit := h.Config.TopCar.SlotsByCategory()[topCar.Category]; limit > 0 && !topCar.StartsAt.After(now) {
+		active, err := h.Cruds.TopCars().CountActiveTopCars(c.Request.Context(), topCar.Category)
+		if err != nil {
+			h.Log.Error("Failed to count active top cars", "error", err)
+		} else if active >= int64(limit) {
+			waitlisted = true
+			message += ". All slots are taken, the promotion will start when a slot becomes free"
+		}
+	}
+
 	c.JSON(http.StatusCreated, CreateTopCarResponse{
-		ID:         topCar.ID.Hex(),
-		CarId:      topCar.CarId,
-		UserId:     topCar.UserId,
-		Category:   topCar.Category,
-		CreatedAt:  topCar.CreatedAt,
-		FinishedAt: topCar.FinishedAt,
-		Message:    "Top car created successfully",
+		ID:          topCar.ID.Hex(),
+		CarId:       topCar.CarId,
+		UserId:      topCar.UserId,
+		Category:    topCar.Category,
+		CreatedAt:   topCar.CreatedAt,
+		StartsAt:    topCar.StartsAt,
+		FinishedAt:  topCar.FinishedAt,
+		Status:      topCar.Status,
+		Amount:      topCar.Amount,
+		Currency:    topCar.Currency,
+		PaymentId:   topCar.PaymentId,
+		CheckoutURL: checkoutURL,
+		Waitlisted:  waitlisted,
+		Message:     message,
 	})
 }
 
 // GetTopCars godoc
 // @Summary Get list of top cars
-// @Description Get filtered list of top cars
+// @Description Get filtered list of top cars. By default only promotions whose window covers the current time are returned
 // @Tags TopCars
 // @Accept json
 // @Produce json
 // @Param category query string false "Category filter (daily, weekly, monthly)"
 // @Param user_id query string false "User ID filter"
 // @Param car_id query string false "Car ID filter"
-// @Param sort_by query string false "Sort by (finished_at_asc, finished_at_desc, created_at_asc, created_at_desc)"
+// @Param sort_by query string false "Sort by (finished_at_asc, finished_at_desc, created_at_asc, created_at_desc, rotation). rotation shuffles promotions fairly and keeps the order stable within a time bucket"
 // @Param show_expired query bool false "Show expired top cars"
+// @Param show_scheduled query bool false "Show top cars whose promotion has not started yet"
 // @Param show_deleted query bool false "Show deleted top cars"
+// @Param show_unpaid query bool false "Show top cars that are not paid yet"
 // @Param limit query int false "Limit results (default 50)"
-// @Param skip query int false "Skip results for pagination"
+// @Param skip query int false "Skip results for pagination (ignored when cursor is set)"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param expand query string false "Set to car to embed car details (promotions of deleted or unavailable cars are dropped)"
 // @Success 200 {object} GetTopCarsResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /v1/topcar [get]
 func (h *Handler) GetTopCars(c *gin.Context) {
 	filter := model.TopCarsFilter{
-		Category:    c.Query("category"),
-		UserID:      c.Query("user_id"),
-		CarID:       c.Query("car_id"),
-		SortBy:      c.Query("sort_by"),
-		ShowExpired: c.Query("show_expired") == "true",
-		ShowDeleted: c.Query("show_deleted") == "true",
+		Category:      c.Query("category"),
+		UserID:        c.Query("user_id"),
+		CarID:         c.Query("car_id"),
+		SortBy:        c.Query("sort_by"),
+		ShowExpired:   c.Query("show_expired") == "true",
+		ShowScheduled: c.Query("show_scheduled") == "true",
+		ShowDeleted:   c.Query("show_deleted") == "true",
+		ShowUnpaid:    c.Query("show_unpaid") == "true",
 	}
 
 	if limitStr := c.Query("limit"); limitStr != "" {
@@ -148,6 +257,38 @@ func (h *Handler) GetTopCars(c *gin.Context) {
 		}
 	}
 
+	if filter.Limit <= 0 {
+		filter.Limit = 50 // default limit
+	}
+
+	// Cursor pagination (skip o'rniga), skip eski clientlar uchun qoladi
+	if cursor := c.Query("cursor"); cursor != "" {
+		if filter.SortBy == "rotation" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "cursor is not supported with rotation sort, use skip",
+			})
+			return
+		}
+		after, err := model.DecodeTopCarsCursor(cursor, filter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid cursor: " + err.Error(),
+			})
+			return
+		}
+		filter.After = after
+		filter.Skip = 0
+	}
+
+	// Rotation tartibi har bir vaqt oralig'ida (bucket) o'zgarmaydi
+	if filter.SortBy == "rotation" {
+		bucket := int64(h.Config.TopCar.ROTATION_BUCKET_MINUTES) * 60
+		if bucket <= 0 {
+			bucket = 60
+		}
+		filter.RotationSeed = time.Now().Unix() / bucket
+	}
+
 	topCars, err := h.Cruds.TopCars().GetListOfTopCars(c.Request.Context(), filter)
 	if err != nil {
 		h.Log.Error("Failed to get top cars", "error", err)
@@ -164,24 +305,51 @@ func (h *Handler) GetTopCars(c *gin.Context) {
 		count = 0
 	}
 
+	// expand=car bo'lsa car ma'lumotlari qo'shiladi, o'chirilgan yoki sotilgan carlar tashlab yuboriladi
+	var cars map[string]*model.CarSummary
+	expandCar := c.Query("expand") == "car"
+	if expandCar {
+		carIDs := make([]string, 0, len(topCars))
+		for _, topCar := range topCars {
+			carIDs = append(carIDs, topCar.CarId)
+		}
+		cars = h.getCarSummaries(c.Request.Context(), carIDs)
+	}
+
 	var response []TopCarResponse
+	ids := make([]string, 0, len(topCars))
 	for _, topCar := range topCars {
-		response = append(response, TopCarResponse{
-			ID:         topCar.ID.Hex(),
-			CarId:      topCar.CarId,
-			UserId:     topCar.UserId,
-			Category:   topCar.Category,
-			CreatedAt:  topCar.CreatedAt,
-			FinishedAt: topCar.FinishedAt,
-			DeletedAt:  topCar.DeletedAt,
-		})
+		item := toTopCarResponse(topCar)
+		if expandCar {
+			car, found := cars[topCar.CarId]
+			if found && (car == nil || !car.Available) {
+				continue
+			}
+			item.Car = car
+		}
+		response = append(response, item)
+		ids = append(ids, topCar.ID.Hex())
+	}
+
+	// Impressionlar Redis'da hisoblanadi va vaqti-vaqti bilan Mongo'ga yoziladi.
+	// Faqat public active ro'yxat hisoblanadi: egasi yoki admin filtrlari bilan ko'rishlari emas
+	if isPublicTopCarListing(filter) {
+		if err := h.Cruds.Redis().IncrTopCarImpressions(c.Request.Context(), ids, time.Now()); err != nil {
+			h.Log.Warn("Failed to record top car impressions", "error", err)
+		}
+	}
+
+	var nextCursor string
+	if filter.SortBy != "rotation" && int64(len(topCars)) == filter.Limit {
+		nextCursor = model.NewTopCarsCursor(filter, topCars[len(topCars)-1]).Encode()
 	}
 
 	c.JSON(http.StatusOK, GetTopCarsResponse{
-		TopCars: response,
-		Total:   count,
-		Limit:   filter.Limit,
-		Skip:    filter.Skip,
+		TopCars:    response,
+		Total:      count,
+		Limit:      filter.Limit,
+		Skip:       filter.Skip,
+		NextCursor: nextCursor,
 	})
 }
 
@@ -222,15 +390,361 @@ func (h *Handler) GetTopCarByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, TopCarResponse{
-		ID:         topCar.ID.Hex(),
-		CarId:      topCar.CarId,
-		UserId:     topCar.UserId,
-		Category:   topCar.Category,
-		CreatedAt:  topCar.CreatedAt,
-		FinishedAt: topCar.FinishedAt,
-		DeletedAt:  topCar.DeletedAt,
+	c.JSON(http.StatusOK, toTopCarResponse(topCar))
+}
+
+// UpdateTopCar godoc
+// @Summary Update top car
+// @Description Change category/duration of an owned promotion. starts_at can only be changed while the promotion has not started yet.
+// @Description If the new price is higher than the paid amount, a payment for the difference is opened (202) and the new window
+// @Description is applied only after the payment webhook confirms it
+// @Tags TopCars
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Top Car ID"
+// @Param topcar body UpdateTopCarRequest true "Top Car data"
+// @Success 200 {object} TopCarResponse
+// @Success 202 {object} TopCarChangeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /v1/topcar/{id} [put]
+func (h *Handler) UpdateTopCar(c *gin.Context) {
+	var req UpdateTopCarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	topCar, userID, ok := h.getOwnedTopCar(c, false)
+	if !ok {
+		return
+	}
+	if h.hasPendingChange(topCar) {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error: "Another change of this top car is waiting for payment",
+		})
+		return
+	}
+
+	now := time.Now()
+	startsAt := topCar.StartsAt
+	if startsAt.IsZero() {
+		startsAt = topCar.CreatedAt // starts_at qo'shilishidan oldingi yozuvlar
+	}
+	if req.StartsAt != nil && !req.StartsAt.Equal(startsAt) {
+		if !startsAt.After(now) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "starts_at can not be changed after the promotion has started",
+			})
+			return
+		}
+		if !req.StartsAt.After(now) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "starts_at must be in the future",
+			})
+			return
+		}
+		startsAt = *req.StartsAt
+	}
+	if startsAt.After(now.AddDate(0, 0, h.Config.TopCar.MAX_SCHEDULE_AHEAD_DAYS)) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("starts_at can not be more than %d days ahead", h.Config.TopCar.MAX_SCHEDULE_AHEAD_DAYS),
+		})
+		return
+	}
+
+	duration, err := h.topCarDuration(req.Category, req.DurationHours)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	finishedAt := startsAt.Add(duration)
+	if !finishedAt.After(now) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Promotion would already be finished with this duration",
+		})
+		return
+	}
+
+	// Qimmatroq o'zgarish narx farqi to'langandan keyin qo'llanadi, arzonrog'i uchun pul qaytarilmaydi
+	if diff := h.topCarPrice(req.Category, duration) - topCar.BaseAmount; diff != 0 {
+		if topCar.Status == model.TopCarStatusPending {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Promotion is waiting for payment, its price can not be changed",
+			})
+			return
+		}
+		if diff > 0 {
+			h.requestTopCarChange(c, topCar, model.TopCarChange{
+				Kind:               model.TopCarChangeReschedule,
+				Category:           req.Category,
+				StartsAt:           startsAt,
+				FinishedAt:         finishedAt,
+				PreviousFinishedAt: topCar.FinishedAt,
+				Amount:             diff,
+				Currency:           topCar.Currency,
+				RequestedBy:        userID,
+			})
+			return
+		}
+	}
+
+	updated, err := h.Cruds.TopCars().RescheduleTopCar(c.Request.Context(), topCar.ID, req.Category, startsAt, finishedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrTopCarOverlap):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Car already has a top promotion overlapping this time window",
+			})
+		case errors.Is(err, repo.ErrTopCarBusy), errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Top car was deleted or changed, please retry",
+			})
+		default:
+			h.Log.Error("Failed to update top car", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update top car",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toTopCarResponse(updated))
+}
+
+// ExtendTopCar godoc
+// @Summary Extend top car
+// @Description Push finished_at of an owned, not deleted and not expired promotion forward by a category duration.
+// @Description Paid extensions open a payment (202) and finished_at moves only after the payment webhook confirms it
+// @Tags TopCars
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Top Car ID"
+// @Param extension body ExtendTopCarRequest true "Extension data"
+// @Success 200 {object} TopCarResponse
+// @Success 202 {object} TopCarChangeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /v1/topcar/{id}/extend [post]
+func (h *Handler) ExtendTopCar(c *gin.Context) {
+	var req ExtendTopCarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	topCar, userID, ok := h.getOwnedTopCar(c, false)
+	if !ok {
+		return
+	}
+
+	if !topCar.FinishedAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Expired promotions can not be extended",
+		})
+		return
+	}
+	if h.hasPendingChange(topCar) {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error: "Another change of this top car is waiting for payment",
+		})
+		return
+	}
+
+	duration, err := h.topCarDuration(req.Category, req.DurationHours)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	finishedAt := topCar.FinishedAt.Add(duration)
+
+	// Uzaytirish alohida to'lanadi, muddat to'lov tasdiqlangandan keyin suriladi
+	if price := h.topCarPrice(req.Category, duration); price > 0 {
+		h.requestTopCarChange(c, topCar, model.TopCarChange{
+			Kind:               model.TopCarChangeExtend,
+			Category:           req.Category,
+			StartsAt:           topCar.StartsAt,
+			FinishedAt:         finishedAt,
+			PreviousFinishedAt: topCar.FinishedAt,
+			Amount:             price,
+			Currency:           topCar.Currency,
+			RequestedBy:        userID,
+		})
+		return
+	}
+
+	// Uzaytirilgan qism keyingi rejalashtirilgan promotion bilan ustma-ust tushmasligi kerak
+	extended, err := h.Cruds.TopCars().ExtendTopCar(c.Request.Context(), topCar.ID, model.TopCarExtension{
+		Category:           req.Category,
+		PreviousFinishedAt: topCar.FinishedAt,
+		FinishedAt:         finishedAt,
+		ExtendedBy:         userID,
+		ExtendedAt:         time.Now(),
 	})
+	if err != nil {
+		if errors.Is(err, repo.ErrTopCarOverlap) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Extension overlaps with another promotion of this car",
+			})
+			return
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, repo.ErrTopCarBusy) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Top car was deleted or changed, please retry",
+			})
+			return
+		}
+		h.Log.Error("Failed to extend top car", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to extend top car",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toTopCarResponse(extended))
+}
+
+// RestoreTopCar godoc
+// @Summary Restore deleted top car
+// @Description Undo the owner's own delete within the restore grace period. Promotions retired by the system or already finished can not be restored
+// @Tags TopCars
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Top Car ID"
+// @Success 200 {object} TopCarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/topcar/{id}/restore [post]
+func (h *Handler) RestoreTopCar(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid ID format",
+		})
+		return
+	}
+
+	token := c.GetHeader("Authorization")
+	userID, _, err := auth.GetUserIdFromToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Invalid token",
+		})
+		return
+	}
+
+	topCar, err := h.Cruds.TopCars().GetDeletedByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deleted top car not found",
+			})
+			return
+		}
+		h.Log.Error("Failed to get deleted top car", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get top car",
+		})
+		return
+	}
+
+	if topCar.UserId != userID {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "User does not own the top car",
+		})
+		return
+	}
+
+	resbool, err := h.Crud.CheckCarOwnership(c, &cruds.BoolCheckCar{UserId: userID, CarId: topCar.CarId})
+	if err != nil {
+		h.Log.Error("Error checking car ownership", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Error checking car ownership",
+		})
+		return
+	}
+	if !resbool.Result {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "User does not own the car",
+		})
+		return
+	}
+
+	// Car o'chirilgani yoki sotilgani uchun avtomatik o'chirilganlarni faqat admin tiklay oladi
+	if topCar.DeleteReason != "" {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Promotion was retired by the system and can only be restored by an admin",
+		})
+		return
+	}
+
+	grace := time.Duration(h.Config.TopCar.RESTORE_GRACE_HOURS) * time.Hour
+	if topCar.DeletedAt == nil || time.Since(*topCar.DeletedAt) > grace {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: fmt.Sprintf("Promotions can only be restored within %d hours after deletion", h.Config.TopCar.RESTORE_GRACE_HOURS),
+		})
+		return
+	}
+
+	if !topCar.FinishedAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Finished promotions can not be restored",
+		})
+		return
+	}
+
+	restored, err := h.Cruds.TopCars().RestoreTopCar(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deleted top car not found",
+			})
+		case errors.Is(err, repo.ErrTopCarOverlap):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Car already has a top promotion overlapping this time window",
+			})
+		case errors.Is(err, repo.ErrTopCarBusy):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Promotions of this car are being changed, please retry",
+			})
+		default:
+			h.Log.Error("Failed to restore top car", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to restore top car",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toTopCarResponse(restored))
 }
 
 // DeleteTopCarByID godoc
@@ -345,37 +859,456 @@ func (h *Handler) DeleteTopCarsByCarID(c *gin.Context) {
 	})
 }
 
+// getOwnedTopCar - path'dagi ID bo'yicha top carni olish va token egasiga tegishliligini tekshirish.
+// includeDeleted bo'lsa soft delete qilinganlari ham qaytadi (masalan, statistika uchun).
+// Xatolik bo'lsa javob yozilgan bo'ladi va ok=false qaytadi
+func (h *Handler) getOwnedTopCar(c *gin.Context, includeDeleted bool) (topCar *model.TopCars, userID string, ok bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid ID format",
+		})
+		return nil, "", false
+	}
+
+	token := c.GetHeader("Authorization")
+	userID, _, err = auth.GetUserIdFromToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Invalid token",
+		})
+		return nil, "", false
+	}
+
+	topCar, err = h.Cruds.TopCars().GetByID(c.Request.Context(), id)
+	if includeDeleted && errors.Is(err, mongo.ErrNoDocuments) {
+		topCar, err = h.Cruds.TopCars().GetDeletedByID(c.Request.Context(), id)
+	}
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Top car not found",
+			})
+			return nil, "", false
+		}
+		h.Log.Error("Failed to get top car by ID", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get top car",
+		})
+		return nil, "", false
+	}
+
+	if topCar.UserId != userID {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "User does not own the top car",
+		})
+		return nil, "", false
+	}
+
+	// O'chirilgan promotionning cari ham o'chirilgan yoki sotilgan bo'lishi mumkin,
+	// ular uchun promotion egasi ekanligi yetarli
+	if topCar.DeletedAt != nil {
+		return topCar, userID, true
+	}
+
+	resbool, err := h.Crud.CheckCarOwnership(c, &cruds.BoolCheckCar{UserId: userID, CarId: topCar.CarId})
+	if err != nil {
+		h.Log.Error("Error checking car ownership", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Error checking car ownership",
+		})
+		return nil, "", false
+	}
+	if !resbool.Result {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "User does not own the car",
+		})
+		return nil, "", false
+	}
+
+	return topCar, userID, true
+}
+
+func toTopCarResponse(topCar *model.TopCars) TopCarResponse {
+	startsAt := topCar.StartsAt
+	if startsAt.IsZero() {
+		startsAt = topCar.CreatedAt // starts_at qo'shilishidan oldingi yozuvlar
+	}
+	status := topCar.Status
+	if status == "" {
+		status = model.TopCarStatusActive // status qo'shilishidan oldingi bepul yozuvlar
+	}
+	return TopCarResponse{
+		ID:            topCar.ID.Hex(),
+		CarId:         topCar.CarId,
+		UserId:        topCar.UserId,
+		Category:      topCar.Category,
+		CreatedAt:     topCar.CreatedAt,
+		StartsAt:      startsAt,
+		FinishedAt:    topCar.FinishedAt,
+		DeletedAt:     topCar.DeletedAt,
+		DeleteReason:  topCar.DeleteReason,
+		Extensions:    topCar.Extensions,
+		Status:        status,
+		Amount:        topCar.Amount,
+		BaseAmount:    topCar.BaseAmount,
+		Currency:      topCar.Currency,
+		PaidAt:        topCar.PaidAt,
+		PendingChange: topCar.PendingChange,
+	}
+}
+
+// isPublicTopCarListing - hozir active promotionlarning umumiy ro'yxati (impression hisoblanadigan yagona ko'rinish)
+func isPublicTopCarListing(filter model.TopCarsFilter) bool {
+	return filter.UserID == "" && filter.CarID == "" &&
+		!filter.ShowExpired && !filter.ShowScheduled && !filter.ShowDeleted && !filter.ShowUnpaid
+}
+
+// hasPendingChange - promotionda muddati o'tmagan, to'lov kutayotgan o'zgarish bormi
+func (h *Handler) hasPendingChange(topCar *model.TopCars) bool {
+	return topCar.PendingChange != nil && topCar.PendingChange.CreatedAt.After(h.pendingChangeStaleBefore())
+}
+
+// pendingChangeStaleBefore - shundan oldin yaratilgan pending o'zgarishlar yangisi bilan almashtiriladi
+func (h *Handler) pendingChangeStaleBefore() time.Time {
+	return time.Now().Add(-time.Duration(h.Config.Payment.PENDING_TTL_MINUTES) * time.Minute)
+}
+
+// requestTopCarChange - o'zgarish uchun to'lov ochib, uni promotionga pending sifatida yozish.
+// Yangi oraliq PaymentWebhook to'lovni tasdiqlaganda qo'llanadi
+func (h *Handler) requestTopCarChange(c *gin.Context, topCar *model.TopCars, change model.TopCarChange) {
+	ctx := c.Request.Context()
+	if h.Payment == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error: "Paid changes are not available, payments are not configured",
+		})
+		return
+	}
+
+	// Oraliq allaqachon band bo'lsa to'lov ochilmaydi
+	overlapFrom := change.StartsAt
+	if change.Kind == model.TopCarChangeExtend {
+		overlapFrom = change.PreviousFinishedAt
+	}
+	overlaps, err := h.Cruds.TopCars().HasOverlappingTopCar(ctx, topCar.CarId, overlapFrom, change.FinishedAt, topCar.ID)
+	if err != nil {
+		h.Log.Error("Failed to check top car overlap", "error", err, "top_car_id", topCar.ID.Hex())
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to change top car",
+		})
+		return
+	}
+	if overlaps {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error: "Car already has a top promotion overlapping this time window",
+		})
+		return
+	}
+
+	pay, err := h.Payment.CreatePayment(ctx, payment.PaymentRequest{
+		OrderID:     topCar.ID.Hex(),
+		UserID:      change.RequestedBy,
+		Amount:      change.Amount,
+		Currency:    change.Currency,
+		Description: fmt.Sprintf("Top car promotion %s (%s) for car %s", change.Kind, change.Category, topCar.CarId),
+	})
+	if err != nil {
+		h.Log.Error("Failed to create payment for top car change", "error", err, "top_car_id", topCar.ID.Hex())
+		c.JSON(http.StatusBadGateway, ErrorResponse{
+			Error: "Failed to create payment",
+		})
+		return
+	}
+	change.PaymentId = pay.ID
+	change.CreatedAt = time.Now()
+
+	updated, err := h.Cruds.TopCars().SetPendingChange(ctx, topCar.ID, change, h.pendingChangeStaleBefore())
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrTopCarChangePending):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Another change of this top car is waiting for payment",
+			})
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Top car was deleted or changed, please retry",
+			})
+		default:
+			h.Log.Error("Failed to save pending top car change", "error", err, "top_car_id", topCar.ID.Hex())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to change top car",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, TopCarChangeResponse{
+		TopCarResponse: toTopCarResponse(updated),
+		CheckoutURL:    pay.CheckoutURL,
+		Message:        "Change is waiting for payment",
+	})
+}
+
+// topCarDuration - category bo'yicha promotion davomiyligini hisoblash
+func (h *Handler) topCarDuration(category string, durationHours int) (time.Duration, error) {
+	switch category {
+	case "daily":
+		return 24 * time.Hour, nil
+	case "weekly":
+		return 7 * 24 * time.Hour, nil
+	case "monthly":
+		return 30 * 24 * time.Hour, nil
+	case "custom":
+		min, max := h.Config.TopCar.MIN_DURATION_HOURS, h.Config.TopCar.MAX_DURATION_HOURS
+		if durationHours < min || durationHours > max {
+			return 0, fmt.Errorf("duration_hours must be between %d and %d", min, max)
+		}
+		return time.Duration(durationHours) * time.Hour, nil
+	default:
+		return 0, errors.New("Invalid category. Must be daily, weekly, monthly, or custom")
+	}
+}
+
+const (
+	carSummaryWorkers  = 8
+	carSummaryCacheTTL = 2 * time.Minute
+)
+
+// getCarSummaries - carlar ma'lumotini avval Redis cache'dan, qolganini cruds servisidan parallel olish.
+// Natijada nil qiymat car o'chirilganini bildiradi; boshqa xatolikda car natijaga kirmaydi
+func (h *Handler) getCarSummaries(ctx context.Context, carIDs []string) map[string]*model.CarSummary {
+	result, err := h.Cruds.Redis().GetCarSummaries(ctx, carIDs)
+	if err != nil {
+		h.Log.Warn("Failed to get car summaries from cache", "error", err)
+		result = make(map[string]*model.CarSummary)
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, id := range carIDs {
+		if _, ok := result[id]; !ok && !seen[id] {
+			seen[id] = true
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return result
+	}
+
+	// Listing rasmi - egasi tanlagan cover yoki tartibdagi birinchi rasm
+	orders, err := h.Cruds.PhotoOrders().GetByCarIDs(ctx, missing)
+	if err != nil {
+		h.Log.Warn("Failed to get photo orders for top car listing", "error", err)
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		fetched []*model.CarSummary
+		jobs    = make(chan string)
+	)
+	for i := 0; i < carSummaryWorkers && i < len(missing); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				car, err := h.Crud.GetCarById(ctx, &cruds.Id{Id: id})
+				if err != nil {
+					mu.Lock()
+					if status.Code(err) == codes.NotFound {
+						result[id] = nil
+					} else {
+						h.Log.Warn("Failed to get car for top car listing", "error", err, "car_id", id)
+					}
+					mu.Unlock()
+					continue
+				}
+
+				summary := &model.CarSummary{
+					Id:        id,
+					Make:      car.Make,
+					Model:     car.Model,
+					Year:      car.Year,
+					Price:     car.Price,
+					Location:  car.Location,
+					Available: car.Available,
+				}
+				if images := orderCarImages(car.Images, orders[id]); len(images) > 0 {
+					summary.Image = images[0].Filename
+				}
+
+				mu.Lock()
+				result[id] = summary
+				fetched = append(fetched, summary)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range missing {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := h.Cruds.Redis().SetCarSummaries(ctx, fetched, carSummaryCacheTTL); err != nil {
+		h.Log.Warn("Failed to cache car summaries", "error", err)
+	}
+
+	return result
+}
+
+// activateTopCars - boshlanish vaqti kelgan paid promotionlarni bo'sh slotlarga joylash
+func (h *Handler) activateTopCars(ctx context.Context) {
+	activated, err := h.Cruds.TopCars().ActivateStartedTopCars(ctx, h.Config.TopCar.SlotsByCategory())
+	if err != nil {
+		h.Log.Error("Failed to activate paid top cars", "error", err)
+		return
+	}
+	if activated > 0 {
+		h.Log.Info("Activated paid top cars", "activated_count", activated)
+	}
+}
+
+// topCarPrice - config'dagi narxlar jadvali bo'yicha promotion narxi (minor birliklarda).
+// Custom promotionning to'liq bo'lmagan soati yuqoriga yaxlitlanadi
+func (h *Handler) topCarPrice(category string, duration time.Duration) int64 {
+	switch category {
+	case "daily":
+		return h.Config.TopCar.PRICE_DAILY
+	case "weekly":
+		return h.Config.TopCar.PRICE_WEEKLY
+	case "monthly":
+		return h.Config.TopCar.PRICE_MONTHLY
+	default:
+		minutes := int64((duration + time.Minute - 1) / time.Minute)
+		return (h.Config.TopCar.PRICE_CUSTOM_PER_HOUR*minutes + 59) / 60
+	}
+}
+
+// RetireTopCarsByCarID godoc
+// @Summary Retire top cars of a car (internal)
+// @Description Called by the cruds service when a car is deleted or marked unavailable. Soft-deletes all promotions of the car with the given reason
+// @Tags Internal
+// @Accept json
+// @Produce json
+// @Param X-Internal-Key header string true "Internal API key"
+// @Param car_id path string true "Car ID"
+// @Param body body RetireTopCarsRequest true "Retire reason"
+// @Success 200 {object} DeleteResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /v1/internal/topcar/car/{car_id}/retire [post]
+func (h *Handler) RetireTopCarsByCarID(c *gin.Context) {
+	var req RetireTopCarsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if req.Reason != model.TopCarDeleteReasonCarDeleted && req.Reason != model.TopCarDeleteReasonCarUnavailable {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid reason. Must be car_deleted or car_unavailable",
+		})
+		return
+	}
+
+	carID := c.Param("car_id")
+	deletedCount, err := h.Cruds.TopCars().RetireByCarID(c.Request.Context(), carID, req.Reason)
+	if err != nil {
+		h.Log.Error("Failed to retire top cars by car ID", "error", err, "car_id", carID)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to retire top cars",
+		})
+		return
+	}
+
+	if err := h.Cruds.Redis().DeleteCarSummary(c.Request.Context(), carID); err != nil {
+		h.Log.Warn("Failed to invalidate car summary cache", "error", err, "car_id", carID)
+	}
+
+	h.Log.Info("Top cars retired", "car_id", carID, "reason", req.Reason, "deleted_count", deletedCount)
+	c.JSON(http.StatusOK, DeleteResponse{
+		Message:      "Top cars retired successfully",
+		DeletedCount: deletedCount,
+	})
+}
+
 // Request/Response structures
 type CreateTopCarRequest struct {
-	CarId    string `json:"car_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Category string `json:"category" binding:"required" example:"daily,weekly,monthly"`
+	CarId         string     `json:"car_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Category      string     `json:"category" binding:"required" example:"daily,weekly,monthly,custom"`
+	StartsAt      *time.Time `json:"starts_at,omitempty" example:"2024-01-01T12:00:00Z"`
+	DurationHours int        `json:"duration_hours,omitempty" example:"72"` // faqat custom category uchun
 }
 
 type CreateTopCarResponse struct {
-	ID         string    `json:"id" example:"507f1f77bcf86cd799439011"`
-	CarId      string    `json:"car_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	UserId     string    `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174001"`
-	Category   string    `json:"category" example:"daily"`
-	CreatedAt  time.Time `json:"created_at" example:"2024-01-01T12:00:00Z"`
-	FinishedAt time.Time `json:"finished_at" example:"2024-01-02T12:00:00Z"`
-	Message    string    `json:"message" example:"Top car created successfully"`
+	ID          string    `json:"id" example:"507f1f77bcf86cd799439011"`
+	CarId       string    `json:"car_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserId      string    `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174001"`
+	Category    string    `json:"category" example:"daily"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-01-01T12:00:00Z"`
+	StartsAt    time.Time `json:"starts_at" example:"2024-01-01T12:00:00Z"`
+	FinishedAt  time.Time `json:"finished_at" example:"2024-01-02T12:00:00Z"`
+	Status      string    `json:"status" example:"pending"`
+	Amount      int64     `json:"amount_minor" example:"500"` // minor birliklarda (cent, tiyin)
+	Currency    string    `json:"currency" example:"USD"`
+	PaymentId   string    `json:"payment_id,omitempty" example:"3f6c2a7e-1b2d-4c5e-8f9a-0b1c2d3e4f5a"`
+	CheckoutURL string    `json:"checkout_url,omitempty" example:"https://pay.example.com/checkout/3f6c2a7e"`
+	Waitlisted  bool      `json:"waitlisted" example:"false"`
+	Message     string    `json:"message" example:"Top car created successfully"`
 }
 
 type TopCarResponse struct {
-	ID         string     `json:"id" example:"507f1f77bcf86cd799439011"`
-	CarId      string     `json:"car_id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	UserId     string     `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174001"`
-	Category   string     `json:"category" example:"daily"`
-	CreatedAt  time.Time  `json:"created_at" example:"2024-01-01T12:00:00Z"`
-	FinishedAt time.Time  `json:"finished_at" example:"2024-01-02T12:00:00Z"`
-	DeletedAt  *time.Time `json:"deleted_at,omitempty" example:"2024-01-03T12:00:00Z"`
+	ID            string                  `json:"id" example:"507f1f77bcf86cd799439011"`
+	CarId         string                  `json:"car_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserId        string                  `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174001"`
+	Category      string                  `json:"category" example:"daily"`
+	CreatedAt     time.Time               `json:"created_at" example:"2024-01-01T12:00:00Z"`
+	StartsAt      time.Time               `json:"starts_at" example:"2024-01-01T12:00:00Z"`
+	FinishedAt    time.Time               `json:"finished_at" example:"2024-01-02T12:00:00Z"`
+	DeletedAt     *time.Time              `json:"deleted_at,omitempty" example:"2024-01-03T12:00:00Z"`
+	DeleteReason  string                  `json:"delete_reason,omitempty" example:"car_deleted"`
+	Extensions    []model.TopCarExtension `json:"extensions,omitempty"`
+	Status        string                  `json:"status" example:"active"`
+	Amount        int64                   `json:"amount_minor" example:"500"`      // jami to'langan, minor birliklarda (cent, tiyin)
+	BaseAmount    int64                   `json:"base_amount_minor" example:"500"` // uzaytirishlarsiz asosiy oraliq narxi
+	Currency      string                  `json:"currency" example:"USD"`
+	PaidAt        *time.Time              `json:"paid_at,omitempty" example:"2024-01-01T12:00:00Z"`
+	PendingChange *model.TopCarChange     `json:"pending_change,omitempty"`
+	Car           *model.CarSummary       `json:"car,omitempty"`
+}
+
+// TopCarChangeResponse - narx farqi to'lanishi kerak bo'lgan o'zgarish; promotion to'lov
+// tasdiqlanmaguncha eski oralig'ida qoladi
+type TopCarChangeResponse struct {
+	TopCarResponse
+	CheckoutURL string `json:"checkout_url,omitempty" example:"https://pay.example.com/checkout/3f6c2a7e"`
+	Message     string `json:"message" example:"Change is waiting for payment"`
+}
+
+type UpdateTopCarRequest struct {
+	Category      string     `json:"category" binding:"required" example:"weekly"`
+	StartsAt      *time.Time `json:"starts_at,omitempty" example:"2024-01-01T12:00:00Z"`
+	DurationHours int        `json:"duration_hours,omitempty" example:"72"` // faqat custom category uchun
+}
+
+type ExtendTopCarRequest struct {
+	Category      string `json:"category" binding:"required" example:"weekly"`
+	DurationHours int    `json:"duration_hours,omitempty" example:"72"` // faqat custom category uchun
+}
+
+type RetireTopCarsRequest struct {
+	Reason string `json:"reason" binding:"required" example:"car_deleted"` // car_deleted, car_unavailable
 }
 
 type GetTopCarsResponse struct {
-	TopCars []TopCarResponse `json:"top_cars"`
-	Total   int64            `json:"total" example:"100"`
-	Limit   int64            `json:"limit" example:"50"`
-	Skip    int64            `json:"skip" example:"0"`
+	TopCars    []TopCarResponse `json:"top_cars"`
+	Total      int64            `json:"total" example:"100"`
+	Limit      int64            `json:"limit" example:"50"`
+	Skip       int64            `json:"skip" example:"0"`
+	NextCursor string           `json:"next_cursor,omitempty" example:"eyJmIjoiZmluaXNoZWRfYXQiLCJkIjoxfQ"`
 }
 
 type DeleteResponse struct {
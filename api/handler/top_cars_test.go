@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wegugin/config"
+	"wegugin/model"
+	"wegugin/payment"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTopCarTest(cars ...*model.TopCars) (*gin.Engine, *fakeStorage) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.TopCar.PRICE_DAILY = 500
+	cfg.TopCar.PRICE_WEEKLY = 1000
+	cfg.TopCar.MAX_SCHEDULE_AHEAD_DAYS = 30
+	cfg.Payment.PENDING_TTL_MINUTES = 30
+	st := &fakeStorage{topCars: &fakeTopCars{cars: cars}}
+	h := &Handler{
+		Cruds:   st,
+		Crud:    &fakeCruds{},
+		Log:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config:  cfg,
+		Payment: payment.NewLocalProvider(testWebhookSecret),
+	}
+
+	r := gin.New()
+	r.POST("/v1/topcar", h.CreateTopCar)
+	r.PUT("/v1/topcar/:id", h.UpdateTopCar)
+	r.POST("/v1/topcar/:id/click", h.RecordTopCarClick)
+	return r, st
+}
+
+func sendTopCarRequest(t *testing.T, r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", testToken(t, "user-1"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateTopCarPricesAgainstBaseWindow(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	// Kunlik promotion (500) 600 ga uzaytirilgan: jami 1100 to'langan
+	topCar := &model.TopCars{
+		ID:         primitive.NewObjectID(),
+		CarId:      "car-1",
+		UserId:     "user-1",
+		Category:   "daily",
+		StartsAt:   now.Add(time.Hour),
+		FinishedAt: now.Add(49 * time.Hour),
+		Status:     model.TopCarStatusPaid,
+		Amount:     1100,
+		BaseAmount: 500,
+		Currency:   "USD",
+		PaidAt:     &now,
+	}
+	r, st := newTopCarTest(topCar)
+
+	// Haftalikka o'tish uzaytirish to'lovi hisobiga bepul bo'lmaydi
+	w := sendTopCarRequest(t, r, http.MethodPut, "/v1/topcar/"+topCar.ID.Hex(), `{"category":"weekly"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status code = %d, want %d (%s)", w.Code, http.StatusAccepted, w.Body.String())
+	}
+	var res TopCarChangeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	change := st.topCars.cars[0].PendingChange
+	if change == nil || change.Amount != 500 || res.CheckoutURL == "" {
+		t.Fatalf("pending change = %+v, want a payment of the 500 difference", change)
+	}
+	if topCar.Category != "daily" {
+		t.Fatalf("category = %s, want the change applied only after payment", topCar.Category)
+	}
+}
+
+func TestCreateTopCarRejectsPastStartsAt(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		startsAt time.Time
+		want     int
+		wantNow  bool // starts_at hozirgi vaqtga tenglanadi
+	}{
+		{"past", now.Add(-time.Hour), http.StatusBadRequest, false},
+		{"within clock skew", now.Add(-2 * time.Minute), http.StatusCreated, true},
+		{"future", now.Add(time.Hour).Truncate(time.Second), http.StatusCreated, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, st := newTopCarTest()
+			body := fmt.Sprintf(`{"car_id":"car-1","category":"daily","starts_at":%q}`, tt.startsAt.Format(time.RFC3339Nano))
+			w := sendTopCarRequest(t, r, http.MethodPost, "/v1/topcar", body)
+			if w.Code != tt.want {
+				t.Fatalf("status code = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want != http.StatusCreated {
+				if len(st.topCars.cars) != 0 {
+					t.Fatal("promotion was created")
+				}
+				return
+			}
+
+			got := st.topCars.cars[0].StartsAt
+			if tt.wantNow && got.Before(now) {
+				t.Fatalf("starts_at = %v, want clamped to now", got)
+			}
+			if !tt.wantNow && !got.Equal(tt.startsAt) {
+				t.Fatalf("starts_at = %v, want %v", got, tt.startsAt)
+			}
+		})
+	}
+}
+
+func TestRecordTopCarClickCountsOnlyRunningPromotions(t *testing.T) {
+	now := time.Now()
+	topCar := func(status string, startsAt time.Time) *model.TopCars {
+		return &model.TopCars{
+			ID:         primitive.NewObjectID(),
+			CarId:      "car-1",
+			UserId:     "user-1",
+			Category:   "daily",
+			StartsAt:   startsAt,
+			FinishedAt: startsAt.Add(24 * time.Hour),
+			Status:     status,
+		}
+	}
+	tests := []struct {
+		name   string
+		topCar *model.TopCars
+		want   int
+	}{
+		{"active", topCar(model.TopCarStatusActive, now.Add(-time.Hour)), http.StatusOK},
+		{"legacy without status", topCar("", now.Add(-time.Hour)), http.StatusOK},
+		{"pending payment", topCar(model.TopCarStatusPending, now.Add(-time.Hour)), http.StatusNotFound},
+		{"paid waiting for a slot", topCar(model.TopCarStatusPaid, now.Add(-time.Hour)), http.StatusNotFound},
+		{"cancelled", topCar(model.TopCarStatusCancelled, now.Add(-time.Hour)), http.StatusNotFound},
+		{"expired", topCar(model.TopCarStatusActive, now.Add(-25*time.Hour)), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, st := newTopCarTest(tt.topCar)
+			st.redis = &fakeRedis{}
+
+			w := sendTopCarRequest(t, r, http.MethodPost, "/v1/topcar/"+tt.topCar.ID.Hex()+"/click", "")
+			if w.Code != tt.want {
+				t.Fatalf("status code = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+			if counted := st.redis.clicks == 1; counted != (tt.want == http.StatusOK) {
+				t.Fatalf("clicks = %d", st.redis.clicks)
+			}
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"strings"
+	"sync"
 	"wegugin/api/auth"
 	pb "wegugin/genproto/cruds"
+	"wegugin/storage/repo"
+	"wegugin/upload"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc/codes"
@@ -15,15 +22,23 @@ import (
 
 // @Summary CreatePhoto
 // @Security ApiKeyAuth
-// @Description Upload Car Photo
+// @Description Upload Car Photos. Send one photo in "file" or several photos in "files" (repeat the field).
+// @Description A single file keeps the old response, several files return per-file results (207 when only some of them failed).
+// @Description Identical files are stored once: "duplicate" is true when the same content was already uploaded
 // @Tags IMAGES
 // @Param car_id path string true "car_id"
 // @Accept multipart/form-data
-// @Param file formData file true "UploadMediaForm"
-// @Success 200 {object} string
+// @Param file formData file false "UploadMediaForm"
+// @Param files formData []file false "Multiple photos" collectionFormat(multi)
+// @Success 200 {object} UploadPhotosResponse
+// @Success 207 {object} UploadPhotosResponse
 // @Failure 400 {object} string
 // @Failure 401 {object} string
+// @Failure 413 {object} string "File is larger than the allowed size"
+// @Failure 415 {object} string "File content is not an allowed image format"
+// @Failure 422 {object} string "Corrupt image or dimensions too large"
 // @Failure 500 {object} string
+// @Failure 503 {object} string "Same photo is being deleted, retry"
 // @Router /v1/car/photo/{car_id} [post]
 func (h *Handler) CreatePhoto(c *gin.Context) {
 	h.Log.Info("Create image called")
@@ -51,38 +66,237 @@ func (h *Handler) CreatePhoto(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User does not own the car"})
 		return
 	}
-	file, header, err := c.Request.FormFile("file")
+
+	form, err := c.MultipartForm()
 	if err != nil {
 		h.Log.Error("Error retrieving the file", "error", err)
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Error retrieving the file"})
 		return
 	}
+	headers := append(form.File["file"], form.File["files"]...)
+	if len(headers) == 0 {
+		h.Log.Error("No files in request")
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Error retrieving the file"})
+		return
+	}
+	if max := h.Config.Photo.MAX_FILES_PER_REQUEST; max > 0 && len(headers) > max {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Too many files, at most %d photos per request", max)})
+		return
+	}
+
+	// Bitta fayl bo'lsa eski javob formati saqlanadi
+	if len(headers) == 1 {
+		result := h.uploadCarPhoto(c.Request.Context(), Id, headers[0])
+		if result.Error != "" {
+			c.AbortWithStatusJSON(result.Status, gin.H{"error": result.Error})
+			return
+		}
+		h.Log.Info("Photo uploaded successfully")
+		c.JSON(http.StatusOK, gin.H{"photo_id": result.PhotoId, "url1": result.URL, "url2": result.storedURL, "duplicate": result.Duplicate})
+		return
+	}
+
+	results := h.uploadCarPhotos(c.Request.Context(), Id, headers)
+
+	response := UploadPhotosResponse{Results: results}
+	clientErrors := 0
+	for _, result := range results {
+		if result.Error == "" {
+			response.Uploaded++
+		} else {
+			response.Failed++
+			if result.Status < http.StatusInternalServerError {
+				clientErrors++
+			}
+		}
+	}
+	h.Log.Info("Photos uploaded", "car_id", Id, "uploaded", response.Uploaded, "failed", response.Failed)
+
+	switch {
+	case response.Failed == 0:
+		c.JSON(http.StatusOK, response)
+	case response.Uploaded == 0 && clientErrors == response.Failed:
+		c.JSON(http.StatusBadRequest, response)
+	case response.Uploaded == 0:
+		c.JSON(http.StatusInternalServerError, response)
+	default:
+		c.JSON(http.StatusMultiStatus, response)
+	}
+}
+
+// uploadCarPhotos - fayllarni cheklangan worker pool orqali parallel yuklash.
+// Natijalar so'rovdagi fayllar tartibida qaytadi
+func (h *Handler) uploadCarPhotos(ctx context.Context, carID string, headers []*multipart.FileHeader) []PhotoUploadResult {
+	workers := h.Config.Photo.UPLOAD_WORKERS
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(headers) {
+		workers = len(headers)
+	}
+
+	results := make([]PhotoUploadResult, len(headers))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = h.uploadCarPhoto(ctx, carID, headers[i])
+			}
+		}()
+	}
+	for i := range headers {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// storePhoto - tekshirilgan rasmni variantlari bilan photos bucketga yozish.
+// Shu mazmundagi rasm avval yuklangan bo'lsa mavjud obyektga havola qo'shiladi (Duplicate)
+func (h *Handler) storePhoto(ctx context.Context, data []byte, info *upload.ImageInfo) (*upload.StoredImage, error) {
+	return h.Images.PutImage(ctx, "photos", data, info)
+}
+
+// deleteStoredPhoto - cruds'ga qo'shilmay qolgan rasmni storage'dan o'chirish (egasiz fayl qolmasligi uchun)
+func (h *Handler) deleteStoredPhoto(url string) {
+	if err := h.Images.DeleteImageByURL(context.Background(), url); err != nil {
+		h.Log.Warn("Failed to delete orphaned photo from storage", "url", url, "error", err)
+	}
+}
+
+// uploadCarPhoto - bitta faylni storage'ga yuklash va cruds'ga qo'shish.
+// AddImage xato bersa yuklangan obyekt o'chiriladi (storage'da egasiz fayl qolmasligi uchun)
+func (h *Handler) uploadCarPhoto(ctx context.Context, carID string, header *multipart.FileHeader) PhotoUploadResult {
+	result := PhotoUploadResult{Filename: header.Filename}
+
+	file, err := header.Open()
+	if err != nil {
+		h.Log.Error("Error opening the file", "filename", header.Filename, "error", err)
+		result.Status = http.StatusBadRequest
+		result.Error = "Error retrieving the file"
+		return result
+	}
 	defer file.Close()
-	url, err := h.MINIO.UploadFile("photos", file, header)
+
+	info, err := upload.ValidateImage(file, header.Size, h.imageRules())
 	if err != nil {
-		h.Log.Error("Error uploading the file to MinIO", "error", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		h.Log.Warn("Rejected photo upload", "filename", header.Filename, "error", err)
+		result.Status = imageErrorStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		h.Log.Error("Error reading the file", "filename", header.Filename, "error", err)
+		result.Status = http.StatusBadRequest
+		result.Error = "Error retrieving the file"
+		return result
 	}
-	res, err := h.Crud.AddImage(c, &pb.AddImageRequest{
-		CarId:    Id,
-		Filename: url,
+
+	stored, err := h.storePhoto(ctx, data, info)
+	if err != nil {
+		h.Log.Error("Error uploading the file to storage", "filename", header.Filename, "error", err)
+		result.Status = imageErrorStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+
+	res, err := h.Crud.AddImage(ctx, &pb.AddImageRequest{
+		CarId:    carID,
+		Filename: stored.URL,
 	})
 	if err != nil {
-		h.Log.Error("Error creating photo", "error", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating photo"})
-		return
+		h.Log.Error("Error creating photo", "filename", header.Filename, "error", err)
+		h.deleteStoredPhoto(stored.URL)
+		result.Status = http.StatusInternalServerError
+		result.Error = "Error creating photo"
+		return result
+	}
+
+	result.Status = http.StatusOK
+	result.PhotoId = res.Id
+	result.URL = stored.URL
+	result.Duplicate = stored.Duplicate
+	result.storedURL = res.Filename
+	return result
+}
+
+// imageRules - config'dagi rasm cheklovlari
+func (h *Handler) imageRules() upload.ImageRules {
+	cfg := h.Config.Photo
+	rules := upload.ImageRules{
+		MaxBytes:  cfg.MAX_BYTES,
+		MaxWidth:  cfg.MAX_WIDTH,
+		MaxHeight: cfg.MAX_HEIGHT,
 	}
-	h.Log.Info("Photo uploaded successfully")
-	c.JSON(http.StatusOK, gin.H{"photo_id": res.Id, "url1": url, "url2": res.Filename})
+	if cfg.ALLOWED_FORMATS != "" {
+		rules.AllowedFormats = strings.Split(cfg.ALLOWED_FORMATS, ",")
+	}
+	return rules
+}
+
+// imageErrorStatus - rasm tekshiruvi xatosiga mos HTTP status
+func imageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, upload.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, upload.ErrImageFormatNotAllowed):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, upload.ErrImageCorrupt), errors.Is(err, upload.ErrImageDimensions):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, repo.ErrPhotoObjectBusy):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type CarImageResponse struct {
+	Id         string            `json:"id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Filename   string            `json:"filename,omitempty" example:"http://localhost:9000/photos/123e4567_original.jpg"`
+	CarId      string            `json:"car_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174001"`
+	UploadedAt string            `json:"uploaded_at,omitempty" example:"2024-01-01T12:00:00Z"`
+	Position   int               `json:"position" example:"0"`
+	IsCover    bool              `json:"is_cover" example:"true"`
+	Variants   map[string]string `json:"variants"`
+}
+
+// UploadedPhotoResponse - yuklangan rasm, duplicate bo'lsa shu mazmundagi mavjud obyekt ishlatilgan
+type UploadedPhotoResponse struct {
+	CarImageResponse
+	Duplicate bool `json:"duplicate" example:"false"`
+}
+
+type PhotoUploadResult struct {
+	Filename string `json:"filename" example:"front.jpg"`
+	Status   int    `json:"status" example:"200"`
+	PhotoId  string `json:"photo_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	URL      string `json:"url,omitempty" example:"http://localhost:9000/photos/123e4567.jpg"`
+	Error    string `json:"error,omitempty" example:"Error creating photo"`
+	// Duplicate - xuddi shu fayl avval yuklangan, storage'dagi mavjud obyekt ishlatildi
+	Duplicate bool `json:"duplicate" example:"false"`
+
+	storedURL string // cruds'da saqlangan URL (eski javob formati uchun)
+}
+
+type UploadPhotosResponse struct {
+	Results  []PhotoUploadResult `json:"results"`
+	Uploaded int                 `json:"uploaded" example:"19"`
+	Failed   int                 `json:"failed" example:"1"`
 }
 
 // GetImagesByCar godoc
 // @Summary Get Car Photos
-// @Description it will Get Car Photos
+// @Description it will Get Car Photos in display order, cover first. variants holds URLs of the resized copies (thumb, medium, large) when they exist
 // @Tags IMAGES
 // @Param car_id path string true "car_id"
-// @Success 200 {object} []cruds.Image
+// @Success 200 {object} []CarImageResponse
 // @Failure 400 {object} string "Invalid data"
 // @Failure 500 {object} string "Server error"
 // @Router /v1/car/photo/{car_id} [get]
@@ -100,8 +314,14 @@ func (h *Handler) GetImagesByCar(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting images by car"})
 		return
 	}
+	order, err := h.Cruds.PhotoOrders().GetByCarID(c.Request.Context(), id)
+	if err != nil {
+		// Tartib olinmasa ham rasmlar cruds qaytargan ketma-ketlikda beriladi
+		h.Log.Warn("Error getting photo order", "car_id", id, "error", err)
+	}
+	images := h.carImageResponses(orderCarImages(res.Images, order), order)
 	h.Log.Info("Get images by car successfully")
-	c.JSON(http.StatusOK, res.Images)
+	c.JSON(http.StatusOK, images)
 }
 
 // @Summary DeleteImage
@@ -170,26 +390,7 @@ func (h *Handler) DeleteImage(c *gin.Context) {
 		return
 	}
 
-	// MinIO'dan faylni o'chirish
-	if imageinfo.Filename != "" {
-		// URL'dan bucket va fayl nomini ajratib olish
-		bucketName, fileName, err := h.parseBucketAndFileFromURL(imageinfo.Filename)
-		if err != nil {
-			h.Log.Error("Failed to parse URL", "error", err, "url", imageinfo.Filename)
-		} else {
-			h.Log.Info("Attempting to delete from MinIO", "bucket", bucketName, "filename", fileName, "url", imageinfo.Filename)
-
-			err = h.MINIO.DeleteFile(bucketName, fileName)
-			if err != nil {
-				h.Log.Warn("Failed to delete image from MinIO", "error", err, "bucket", bucketName, "filename", fileName)
-				// MinIO'dan o'chirishda xato bo'lsa ham davom etamiz
-			} else {
-				h.Log.Info("Image deleted from MinIO successfully", "bucket", bucketName, "filename", fileName)
-			}
-		}
-	}
-
-	// Database'dan o'chirish
+	// Avval database'dan o'chiramiz: xato bo'lsa qayta urinishda refcount ikki marta kamaymaydi
 	_, err = h.Crud.DeleteImage(ctx, &pb.ImageId{Id: id})
 	if err != nil {
 		h.Log.Error("Error deleting image from database", "error", err)
@@ -197,6 +398,20 @@ func (h *Handler) DeleteImage(c *gin.Context) {
 		return
 	}
 
+	// MinIO'dan faylni o'chirish
+	if imageinfo.Filename != "" {
+		// MinIO'dan o'chirishda xato bo'lsa ham davom etamiz
+		if err := h.Images.DeleteImageByURL(ctx, imageinfo.Filename); err != nil {
+			h.Log.Warn("Failed to delete image from MinIO", "error", err, "url", imageinfo.Filename)
+		}
+	}
+
+	// Tartib va cover'dan chiqarish
+	if err := h.Cruds.PhotoOrders().RemoveImage(ctx, imageinfo.CarId, id); err != nil {
+		h.Log.Warn("Failed to remove image from photo order", "error", err, "car_id", imageinfo.CarId)
+	}
+	h.invalidateCarSummary(c, imageinfo.CarId)
+
 	h.Log.Info("Image deleted successfully", "id", id)
 	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
 }
@@ -267,85 +482,34 @@ func (h *Handler) DeleteImagesByCarId(c *gin.Context) {
 		return
 	}
 
+	// Avval database'dan o'chiramiz: xato bo'lsa qayta urinishda refcountlar ikki marta kamaymaydi
+	_, err = h.Crud.DeleteImagesByCarId(ctx, &pb.CarId{CarId: carId})
+	if err != nil {
+		h.Log.Error("Error deleting images by car id from database", "error", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error deleting images by car id"})
+		return
+	}
+
 	// MinIO'dan barcha rasmlarni o'chirish
 	if len(car.Images) > 0 {
 		deletedCount := 0
 		for _, image := range car.Images {
 			if image.Filename != "" {
-				// URL'dan bucket va fayl nomini ajratib olish
-				bucketName, fileName, err := h.parseBucketAndFileFromURL(image.Filename)
-				if err != nil {
-					h.Log.Warn("Failed to parse URL", "error", err, "url", image.Filename)
-					continue
-				}
-
-				h.Log.Info("Attempting to delete from MinIO", "bucket", bucketName, "filename", fileName)
-
-				err = h.MINIO.DeleteFile(bucketName, fileName)
-				if err != nil {
-					h.Log.Warn("Failed to delete image from MinIO", "error", err, "bucket", bucketName, "filename", fileName)
+				if err := h.Images.DeleteImageByURL(ctx, image.Filename); err != nil {
+					h.Log.Warn("Failed to delete image from MinIO", "error", err, "url", image.Filename)
 				} else {
 					deletedCount++
-					h.Log.Info("Image deleted from MinIO", "bucket", bucketName, "filename", fileName)
 				}
 			}
 		}
 		h.Log.Info("MinIO deletion completed", "total", len(car.Images), "deleted", deletedCount)
 	}
 
-	// Database'dan barcha rasmlarni o'chirish
-	_, err = h.Crud.DeleteImagesByCarId(ctx, &pb.CarId{CarId: carId})
-	if err != nil {
-		h.Log.Error("Error deleting images by car id from database", "error", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error deleting images by car id"})
-		return
+	if err := h.Cruds.PhotoOrders().DeleteByCarID(ctx, carId); err != nil {
+		h.Log.Warn("Failed to delete photo order", "error", err, "car_id", carId)
 	}
+	h.invalidateCarSummary(c, carId)
 
 	h.Log.Info("Images deleted successfully by car id", "car_id", carId)
 	c.JSON(http.StatusOK, gin.H{"message": "Images deleted successfully by car id"})
 }
-
-// URL'dan bucket va fayl nomini ajratib olish
-func (h *Handler) parseBucketAndFileFromURL(url string) (bucket, filename string, err error) {
-	// URL format: https://media.turbocarsautoexport.com/photos/filename.jpg
-	// yoki: http://localhost:9000/bucket/filename.jpg
-
-	h.Log.Info("Parsing URL", "url", url)
-
-	// "://" dan keyingi qismni olish
-	protocolIndex := strings.Index(url, "://")
-	if protocolIndex == -1 {
-		return "", "", fmt.Errorf("invalid URL format: no protocol found")
-	}
-
-	// Protocol'dan keyingi qismni olish
-	afterProtocol := url[protocolIndex+3:]
-	h.Log.Info("After protocol", "part", afterProtocol)
-
-	// Birinchi "/" dan keyingi qismni olish (path qismi)
-	firstSlashIndex := strings.Index(afterProtocol, "/")
-	if firstSlashIndex == -1 {
-		return "", "", fmt.Errorf("invalid URL format: no path found")
-	}
-
-	pathPart := afterProtocol[firstSlashIndex+1:]
-	h.Log.Info("Path part", "path", pathPart)
-
-	// Path'ni "/" bo'yicha bo'lish
-	pathParts := strings.Split(pathPart, "/")
-	if len(pathParts) < 2 {
-		return "", "", fmt.Errorf("invalid URL format: insufficient path parts")
-	}
-
-	// Birinchi qism bucket, oxirgi qism filename
-	bucket = pathParts[0]
-	filename = pathParts[len(pathParts)-1]
-
-	h.Log.Info("Parsed URL", "bucket", bucket, "filename", filename)
-
-	if bucket == "" || filename == "" {
-		return "", "", fmt.Errorf("invalid URL format: empty bucket or filename")
-	}
-
-	return bucket, filename, nil
-}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"wegugin/config"
+	"wegugin/genproto/cruds"
+	"wegugin/upload"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countingRefs - kalitlar bo'yicha havolalar soni (upload.RefCounter)
+type countingRefs struct {
+	mu   sync.Mutex
+	refs map[string]int
+}
+
+func (r *countingRefs) Acquire(ctx context.Context, bucket, key, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refs[bucket+"/"+key]++
+	return r.refs[bucket+"/"+key] == 1, nil
+}
+
+func (r *countingRefs) Release(ctx context.Context, bucket, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refs[bucket+"/"+key]--
+	return r.refs[bucket+"/"+key] <= 0, nil
+}
+
+func (r *countingRefs) Forget(ctx context.Context, bucket, key string) error { return nil }
+
+func (r *countingRefs) Complete(ctx context.Context, bucket, key string) error { return nil }
+
+func (r *countingRefs) Abandon(ctx context.Context, bucket, key string) error { return nil }
+
+func (r *countingRefs) AwaitComplete(ctx context.Context, bucket, key string) (bool, error) {
+	return false, nil
+}
+
+func TestDeleteImageReleasesOnlyAfterDatabaseDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := upload.NewMemoryStore("http://media.test")
+	const key = "shared_original.jpg"
+	if err := store.Put(context.Background(), "photos", key, strings.NewReader("x"), 1, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	// Bir xil rasm ikki carga yuklangan
+	refs := &countingRefs{refs: map[string]int{"photos/" + key: 2}}
+	crud := &fakeCruds{images: map[string]*cruds.Image{
+		"img-1": {Id: "img-1", CarId: "car-1", Filename: store.URL("photos", key)},
+	}}
+	h := &Handler{
+		Cruds:  &fakeStorage{redis: &fakeRedis{}},
+		Crud:   crud,
+		Log:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: &config.Config{},
+		Images: &upload.ImageStore{Store: store, Refs: refs},
+	}
+	r := gin.New()
+	r.DELETE("/v1/car/photo/:id", h.DeleteImage)
+
+	deleteImage := func() int {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/car/photo/img-1", nil)
+		req.Header.Set("Authorization", testToken(t, "user-1"))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	crud.deleteErr = errors.New("cruds is down")
+	if code := deleteImage(); code != http.StatusInternalServerError {
+		t.Fatalf("status code = %d, want %d", code, http.StatusInternalServerError)
+	}
+	if refs.refs["photos/"+key] != 2 {
+		t.Fatalf("refs = %d after a failed delete, want 2", refs.refs["photos/"+key])
+	}
+
+	// Qayta urinish havolani faqat bir marta qaytaradi, boshqa carning rasmi qoladi
+	crud.deleteErr = nil
+	if code := deleteImage(); code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d", code, http.StatusOK)
+	}
+	if refs.refs["photos/"+key] != 1 {
+		t.Fatalf("refs = %d after the retry, want 1", refs.refs["photos/"+key])
+	}
+	if _, err := store.Stat(context.Background(), "photos", key); err != nil {
+		t.Fatalf("shared image was deleted: %v", err)
+	}
+}
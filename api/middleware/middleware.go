@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"errors"
 	"net/http"
 	"wegugin/api/auth"
@@ -33,6 +34,21 @@ func Check(c *gin.Context) {
 	c.Next()
 }
 
+// CheckInternalKey - boshqa servislar chaqiradigan internal endpointlar uchun.
+// Key bo'sh bo'lsa internal endpointlar o'chirilgan hisoblanadi
+func CheckInternalKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader("X-Internal-Key")
+		if key == "" || subtle.ConstantTimeCompare([]byte(got), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid internal key",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
 func (casb *casbinPermission) GetRole(c *gin.Context) (string, int) {
 	token := c.GetHeader("Authorization")
 	if token == "" {
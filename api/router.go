@@ -1,9 +1,13 @@
 package api
 
 import (
+	"net/url"
+	"path/filepath"
+	"strings"
 	_ "wegugin/api/docs"
 	"wegugin/api/handler"
 	"wegugin/api/middleware"
+	"wegugin/upload"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -37,6 +41,15 @@ func Router(hand *handler.Handler) *gin.Engine {
 	car := router.Group("/v1/car/photo")
 	{
 		car.POST("/:car_id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.CreatePhoto)
+		car.POST("/:car_id/upload-url", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.CreatePhotoUploadURL)
+		car.POST("/:car_id/complete", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.CompletePhotoUpload)
+		car.POST("/:car_id/uploads", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.CreateResumableUpload)
+		car.GET("/uploads/:upload_id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.GetResumableUpload)
+		car.PATCH("/uploads/:upload_id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.UploadChunk)
+		car.POST("/uploads/:upload_id/complete", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.CompleteResumableUpload)
+		car.DELETE("/uploads/:upload_id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.AbortResumableUpload)
+		car.PUT("/:car_id/order", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.SetPhotoOrder)
+		car.PUT("/:car_id/cover", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.SetPhotoCover)
 		car.GET("/:car_id", hand.GetImagesByCar) // Middleware YO‘Q
 		car.DELETE("/:id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.DeleteImage)
 		car.DELETE("/car/:car_id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.DeleteImagesByCarId)
@@ -45,12 +58,65 @@ func Router(hand *handler.Handler) *gin.Engine {
 	topcar := router.Group("/v1/topcar")
 	{
 		topcar.POST("", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.CreateTopCar)
-		topcar.GET("", hand.GetTopCars)        // Public endpoint
-		topcar.GET("/:id", hand.GetTopCarByID) // Public endpoint
+		topcar.GET("", hand.GetTopCars) // Public endpoint
+		topcar.GET("/analytics", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.GetTopCarAnalytics)
+		topcar.GET("/:id", hand.GetTopCarByID)            // Public endpoint
+		topcar.POST("/:id/click", hand.RecordTopCarClick) // Public endpoint
+		topcar.GET("/:id/stats", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.GetTopCarStats)
+		topcar.PUT("/:id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.UpdateTopCar)
+		topcar.POST("/:id/extend", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.ExtendTopCar)
+		topcar.POST("/:id/restore", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.RestoreTopCar)
+		// Eski yo'l, /v1/admin/topcar/cleanup bilan bir xil (mavjud admin clientlar uchun)
+		topcar.DELETE("/cleanup", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.AdminCleanupTopCars)
 		topcar.DELETE("/:id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.DeleteTopCarByID)
 		topcar.DELETE("/user/:user_id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.DeleteTopCarsByUserID)
 		topcar.DELETE("/car/:car_id", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.DeleteTopCarsByCarID)
 	}
 
+	admin := router.Group("/v1/admin/topcar", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer))
+	{
+		admin.GET("", hand.AdminGetTopCars)
+		admin.GET("/audit", hand.AdminGetAuditLog)
+		admin.POST("/grant", hand.AdminGrantTopCar)
+		admin.POST("/:id/restore", hand.AdminRestoreTopCar)
+		admin.DELETE("/cleanup", hand.AdminCleanupTopCars)
+	}
+
+	adminPayment := router.Group("/v1/admin/payment", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer))
+	{
+		adminPayment.GET("/refunds", hand.AdminGetPaymentRefunds)
+		adminPayment.POST("/refunds/:payment_id/refunded", hand.AdminMarkPaymentRefunded)
+	}
+
+	// Boshqa servislar uchun internal endpointlar
+	internal := router.Group("/v1/internal", middleware.CheckInternalKey(hand.Config.Token.INTERNAL_API_KEY))
+	{
+		internal.POST("/topcar/car/:car_id/retire", hand.RetireTopCarsByCarID)
+	}
+
+	// Payment provider callback (imzo handler ichida tekshiriladi)
+	router.POST("/v1/payment/webhook/:provider", hand.PaymentWebhook)
+
+	notification := router.Group("/v1/notification/preferences")
+	{
+		notification.GET("", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.GetNotificationPreferences)
+		notification.PUT("", middleware.Check, middleware.CheckPermissionMiddleware(hand.Enforcer), hand.UpdateNotificationPreferences)
+	}
+
+	// Obyektlar bucket ko'rinishi bo'yicha tekshirilib imzolangan URL'ga yo'naltiriladi.
+	// Token handler ichida tekshiriladi (public bucketlar uchun shart emas)
+	router.GET("/v1/media/:bucket/*key", hand.GetMedia)
+
+	// local driverda public bucketlar fayllari shu serverning o'zidan beriladi
+	if local, ok := hand.Store.(*upload.LocalStore); ok {
+		mount := "/storage"
+		if u, err := url.Parse(hand.Config.Storage.PUBLIC_URL); err == nil && u.Path != "" {
+			mount = strings.TrimSuffix(u.Path, "/")
+		}
+		for _, bucket := range upload.PublicBuckets(hand.Config) {
+			router.Static(mount+"/"+bucket, filepath.Join(local.Root(), bucket))
+		}
+	}
+
 	return router
 }
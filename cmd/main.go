@@ -1,10 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
 	"log/slog"
+	"os"
+	"strings"
+	"sync"
 	"time"
+	_ "time/tzdata" // alpine image'da timezone ma'lumotlari yo'q (quiet hours uchun kerak)
 
 	"wegugin/api"
 	"wegugin/api/handler"
@@ -12,22 +21,57 @@ import (
 	"wegugin/genproto/cruds"
 	"wegugin/genproto/user"
 	"wegugin/logs"
+	"wegugin/model"
+	"wegugin/notification"
+	"wegugin/payment"
 	"wegugin/storage"
 	"wegugin/storage/mongosh"
 	"wegugin/storage/redis"
 	"wegugin/upload"
 
 	"github.com/casbin/casbin/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 func main() {
+	migrate := flag.Bool("migrate", false, "apply pending Mongo migrations and exit")
+	migrateDryRun := flag.Bool("migrate-dry-run", false, "list pending Mongo migrations and exit")
+	backfillPhotos := flag.Bool("backfill-photos", false, "strip EXIF metadata from existing photos and exit")
+	backfillDryRun := flag.Bool("backfill-dry-run", false, "with -backfill-photos: only report photos that would be changed")
+	gcPhotos := flag.Bool("gc-photos", false, "delete MinIO objects not referenced by any cruds image and exit")
+	gcDryRun := flag.Bool("gc-dry-run", false, "with -gc-photos: only print the orphaned objects report")
+	flag.Parse()
+
+	if *backfillPhotos {
+		runPhotoBackfill(*backfillDryRun)
+		return
+	}
+
 	conf := config.Load()
 	mdb, err := mongosh.Connect(context.Background())
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := mongosh.RequireReplicaSet(context.Background(), mdb); err != nil {
+		log.Fatal(err)
+	}
+
+	switch {
+	case *migrateDryRun:
+		listPendingMigrations(mdb)
+		return
+	case *migrate:
+		runMigrations(mdb)
+		return
+	case conf.Mongo.MIGRATE_ON_START:
+		runMigrations(mdb)
+	}
 	rdbs := redis.ConnectRDB()
 	logger := logs.NewLogger()
 	dbs := storage.NewStorage(mdb, rdbs)
@@ -37,13 +81,68 @@ func main() {
 		}
 	}()
 
-	go startTopCarsCleanup(dbs, logger)
 	hand := NewHandler(conf, logger, dbs)
+	if *gcPhotos {
+		runPhotoGC(conf, hand.Crud, dbs, hand.Store, *gcDryRun)
+		return
+	}
+	go startTopCarsCleanup(dbs, hand.Notifier, logger)
+	go startTopCarsReconciliation(hand.Crud, dbs, logger)
+	go startTopCarsScheduler(conf, dbs, logger)
+	go startTopCarStatsFlush(dbs, logger)
+	go startTopCarsRetention(conf, dbs, hand.Store, logger)
+	go startUploadSessionsCleanup(hand, dbs, logger)
+	go startPhotoGC(conf, hand.Crud, dbs, hand.Store, logger)
 	router := api.Router(hand)
 	log.Printf("server is running...")
 	log.Fatal(router.Run(conf.Server.HTTP_PORT))
 }
 
+func runMigrations(mdb *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	applied, err := mongosh.RunMigrations(ctx, mdb)
+	for _, m := range applied {
+		log.Printf("migration %d applied: %s", m.Version, m.Description)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func listPendingMigrations(mdb *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pending, err := mongosh.PendingMigrations(ctx, mdb)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pending) == 0 {
+		log.Printf("no pending migrations")
+		return
+	}
+	for _, m := range pending {
+		log.Printf("pending migration %d: %s", m.Version, m.Description)
+	}
+}
+
+func runPhotoBackfill(dryRun bool) {
+	conf := config.Load()
+	store, err := upload.NewObjectStore(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := upload.SanitizeExisting(context.Background(), store, "photos", conf.Photo.KEEP_EXIF_TAGS, dryRun, log.Printf)
+	log.Printf("photo backfill: scanned=%d sanitized=%d skipped=%d failed=%d dry_run=%t",
+		report.Scanned, report.Sanitized, report.Skipped, report.Failed, dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func NewHandler(conf *config.Config, logs *slog.Logger, st storage.IStorage) *handler.Handler {
 
 	connUser, err := grpc.NewClient(conf.Server.USER_PORT, grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -62,32 +161,101 @@ func NewHandler(conf *config.Config, logs *slog.Logger, st storage.IStorage) *ha
 	if err != nil {
 		log.Fatal(err)
 	}
-	//minio
-	uploader, err := upload.NewMinioUploader()
+	// storage (minio, local yoki memory)
+	store, err := upload.NewObjectStore(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Resumable yuklashlar MinIO multipart API'siga bog'liq
+	uploader, _ := store.(*upload.MinioUploader)
+	if uploader != nil {
+		go uploader.ApplyBucketPolicies(context.Background())
+	}
+	provider, err := payment.NewProvider(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if provider == nil {
+		logs.Warn("PAYMENT_PROVIDER is not set, paid top car promotions are disabled")
+	}
+	// Push va email provayderlari ulanmaguncha LogSender ishlatiladi
+	notifier := notification.NewNotifier(st.NotificationPreferences(), logs,
+		notification.NewLogSender(model.NotificationChannelInApp, logs),
+		notification.NewLogSender(model.NotificationChannelPush, logs),
+		notification.NewLogSender(model.NotificationChannelEmail, logs),
+	)
 	return &handler.Handler{
 		Cruds:    st,
 		User:     User,
 		Crud:     Crud,
 		Log:      logs,
 		Enforcer: enforcer,
+		Store:    store,
+		Images:   &upload.ImageStore{Store: store, Refs: st.PhotoObjects(), KeepExifTags: conf.Photo.KEEP_EXIF_TAGS},
+		Media:    upload.NewMediaSigner(conf, store),
 		MINIO:    uploader,
+		Config:   conf,
+		Payment:  provider,
+		Notifier: notifier,
 	}
 }
 
-func startTopCarsCleanup(storage storage.IStorage, logger *slog.Logger) {
+func startTopCarsCleanup(storage storage.IStorage, notifier *notification.Notifier, logger *slog.Logger) {
 	ticker := time.NewTicker(30 * time.Minute) // har 30 daqiqada ishga tushadi
 	defer ticker.Stop()
 
 	logger.Info("TopCars cleanup goroutine started", "interval", "30 minutes")
 
 	for range ticker.C {
+		notifyExpiredTopCars(storage, notifier, logger)
 		cleanupExpiredTopCars(storage, logger)
 	}
 }
 
+// topCarExpiryNotifyWindow - bundan oldin tugagan promotionlar uchun xabar yuborilmaydi
+// (masalan, servis uzoq vaqt o'chib qolgandan keyin eski promotionlar haqida yozmaslik uchun)
+const topCarExpiryNotifyWindow = 24 * time.Hour
+
+// notifyExpiredTopCars - muddati tugagan promotion egalariga, sozlamalari ruxsat bergan
+// kanallar orqali xabar yuborish
+func notifyExpiredTopCars(storage storage.IStorage, notifier *notification.Notifier, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	finishedAfter := time.Now().Add(-topCarExpiryNotifyWindow)
+	for {
+		topCar, err := storage.TopCars().ClaimExpiryNotification(ctx, finishedAfter)
+		if err != nil {
+			logger.Error("Failed to claim expired top car for notification", "error", err)
+			return
+		}
+		if topCar == nil {
+			return
+		}
+
+		sent, err := notifier.Notify(ctx, notification.Notification{
+			UserID: topCar.UserId,
+			Type:   model.NotificationTypeTopCarExpiry,
+			Title:  "Top car promotion has ended",
+			Body:   fmt.Sprintf("Your %s promotion ended at %s", topCar.Category, topCar.FinishedAt.Format(time.RFC3339)),
+			Data: map[string]string{
+				"top_car_id": topCar.ID.Hex(),
+				"car_id":     topCar.CarId,
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send top car expiry notification",
+				"top_car_id", topCar.ID.Hex(),
+				"user_id", topCar.UserId,
+				"error", err)
+			continue
+		}
+		logger.Debug("Top car expiry notification processed",
+			"top_car_id", topCar.ID.Hex(),
+			"channels", sent)
+	}
+}
+
 func cleanupExpiredTopCars(storage storage.IStorage, logger *slog.Logger) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -111,3 +279,477 @@ func cleanupExpiredTopCars(storage storage.IStorage, logger *slog.Logger) {
 			"duration", time.Since(start))
 	}
 }
+
+func startTopCarsReconciliation(crud cruds.CrudsServiceClient, storage storage.IStorage, logger *slog.Logger) {
+	ticker := time.NewTicker(30 * time.Minute) // har 30 daqiqada ishga tushadi
+	defer ticker.Stop()
+
+	logger.Info("TopCars reconciliation goroutine started", "interval", "30 minutes")
+
+	for range ticker.C {
+		reconcileTopCars(crud, storage, logger)
+	}
+}
+
+// reconcileTopCars - active promotionlarni cruds servisidagi carlar bilan solishtirish,
+// o'chirilgan yoki sotilgan (available=false) carlarning promotionlarini o'chirish
+func reconcileTopCars(crud cruds.CrudsServiceClient, storage storage.IStorage, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+
+	carIDs, err := storage.TopCars().GetActiveCarIDs(ctx)
+	if err != nil {
+		logger.Error("Failed to get cars of active top cars", "error", err)
+		return
+	}
+
+	var retiredCount int64
+	for _, carID := range carIDs {
+		callCtx, callCancel := context.WithTimeout(ctx, 5*time.Second)
+		car, err := crud.GetCarById(callCtx, &cruds.Id{Id: carID})
+		callCancel()
+
+		reason := ""
+		switch {
+		case status.Code(err) == codes.NotFound:
+			reason = model.TopCarDeleteReasonCarDeleted
+		case err != nil:
+			logger.Warn("Failed to get car for top car reconciliation", "error", err, "car_id", carID)
+			continue
+		case !car.Available:
+			reason = model.TopCarDeleteReasonCarUnavailable
+		default:
+			continue
+		}
+
+		count, err := storage.TopCars().RetireByCarID(ctx, carID, reason)
+		if err != nil {
+			logger.Error("Failed to retire top cars", "error", err, "car_id", carID)
+			continue
+		}
+		if err := storage.Redis().DeleteCarSummary(ctx, carID); err != nil {
+			logger.Warn("Failed to invalidate car summary cache", "error", err, "car_id", carID)
+		}
+		retiredCount += count
+	}
+
+	logger.Info("TopCars reconciliation finished",
+		"checked_cars", len(carIDs),
+		"retired_count", retiredCount,
+		"duration", time.Since(start))
+}
+
+func startTopCarsScheduler(conf *config.Config, storage storage.IStorage, logger *slog.Logger) {
+	ticker := time.NewTicker(1 * time.Minute) // har daqiqada ishga tushadi
+	defer ticker.Stop()
+
+	logger.Info("TopCars scheduler goroutine started", "interval", "1 minute")
+
+	for range ticker.C {
+		updateTopCarPaymentStatuses(conf, storage, logger)
+	}
+}
+
+// updateTopCarPaymentStatuses - boshlangan paid promotionlarni bo'sh slotlarga qarab active qilish va
+// muddatida to'lanmagan pending promotionlarni bekor qilish
+func updateTopCarPaymentStatuses(conf *config.Config, storage storage.IStorage, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	activatedCount, err := storage.TopCars().ActivateStartedTopCars(ctx, conf.TopCar.SlotsByCategory())
+	if err != nil {
+		logger.Error("Failed to activate paid top cars", "error", err)
+	} else if activatedCount > 0 {
+		logger.Info("Activated paid top cars", "activated_count", activatedCount)
+	}
+
+	ttl := time.Duration(conf.Payment.PENDING_TTL_MINUTES) * time.Minute
+	cancelledCount, err := storage.TopCars().CancelStalePendingTopCars(ctx, time.Now().Add(-ttl))
+	if err != nil {
+		logger.Error("Failed to cancel unpaid top cars", "error", err)
+	} else if cancelledCount > 0 {
+		logger.Info("Cancelled unpaid top cars", "cancelled_count", cancelledCount)
+	}
+}
+
+func startUploadSessionsCleanup(hand *handler.Handler, storage storage.IStorage, logger *slog.Logger) {
+	ticker := time.NewTicker(15 * time.Minute) // har 15 daqiqada ishga tushadi
+	defer ticker.Stop()
+
+	logger.Info("Upload sessions cleanup goroutine started", "interval", "15 minutes")
+
+	for range ticker.C {
+		cleanupExpiredUploadSessions(hand, storage, logger)
+	}
+}
+
+// cleanupExpiredUploadSessions - tashlab ketilgan resumable yuklashlarning MinIO part'larini
+// va Redis sessiyalarini o'chirish
+func cleanupExpiredUploadSessions(hand *handler.Handler, storage storage.IStorage, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	sessions, err := storage.Redis().GetExpiredUploadSessions(ctx, time.Now(), 200)
+	if err != nil {
+		logger.Error("Failed to get expired upload sessions", "error", err)
+	}
+
+	var aborted int
+	for _, session := range sessions {
+		if err := hand.AbortUploadSession(ctx, session); err != nil {
+			logger.Warn("Failed to abort expired upload session", "upload_id", session.Id, "error", err)
+			continue
+		}
+		aborted++
+	}
+
+	if aborted > 0 {
+		logger.Info("Expired upload sessions aborted", "count", aborted)
+	}
+}
+
+func startTopCarStatsFlush(storage storage.IStorage, logger *slog.Logger) {
+	ticker := time.NewTicker(1 * time.Minute) // har daqiqada ishga tushadi
+	defer ticker.Stop()
+
+	logger.Info("TopCar stats flush goroutine started", "interval", "1 minute")
+
+	for range ticker.C {
+		flushTopCarStats(storage, logger)
+	}
+}
+
+// flushTopCarStats - Redis'dagi impression/click hisoblagichlarini Mongo'ga yozish
+func flushTopCarStats(storage storage.IStorage, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for {
+		stats, err := storage.Redis().PopTopCarCounters(ctx, 500)
+		if err != nil {
+			logger.Error("Failed to pop top car counters", "error", err)
+			return
+		}
+		// Faqat yozilmagan hisoblagichlar qaytariladi, yozilganlari qayta qo'shilsa ikki marta hisoblanadi
+		failed, err := storage.TopCarStats().AddDailyStats(ctx, stats)
+		if err != nil {
+			logger.Error("Failed to flush top car stats", "error", err, "failed", len(failed), "count", len(stats))
+			if len(failed) > 0 {
+				if restoreErr := storage.Redis().RestoreTopCarCounters(ctx, failed); restoreErr != nil {
+					logger.Error("Failed to restore top car counters", "error", restoreErr, "lost", len(failed))
+				}
+			}
+			return
+		}
+		if len(stats) == 0 {
+			return
+		}
+		logger.Debug("Flushed top car stats", "count", len(stats))
+	}
+}
+
+func startTopCarsRetention(conf *config.Config, storage storage.IStorage, store upload.ObjectStore, logger *slog.Logger) {
+	if conf.TopCar.RETENTION_DAYS <= 0 {
+		logger.Info("TopCars retention disabled")
+		return
+	}
+
+	ticker := time.NewTicker(6 * time.Hour) // har 6 soatda ishga tushadi
+	defer ticker.Stop()
+
+	logger.Info("TopCars retention goroutine started", "interval", "6 hours", "retention_days", conf.TopCar.RETENTION_DAYS)
+
+	for range ticker.C {
+		purgeDeletedTopCars(conf, storage, store, logger)
+	}
+}
+
+// purgeDeletedTopCars - retention muddatidan oldin soft delete qilingan promotionlarni
+// gzip JSONL ko'rinishida MinIO'ga arxivlab, keyin Mongo'dan butunlay o'chirish.
+// Arxiv yuklanmasa hech narsa o'chirilmaydi
+func purgeDeletedTopCars(conf *config.Config, storage storage.IStorage, store upload.ObjectStore, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	cutoff := time.Now().AddDate(0, 0, -conf.TopCar.RETENTION_DAYS)
+	var purged int64
+
+	for {
+		docs, err := storage.TopCars().GetDeletedBefore(ctx, cutoff, 500)
+		if err != nil {
+			logger.Error("Failed to get deleted top cars for purge", "error", err)
+			return
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		archive, ids, err := archiveTopCars(docs)
+		if err != nil {
+			logger.Error("Failed to build top cars archive", "error", err)
+			return
+		}
+
+		now := time.Now().UTC()
+		objectName := fmt.Sprintf("topcars/%s/%s-%s.jsonl.gz", now.Format("2006/01/02"), now.Format("20060102T150405Z"), ids[0].Hex())
+		err = store.Put(ctx, conf.TopCar.ARCHIVE_BUCKET, objectName, bytes.NewReader(archive), int64(len(archive)), "application/gzip")
+		if err != nil {
+			logger.Error("Failed to upload top cars archive", "object", objectName, "error", err)
+			return
+		}
+
+		deleted, err := storage.TopCars().PurgeTopCars(ctx, ids, cutoff)
+		if err != nil {
+			logger.Error("Failed to purge archived top cars", "object", objectName, "error", err)
+			return
+		}
+		purged += deleted
+		logger.Debug("Archived deleted top cars", "object", objectName, "count", len(ids), "purged", deleted)
+
+		if len(docs) < 500 {
+			break
+		}
+	}
+
+	if purged > 0 {
+		logger.Info("Purged deleted top cars", "purged_count", purged, "deleted_before", cutoff)
+	}
+}
+
+// archiveTopCars - hujjatlarni gzip bilan siqilgan JSONL (har qatorda bitta relaxed Extended JSON) ga yozish
+func archiveTopCars(docs []bson.Raw) ([]byte, []primitive.ObjectID, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	ids := make([]primitive.ObjectID, 0, len(docs))
+
+	for _, doc := range docs {
+		id, ok := doc.Lookup("_id").ObjectIDOK()
+		if !ok {
+			continue
+		}
+
+		line, err := bson.MarshalExtJSON(doc, false, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := gz.Write(append(line, '\n')); err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil, fmt.Errorf("no archivable top cars in batch")
+	}
+
+	return buf.Bytes(), ids, nil
+}
+
+const (
+	photoGCPageSize = 100
+	photoGCWorkers  = 4
+)
+
+func startPhotoGC(conf *config.Config, crud cruds.CrudsServiceClient, storage storage.IStorage, store upload.ObjectStore, logger *slog.Logger) {
+	interval := time.Duration(conf.Photo.GC_INTERVAL_HOURS) * time.Hour
+	if interval <= 0 {
+		logger.Warn("PHOTO_GC_INTERVAL_HOURS must be positive, using the default", "value", conf.Photo.GC_INTERVAL_HOURS)
+		interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Photo GC goroutine started", "interval", interval.String(), "delete", conf.Photo.GC_DELETE)
+
+	for range ticker.C {
+		reports, err := collectPhotoOrphans(conf, crud, storage, store, !conf.Photo.GC_DELETE)
+		if err != nil {
+			logger.Error("Photo GC failed", "error", err)
+		}
+		for _, r := range reports {
+			logger.Info("Photo GC finished",
+				"bucket", r.Bucket,
+				"dry_run", r.DryRun,
+				"scanned", r.Scanned,
+				"orphans", len(r.Orphans),
+				"orphan_bytes", r.OrphanBytes,
+				"deleted", r.Deleted,
+				"failed", r.Failed,
+				"aborted", r.Aborted)
+		}
+	}
+}
+
+// runPhotoGC - -gc-photos flag'i: hisobotni JSON ko'rinishida stdout'ga chiqarish
+func runPhotoGC(conf *config.Config, crud cruds.CrudsServiceClient, storage storage.IStorage, store upload.ObjectStore, dryRun bool) {
+	reports, err := collectPhotoOrphans(conf, crud, storage, store, dryRun)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(reports); encErr != nil {
+		log.Printf("failed to write photo GC report: %v", encErr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// collectPhotoOrphans - cruds'dagi barcha rasmlarni yig'ib, har bir GC bucketdagi orphan
+// obyektlarni topish. Rasmlar ro'yxati to'liq yig'ilmasa hech narsa o'chirilmaydi
+func collectPhotoOrphans(conf *config.Config, crud cruds.CrudsServiceClient, storage storage.IStorage, store upload.ObjectStore, dryRun bool) ([]*upload.GCReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	referenced, err := referencedPhotoObjects(ctx, crud, storage, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect referenced photos: %v", err)
+	}
+
+	opts := upload.GCOptions{
+		GracePeriod:    time.Duration(conf.Photo.GC_GRACE_HOURS) * time.Hour,
+		DryRun:         dryRun,
+		MaxDeleteRatio: conf.Photo.GC_MAX_DELETE_RATIO,
+		Refs:           storage.PhotoObjects(),
+	}
+
+	var reports []*upload.GCReport
+	for _, bucket := range strings.Split(conf.Photo.GC_BUCKETS, ",") {
+		bucket = strings.TrimSpace(bucket)
+		if bucket == "" {
+			continue
+		}
+
+		// Refcount'i bor obyektlar qaysidir rasmga tegishli, car ro'yxatda ko'rinmasa ham o'chirilmaydi
+		keys, err := storage.PhotoObjects().ReferencedKeys(ctx, bucket)
+		if err != nil {
+			return reports, fmt.Errorf("failed to get refcounted objects of bucket %s: %v", bucket, err)
+		}
+		if referenced[bucket] == nil {
+			referenced[bucket] = make(map[string]bool)
+		}
+		for _, key := range keys {
+			for _, k := range upload.VariantKeys(key) {
+				referenced[bucket][k] = true
+			}
+		}
+
+		report, err := upload.CollectOrphans(ctx, store, bucket, referenced[bucket], opts)
+		reports = append(reports, report)
+		if err != nil {
+			return reports, fmt.Errorf("bucket %s: %v", bucket, err)
+		}
+	}
+
+	return reports, nil
+}
+
+// referencedPhotoObjects - bucket -> cruds rasmlariga tegishli obyekt nomlari (variantlari bilan).
+// Umumiy ListCars sotilgan yoki mavjud bo'lmagan carlarni qaytarmasligi mumkin, shuning uchun
+// egalarining carlari, promotion sotib olingan va rasm tartibi saqlangan barcha carlar ham tekshiriladi
+func referencedPhotoObjects(ctx context.Context, crud cruds.CrudsServiceClient, storage storage.IStorage, store upload.ObjectStore) (map[string]map[string]bool, error) {
+	carIDs := make(map[string]bool)
+	owners := make(map[string]bool)
+	listCars := func(userID string) error {
+		for offset := int32(0); ; offset += photoGCPageSize {
+			res, err := crud.ListCars(ctx, &cruds.ListCarsRequest{Limit: photoGCPageSize, Offset: offset, UserId: userID})
+			if err != nil {
+				return fmt.Errorf("failed to list cars: %v", err)
+			}
+			for _, car := range res.Cars {
+				carIDs[car.Id] = true
+				if car.OwnerId != "" {
+					owners[car.OwnerId] = true
+				}
+			}
+			if len(res.Cars) < photoGCPageSize {
+				return nil
+			}
+		}
+	}
+	if err := listCars(""); err != nil {
+		return nil, err
+	}
+
+	topCarIDs, topCarUsers, err := storage.TopCars().GetAllCarIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cars of top cars: %v", err)
+	}
+	for _, id := range topCarIDs {
+		carIDs[id] = true
+	}
+	for _, id := range topCarUsers {
+		owners[id] = true
+	}
+
+	orderedIDs, err := storage.PhotoOrders().GetCarIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cars of photo orders: %v", err)
+	}
+	for _, id := range orderedIDs {
+		carIDs[id] = true
+	}
+
+	// Egasi o'z carlarini (sotilganlari bilan) ko'radi
+	for owner := range owners {
+		if err := listCars(owner); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		firstErr   error
+		referenced = make(map[string]map[string]bool)
+		jobs       = make(chan string)
+	)
+	for i := 0; i < photoGCWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for carID := range jobs {
+				res, err := crud.GetImagesByCar(ctx, &cruds.CarId{CarId: carID})
+				if status.Code(err) == codes.NotFound {
+					continue
+				}
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to get images of car %s: %v", carID, err)
+					}
+					mu.Unlock()
+					continue
+				}
+				for _, image := range res.Images {
+					bucket, key, err := store.ParseURL(image.Filename)
+					if err != nil {
+						continue
+					}
+					if referenced[bucket] == nil {
+						referenced[bucket] = make(map[string]bool)
+					}
+					for _, k := range upload.VariantKeys(key) {
+						referenced[bucket][k] = true
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for id := range carIDs {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return referenced, nil
+}
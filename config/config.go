@@ -9,16 +9,21 @@ import (
 )
 
 type Config struct {
-	Mongo  MongoConfig
-	Server ServerConfig
-	Redis  RedisConfig
-	Token  TokensConfig
-	Minio  MinioConfig
+	Mongo   MongoConfig
+	Server  ServerConfig
+	Redis   RedisConfig
+	Token   TokensConfig
+	Minio   MinioConfig
+	TopCar  TopCarConfig
+	Payment PaymentConfig
+	Photo   PhotoConfig
+	Storage StorageConfig
 }
 
 type MongoConfig struct {
-	MDB_ADDRESS string
-	MDB_NAME    string
+	MDB_ADDRESS      string
+	MDB_NAME         string
+	MIGRATE_ON_START bool
 }
 
 type RedisConfig struct {
@@ -30,10 +35,12 @@ type ServerConfig struct {
 	HTTP_PORT string
 	GRPC_PORT string
 	USER_PORT string
+	ENV       string // dev yoki production
 }
 
 type TokensConfig struct {
-	ACCES_KEY string
+	ACCES_KEY        string
+	INTERNAL_API_KEY string // servislar orasidagi internal endpointlar uchun
 }
 
 type MinioConfig struct {
@@ -42,6 +49,73 @@ type MinioConfig struct {
 	MINIO_SECRET_ACCESS_KEY string
 	MINIO_BUCKET_NAME       string
 	MINIO_PUBLIC_URL        string
+	MINIO_REGION            string // presigned URL'lar imzosi uchun (public endpoint orqali bucket region so'ralmaydi)
+}
+
+type TopCarConfig struct {
+	MIN_DURATION_HOURS      int
+	MAX_DURATION_HOURS      int
+	MAX_SCHEDULE_AHEAD_DAYS int
+	// Narxlar valyutaning minor birliklarida (cent, tiyin)
+	PRICE_DAILY             int64
+	PRICE_WEEKLY            int64
+	PRICE_MONTHLY           int64
+	PRICE_CUSTOM_PER_HOUR   int64
+	CURRENCY                string
+	SLOTS_DAILY             int // 0 - cheklanmagan
+	SLOTS_WEEKLY            int
+	SLOTS_MONTHLY           int
+	SLOTS_CUSTOM            int
+	ROTATION_BUCKET_MINUTES int
+	CLICK_DEDUP_MINUTES     int    // bitta IP/sessiyadan shu vaqt ichidagi takroriy clicklar hisoblanmaydi
+	RESTORE_GRACE_HOURS     int    // owner o'chirilgan promotionni shu vaqt ichida tiklay oladi
+	RETENTION_DAYS          int    // soft delete qilinganlar shuncha kundan keyin arxivlanib o'chiriladi, 0 - o'chirilmaydi
+	ARCHIVE_BUCKET          string // arxiv fayllari uchun MinIO bucket
+}
+
+// SlotsByCategory - har bir category uchun bir vaqtda active bo'la oladigan promotionlar soni
+func (c TopCarConfig) SlotsByCategory() map[string]int {
+	return map[string]int{
+		"daily":   c.SLOTS_DAILY,
+		"weekly":  c.SLOTS_WEEKLY,
+		"monthly": c.SLOTS_MONTHLY,
+		"custom":  c.SLOTS_CUSTOM,
+	}
+}
+
+type StorageConfig struct {
+	DRIVER     string // minio, local yoki memory
+	LOCAL_ROOT string // local driver fayllari papkasi
+	PUBLIC_URL string // local va memory driverlar uchun obyektlar URL'i
+	// vergul bilan bucket:visibility (public, authenticated, owner, private).
+	// Ro'yxatda yo'q bucketlar private hisoblanadi
+	BUCKET_VISIBILITY  string
+	SIGNED_URL_MINUTES int // private bucketlar uchun imzolangan GET URL'lar muddati
+}
+
+type PhotoConfig struct {
+	MAX_FILES_PER_REQUEST  int // bitta so'rovda yuklanadigan rasmlar soni
+	UPLOAD_WORKERS         int // bir vaqtda MinIO'ga yuklanadigan rasmlar soni
+	MAX_BYTES              int64
+	MAX_WIDTH              int
+	MAX_HEIGHT             int
+	ALLOWED_FORMATS        string // vergul bilan: jpeg,png,gif,webp,bmp
+	KEEP_EXIF_TAGS         bool   // true - kamera, dastur, sana va copyright teglari saqlanadi (GPS hech qachon)
+	STAGING_BUCKET         string // presigned yuklashlar uchun private bucket
+	PRESIGN_EXPIRY_MINUTES int
+	CHUNK_SIZE             int64   // resumable yuklashda bo'lak hajmi (kamida 5 MiB)
+	UPLOAD_SESSION_HOURS   int     // oxirgi bo'lakdan keyin sessiya shuncha vaqt yashaydi (boshlanganidan ko'pi bilan 23 soat)
+	GC_BUCKETS             string  // vergul bilan: orphan obyektlar qidiriladigan bucketlar
+	GC_INTERVAL_HOURS      int     // 0 yoki manfiy bo'lsa 24 soat
+	GC_GRACE_HOURS         int     // shundan yangi orphanlar o'chirilmaydi
+	GC_DELETE              bool    // false - job faqat hisobot yozadi
+	GC_MAX_DELETE_RATIO    float64 // bucketning shuncha qismidan ko'p orphan bo'lsa o'chirish to'xtatiladi
+}
+
+type PaymentConfig struct {
+	PROVIDER            string // bo'sh - to'lovlar o'chirilgan, "local" faqat APP_ENV=dev bo'lganda
+	WEBHOOK_SECRET      string // majburiy, default qiymati yo'q
+	PENDING_TTL_MINUTES int
 }
 
 func Load() *Config {
@@ -51,16 +125,19 @@ func Load() *Config {
 
 	return &Config{
 		Mongo: MongoConfig{
-			MDB_ADDRESS: cast.ToString(coalesce("MDB_ADDRESS", "localhost")),
-			MDB_NAME:    cast.ToString(coalesce("MDB_NAME", "5432")),
+			MDB_ADDRESS:      cast.ToString(coalesce("MDB_ADDRESS", "localhost")),
+			MDB_NAME:         cast.ToString(coalesce("MDB_NAME", "5432")),
+			MIGRATE_ON_START: cast.ToBool(coalesce("MDB_MIGRATE_ON_START", true)),
 		},
 		Server: ServerConfig{
 			HTTP_PORT: cast.ToString(coalesce("HTTP_PORT", ":1234")),
 			GRPC_PORT: cast.ToString(coalesce("GRPC_PORT", ":5678")),
 			USER_PORT: cast.ToString(coalesce("USER_PORT", ":9000")),
+			ENV:       cast.ToString(coalesce("APP_ENV", "production")),
 		},
 		Token: TokensConfig{
-			ACCES_KEY: cast.ToString(coalesce("ACCES_KEY", "access_key")),
+			ACCES_KEY:        cast.ToString(coalesce("ACCES_KEY", "access_key")),
+			INTERNAL_API_KEY: cast.ToString(coalesce("INTERNAL_API_KEY", "")),
 		},
 		Minio: MinioConfig{
 			MINIO_ENDPOINT:          cast.ToString(coalesce("MINIO_ENDPOINT", "access_key")),
@@ -68,6 +145,56 @@ func Load() *Config {
 			MINIO_SECRET_ACCESS_KEY: cast.ToString(coalesce("MINIO_SECRET_ACCESS_KEY", "access_key")),
 			MINIO_BUCKET_NAME:       cast.ToString(coalesce("MINIO_BUCKET_NAME", "twit_images")),
 			MINIO_PUBLIC_URL:        cast.ToString(coalesce("MINIO_PUBLIC_URL", "http://localhost:9000/minio/")),
+			MINIO_REGION:            cast.ToString(coalesce("MINIO_REGION", "us-east-1")),
+		},
+		TopCar: TopCarConfig{
+			MIN_DURATION_HOURS:      cast.ToInt(coalesce("TOPCAR_MIN_DURATION_HOURS", 1)),
+			MAX_DURATION_HOURS:      cast.ToInt(coalesce("TOPCAR_MAX_DURATION_HOURS", 90*24)),
+			MAX_SCHEDULE_AHEAD_DAYS: cast.ToInt(coalesce("TOPCAR_MAX_SCHEDULE_AHEAD_DAYS", 90)),
+			PRICE_DAILY:             cast.ToInt64(coalesce("TOPCAR_PRICE_DAILY_MINOR", 500)),
+			PRICE_WEEKLY:            cast.ToInt64(coalesce("TOPCAR_PRICE_WEEKLY_MINOR", 2500)),
+			PRICE_MONTHLY:           cast.ToInt64(coalesce("TOPCAR_PRICE_MONTHLY_MINOR", 8000)),
+			PRICE_CUSTOM_PER_HOUR:   cast.ToInt64(coalesce("TOPCAR_PRICE_CUSTOM_PER_HOUR_MINOR", 25)),
+			CURRENCY:                cast.ToString(coalesce("TOPCAR_CURRENCY", "USD")),
+			SLOTS_DAILY:             cast.ToInt(coalesce("TOPCAR_SLOTS_DAILY", 20)),
+			SLOTS_WEEKLY:            cast.ToInt(coalesce("TOPCAR_SLOTS_WEEKLY", 20)),
+			SLOTS_MONTHLY:           cast.ToInt(coalesce("TOPCAR_SLOTS_MONTHLY", 20)),
+			SLOTS_CUSTOM:            cast.ToInt(coalesce("TOPCAR_SLOTS_CUSTOM", 20)),
+			ROTATION_BUCKET_MINUTES: cast.ToInt(coalesce("TOPCAR_ROTATION_BUCKET_MINUTES", 15)),
+			CLICK_DEDUP_MINUTES:     cast.ToInt(coalesce("TOPCAR_CLICK_DEDUP_MINUTES", 30)),
+			RESTORE_GRACE_HOURS:     cast.ToInt(coalesce("TOPCAR_RESTORE_GRACE_HOURS", 24)),
+			RETENTION_DAYS:          cast.ToInt(coalesce("TOPCAR_RETENTION_DAYS", 90)),
+			ARCHIVE_BUCKET:          cast.ToString(coalesce("TOPCAR_ARCHIVE_BUCKET", "topcars-archive")),
+		},
+		Payment: PaymentConfig{
+			PROVIDER:            cast.ToString(coalesce("PAYMENT_PROVIDER", "")),
+			WEBHOOK_SECRET:      cast.ToString(coalesce("PAYMENT_WEBHOOK_SECRET", "")),
+			PENDING_TTL_MINUTES: cast.ToInt(coalesce("PAYMENT_PENDING_TTL_MINUTES", 30)),
+		},
+		Photo: PhotoConfig{
+			MAX_FILES_PER_REQUEST:  cast.ToInt(coalesce("PHOTO_MAX_FILES_PER_REQUEST", 20)),
+			UPLOAD_WORKERS:         cast.ToInt(coalesce("PHOTO_UPLOAD_WORKERS", 4)),
+			MAX_BYTES:              cast.ToInt64(coalesce("PHOTO_MAX_BYTES", 10<<20)),
+			MAX_WIDTH:              cast.ToInt(coalesce("PHOTO_MAX_WIDTH", 8000)),
+			MAX_HEIGHT:             cast.ToInt(coalesce("PHOTO_MAX_HEIGHT", 8000)),
+			ALLOWED_FORMATS:        cast.ToString(coalesce("PHOTO_ALLOWED_FORMATS", "jpeg,png,webp")),
+			KEEP_EXIF_TAGS:         cast.ToBool(coalesce("PHOTO_KEEP_EXIF_TAGS", false)),
+			STAGING_BUCKET:         cast.ToString(coalesce("PHOTO_STAGING_BUCKET", "photos-staging")),
+			PRESIGN_EXPIRY_MINUTES: cast.ToInt(coalesce("PHOTO_PRESIGN_EXPIRY_MINUTES", 15)),
+			CHUNK_SIZE:             cast.ToInt64(coalesce("PHOTO_CHUNK_SIZE", 5<<20)),
+			UPLOAD_SESSION_HOURS:   cast.ToInt(coalesce("PHOTO_UPLOAD_SESSION_HOURS", 24)),
+			GC_BUCKETS:             cast.ToString(coalesce("PHOTO_GC_BUCKETS", "photos")),
+			GC_INTERVAL_HOURS:      cast.ToInt(coalesce("PHOTO_GC_INTERVAL_HOURS", 24)),
+			GC_GRACE_HOURS:         cast.ToInt(coalesce("PHOTO_GC_GRACE_HOURS", 24)),
+			GC_DELETE:              cast.ToBool(coalesce("PHOTO_GC_DELETE", false)),
+			GC_MAX_DELETE_RATIO:    cast.ToFloat64(coalesce("PHOTO_GC_MAX_DELETE_RATIO", 0.5)),
+		},
+		Storage: StorageConfig{
+			DRIVER:             cast.ToString(coalesce("STORAGE_DRIVER", "minio")),
+			LOCAL_ROOT:         cast.ToString(coalesce("STORAGE_LOCAL_ROOT", "./data/storage")),
+			PUBLIC_URL:         cast.ToString(coalesce("STORAGE_PUBLIC_URL", "http://localhost:1234/storage")),
+			BUCKET_VISIBILITY:  cast.ToString(coalesce("STORAGE_BUCKET_VISIBILITY", "photos:public")),
+			SIGNED_URL_MINUTES: cast.ToInt(coalesce("STORAGE_SIGNED_URL_MINUTES", 10)),
 		},
 		Redis: RedisConfig{
 			RDB_ADDRESS:  cast.ToString(coalesce("RDB_ADDRESS", "localhost:6379")),
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,23 +15,417 @@ type SendMessageBody struct {
 }
 
 type TopCars struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CarId        string             `bson:"car_id" json:"car_id"`
+	UserId       string             `bson:"user_id" json:"user_id"`
+	Category     string             `bson:"category" json:"category"` // "daily", "weekly", "monthly", "custom"
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	StartsAt     time.Time          `bson:"starts_at" json:"starts_at"`
+	FinishedAt   time.Time          `bson:"finished_at" json:"finished_at"`
+	DeletedAt    *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeleteReason string             `bson:"delete_reason,omitempty" json:"delete_reason,omitempty"`
+	Extensions   []TopCarExtension  `bson:"extensions,omitempty" json:"extensions,omitempty"`
+	Status       string             `bson:"status" json:"status"`                       // "pending", "paid", "active", "cancelled"
+	Amount       int64              `bson:"amount_minor" json:"amount_minor"`           // jami to'langan (uzaytirishlar bilan), minor birliklarda (cent, tiyin)
+	BaseAmount   int64              `bson:"base_amount_minor" json:"base_amount_minor"` // asosiy oraliq narxi (uzaytirishlarsiz), reschedule farqi shunga nisbatan
+	Currency     string             `bson:"currency" json:"currency"`
+	PaymentId    string             `bson:"payment_id,omitempty" json:"payment_id,omitempty"`
+	PaidAt       *time.Time         `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+	// PendingChange - to'lov kutayotgan reschedule/extend, webhook tasdiqlaganda qo'llanadi
+	PendingChange *TopCarChange `bson:"pending_change,omitempty" json:"pending_change,omitempty"`
+	// AppliedChanges - to'lab qo'llangan o'zgarishlar (webhook qayta kelganda takror qo'llamaslik uchun)
+	AppliedChanges []TopCarChange `bson:"applied_changes,omitempty" json:"applied_changes,omitempty"`
+	// ExpiryNotifiedAt - egasiga muddat tugagani haqida notification yuborilgan vaqt
+	ExpiryNotifiedAt *time.Time `bson:"expiry_notified_at,omitempty" json:"-"`
+}
+
+// TopCar promotion statuslari: pending -> paid -> active
+const (
+	TopCarStatusPending   = "pending"   // to'lov kutilmoqda
+	TopCarStatusPaid      = "paid"      // to'langan, starts_at yoki bo'sh slot kutilmoqda (waitlist)
+	TopCarStatusActive    = "active"    // to'langan va boshlangan
+	TopCarStatusCancelled = "cancelled" // to'lov amalga oshmadi yoki muddati o'tdi
+)
+
+// Pullik o'zgarish turlari
+const (
+	TopCarChangeReschedule = "reschedule"
+	TopCarChangeExtend     = "extend"
+)
+
+// Qaytarilishi kerak bo'lgan to'lov statuslari: required -> refunded
+const (
+	PaymentRefundStatusRequired = "required" // pul olingan, lekin xizmat berilmadi
+	PaymentRefundStatusRefunded = "refunded" // admin provider orqali pulni qaytargan
+)
+
+// Pul qaytarish sabablari
+const (
+	PaymentRefundReasonChangeNotApplied   = "change_not_applied"  // to'langan reschedule/extend oralig'i band bo'lib qolgan
+	PaymentRefundReasonPromotionCancelled = "promotion_cancelled" // to'lov promotion bekor qilingandan keyin tasdiqlangan
+)
+
+// PaymentRefund - olingan, lekin qaytarilishi kerak bo'lgan to'lov. Webhook yozadi, admin qaytargandan keyin belgilaydi
+type PaymentRefund struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	CarId      string             `bson:"car_id" json:"car_id"`
+	PaymentId  string             `bson:"payment_id" json:"payment_id"`
+	TopCarId   string             `bson:"top_car_id" json:"top_car_id"`
 	UserId     string             `bson:"user_id" json:"user_id"`
-	Category   string             `bson:"category" json:"category"` // "daily", "weekly", "monthly"
+	Amount     int64              `bson:"amount_minor" json:"amount_minor"` // minor birliklarda (cent, tiyin)
+	Currency   string             `bson:"currency" json:"currency"`
+	Reason     string             `bson:"reason" json:"reason"`
+	Status     string             `bson:"status" json:"status"` // "required", "refunded"
 	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
-	FinishedAt time.Time          `bson:"finished_at" json:"finished_at"`
-	DeletedAt  *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	RefundedAt *time.Time         `bson:"refunded_at,omitempty" json:"refunded_at,omitempty"`
+	RefundedBy string             `bson:"refunded_by,omitempty" json:"refunded_by,omitempty"`
+}
+
+// TopCarChange - qo'shimcha to'lov talab qiladigan o'zgarish. Yangi oraliq faqat to'lov
+// tasdiqlangandan keyin promotionga yoziladi
+type TopCarChange struct {
+	Kind               string     `bson:"kind" json:"kind"` // "reschedule", "extend"
+	Category           string     `bson:"category" json:"category"`
+	StartsAt           time.Time  `bson:"starts_at" json:"starts_at"`
+	FinishedAt         time.Time  `bson:"finished_at" json:"finished_at"`
+	PreviousFinishedAt time.Time  `bson:"previous_finished_at" json:"previous_finished_at"`
+	Amount             int64      `bson:"amount_minor" json:"amount_minor"` // narx farqi, minor birliklarda
+	Currency           string     `bson:"currency" json:"currency"`
+	PaymentId          string     `bson:"payment_id" json:"payment_id"`
+	RequestedBy        string     `bson:"requested_by" json:"requested_by"`
+	CreatedAt          time.Time  `bson:"created_at" json:"created_at"`
+	PaidAt             *time.Time `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+}
+
+// Promotion o'chirilish sabablari (avtomatik o'chirishlar uchun)
+const (
+	TopCarDeleteReasonCarDeleted     = "car_deleted"
+	TopCarDeleteReasonCarUnavailable = "car_unavailable"
+)
+
+// TopCarExtension - promotion muddati uzaytirilganligi tarixi
+type TopCarExtension struct {
+	Category           string    `bson:"category" json:"category"`
+	PreviousFinishedAt time.Time `bson:"previous_finished_at" json:"previous_finished_at"`
+	FinishedAt         time.Time `bson:"finished_at" json:"finished_at"`
+	ExtendedBy         string    `bson:"extended_by" json:"extended_by"`
+	ExtendedAt         time.Time `bson:"extended_at" json:"extended_at"`
+}
+
+// CarSummary - top car ro'yxatida ko'rsatiladigan qisqa car ma'lumotlari (Redis'da cache qilinadi)
+type CarSummary struct {
+	Id        string  `json:"id"`
+	Make      string  `json:"make"`
+	Model     string  `json:"model"`
+	Year      int32   `json:"year"`
+	Price     float64 `json:"price"`
+	Location  string  `json:"location"`
+	Available bool    `json:"available"`
+	Image     string  `json:"image,omitempty"` // birinchi rasm
+}
+
+// TopCarDailyStats - promotion uchun kunlik impression/click statistikasi
+type TopCarDailyStats struct {
+	TopCarId    string    `bson:"top_car_id" json:"top_car_id"`
+	Day         time.Time `bson:"day" json:"day"` // UTC bo'yicha kun boshi
+	Impressions int64     `bson:"impressions" json:"impressions"`
+	Clicks      int64     `bson:"clicks" json:"clicks"`
 }
 
 // Filter options for GetListOfTopCars
 type TopCarsFilter struct {
-	Category    string `json:"category,omitempty"`
-	UserID      string `json:"user_id,omitempty"`
-	CarID       string `json:"car_id,omitempty"`
-	SortBy      string `json:"sort_by,omitempty"`      // "finished_at_asc", "finished_at_desc", "created_at_asc", "created_at_desc"
-	ShowExpired bool   `json:"show_expired,omitempty"` // false by default
-	ShowDeleted bool   `json:"show_deleted,omitempty"` // false by default
-	Limit       int64  `json:"limit,omitempty"`        // default 50
-	Skip        int64  `json:"skip,omitempty"`         // for pagination
+	Category      string         `json:"category,omitempty"`
+	UserID        string         `json:"user_id,omitempty"`
+	CarID         string         `json:"car_id,omitempty"`
+	SortBy        string         `json:"sort_by,omitempty"`        // "finished_at_asc", "finished_at_desc", "created_at_asc", "created_at_desc", "rotation"
+	ShowExpired   bool           `json:"show_expired,omitempty"`   // false by default
+	ShowScheduled bool           `json:"show_scheduled,omitempty"` // false by default, hali boshlanmagan promotionlar
+	ShowDeleted   bool           `json:"show_deleted,omitempty"`   // false by default
+	ShowUnpaid    bool           `json:"show_unpaid,omitempty"`    // false by default, pending/cancelled promotionlar
+	Limit         int64          `json:"limit,omitempty"`          // default 50
+	Skip          int64          `json:"skip,omitempty"`           // for pagination
+	RotationSeed  int64          `json:"rotation_seed,omitempty"`  // "rotation" sort uchun, bir vaqt oralig'ida o'zgarmaydi
+	After         *TopCarsCursor `json:"-"`                        // cursor pagination, berilsa Skip ishlatilmaydi
+}
+
+// SortField - SortBy bo'yicha sort maydoni va yo'nalishi (1 - asc, -1 - desc)
+func (f TopCarsFilter) SortField() (string, int) {
+	switch f.SortBy {
+	case "finished_at_desc":
+		return "finished_at", -1
+	case "created_at_asc":
+		return "created_at", 1
+	case "created_at_desc":
+		return "created_at", -1
+	default:
+		// Default: muddati tezroq tugaydiganlar birinchi
+		return "finished_at", 1
+	}
+}
+
+// TopCarsCursor - cursor pagination uchun oxirgi qaytarilgan element (sort qiymati va _id)
+type TopCarsCursor struct {
+	Field     string             `json:"f"`
+	Direction int                `json:"d"`
+	Value     time.Time          `json:"v"`
+	ID        primitive.ObjectID `json:"id"`
+}
+
+// NewTopCarsCursor - ro'yxatdagi oxirgi element uchun keyingi sahifa cursori
+func NewTopCarsCursor(filter TopCarsFilter, last *TopCars) TopCarsCursor {
+	field, direction := filter.SortField()
+	value := last.FinishedAt
+	if field == "created_at" {
+		value = last.CreatedAt
+	}
+	return TopCarsCursor{Field: field, Direction: direction, Value: value, ID: last.ID}
+}
+
+// Encode - clientga beriladigan opaque token
+func (c TopCarsCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTopCarsCursor - tokenni o'qish va filterning sort tartibiga mosligini tekshirish
+func DecodeTopCarsCursor(token string, filter TopCarsFilter) (*TopCarsCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor TopCarsCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	field, direction := filter.SortField()
+	if cursor.Field != field || cursor.Direction != direction {
+		return nil, errors.New("cursor does not match sort_by")
+	}
+
+	return &cursor, nil
+}
+
+// Notification turlari
+const (
+	NotificationTypeChatMessage         = "chat_message"
+	NotificationTypeTopCarExpiry        = "top_car_expiry"
+	NotificationTypeSavedCarPriceChange = "saved_car_price_change"
+)
+
+// Notification kanallari
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelPush  = "push"
+	NotificationChannelEmail = "email" // hozircha stub
+)
+
+type ChannelPreferences struct {
+	InApp bool `bson:"in_app" json:"in_app"`
+	Push  bool `bson:"push" json:"push"`
+	Email bool `bson:"email" json:"email"`
+}
+
+type QuietHours struct {
+	Enabled  bool   `bson:"enabled" json:"enabled"`
+	Start    string `bson:"start" json:"start" example:"22:00"`               // "HH:MM"
+	End      string `bson:"end" json:"end" example:"07:00"`                   // "HH:MM"
+	Timezone string `bson:"timezone" json:"timezone" example:"Asia/Tashkent"` // IANA timezone
+}
+
+type NotificationPreferences struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserId              string             `bson:"user_id" json:"user_id"`
+	ChatMessage         ChannelPreferences `bson:"chat_message" json:"chat_message"`
+	TopCarExpiry        ChannelPreferences `bson:"top_car_expiry" json:"top_car_expiry"`
+	SavedCarPriceChange ChannelPreferences `bson:"saved_car_price_change" json:"saved_car_price_change"`
+	QuietHours          QuietHours         `bson:"quiet_hours" json:"quiet_hours"`
+	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// DefaultNotificationPreferences - user hali sozlamalarni saqlamagan bo'lsa ishlatiladi
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	defaults := ChannelPreferences{InApp: true, Push: true, Email: false}
+	return &NotificationPreferences{
+		UserId:              userID,
+		ChatMessage:         defaults,
+		TopCarExpiry:        defaults,
+		SavedCarPriceChange: defaults,
+		QuietHours: QuietHours{
+			Enabled:  false,
+			Start:    "22:00",
+			End:      "07:00",
+			Timezone: "UTC",
+		},
+	}
+}
+
+// Allows - notification yuborishdan oldin har bir notifier shu metodni tekshirishi kerak.
+// Quiet hours faqat push va email kanallariga ta'sir qiladi, in-app notificationlar saqlanaveradi.
+func (p *NotificationPreferences) Allows(notificationType, channel string, at time.Time) bool {
+	var prefs ChannelPreferences
+	switch notificationType {
+	case NotificationTypeChatMessage:
+		prefs = p.ChatMessage
+	case NotificationTypeTopCarExpiry:
+		prefs = p.TopCarExpiry
+	case NotificationTypeSavedCarPriceChange:
+		prefs = p.SavedCarPriceChange
+	default:
+		return false
+	}
+
+	switch channel {
+	case NotificationChannelInApp:
+		return prefs.InApp
+	case NotificationChannelPush:
+		return prefs.Push && !p.QuietHours.Contains(at)
+	case NotificationChannelEmail:
+		return prefs.Email && !p.QuietHours.Contains(at)
+	default:
+		return false
+	}
+}
+
+// Contains - berilgan vaqt userning timezone'ida quiet hours oralig'iga tushadimi
+func (q QuietHours) Contains(at time.Time) bool {
+	if !q.Enabled {
+		return false
+	}
+
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return false
+	}
+
+	local := at.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute == endMinute {
+		return false
+	}
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	// Yarim tundan o'tadigan oraliq, masalan 22:00 - 07:00
+	return minute >= startMinute || minute < endMinute
+}
+
+// Admin amallari (audit log uchun)
+const (
+	AdminActionTopCarCleanup   = "topcar_cleanup"
+	AdminActionTopCarRestore   = "topcar_restore"
+	AdminActionTopCarGrant     = "topcar_grant"
+	AdminActionPaymentRefunded = "payment_refunded"
+)
+
+// AdminAuditLog - admin tomonidan bajarilgan har bir amal
+type AdminAuditLog struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AdminId   string             `bson:"admin_id" json:"admin_id"`
+	Action    string             `bson:"action" json:"action"`
+	TopCarId  string             `bson:"top_car_id,omitempty" json:"top_car_id,omitempty"`
+	CarId     string             `bson:"car_id,omitempty" json:"car_id,omitempty"`
+	Details   map[string]string  `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type AdminAuditFilter struct {
+	AdminID  string
+	Action   string
+	TopCarID string
+	Limit    int64 // default 50
+	Skip     int64
+}
+
+// TopCarAnalyticsFilter - promotion hisobotlari uchun [From, To) oralig'i va guruhlash
+type TopCarAnalyticsFilter struct {
+	From            time.Time
+	To              time.Time
+	Interval        string // "day", "week", "month"
+	Timezone        string // IANA timezone, periodlar shu vaqt zonasida hisoblanadi
+	TopSellersLimit int64
+}
+
+// TopCarAnalyticsBucket - bitta period va category uchun hisoblagichlar.
+// Created - yaratilgan, Active - boshlangan, Expired - muddati tugagan,
+// Deleted - muddati tugashidan oldin o'chirilgan promotionlar
+type TopCarAnalyticsBucket struct {
+	Period   string `json:"period,omitempty"`
+	Category string `json:"category"`
+	Created  int64  `json:"created"`
+	Active   int64  `json:"active"`
+	Expired  int64  `json:"expired"`
+	Deleted  int64  `json:"deleted"`
+}
+
+// TopCarSeller - eng ko'p promotion sotib olgan user
+type TopCarSeller struct {
+	UserId     string `bson:"_id" json:"user_id"`
+	Promotions int64  `bson:"promotions" json:"promotions"`
+	Amount     int64  `bson:"amount_minor" json:"amount_minor"`
+}
+
+type TopCarAnalytics struct {
+	Series     []TopCarAnalyticsBucket `json:"series"`
+	Totals     []TopCarAnalyticsBucket `json:"totals"`
+	TopSellers []TopCarSeller          `json:"top_sellers"`
+}
+
+// UploadSession - bo'laklab (resumable) yuklash sessiyasi. Redis'da saqlanadi,
+// bo'laklar MinIO multipart upload'ning part'lari sifatida staging bucketga yoziladi
+type UploadSession struct {
+	Id          string       `json:"id"`
+	UserId      string       `json:"user_id"`
+	CarId       string       `json:"car_id"`
+	Bucket      string       `json:"bucket"`
+	ObjectKey   string       `json:"object_key"`
+	MultipartId string       `json:"multipart_id"` // MinIO multipart upload ID
+	ContentType string       `json:"content_type"`
+	Size        int64        `json:"size"`       // faylning umumiy hajmi
+	ChunkSize   int64        `json:"chunk_size"` // oxirgisidan boshqa barcha bo'laklar hajmi
+	Offset      int64        `json:"offset"`     // shu baytgacha qabul qilingan
+	Parts       []UploadPart `json:"parts"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   time.Time    `json:"expires_at"` // har bir bo'lakdan keyin uzaytiriladi
+	Completed   bool         `json:"completed"`  // multipart upload yig'ilgan, faqat rasmni carga biriktirish qolgan
+}
+
+type UploadPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// PhotoOrder - car rasmlarining tartibi va cover rasmi (cruds.Image'da position yo'q).
+// Ro'yxatda yo'q rasmlar (masalan, keyin yuklanganlar) tartiblanganlardan keyin keladi
+type PhotoOrder struct {
+	CarId     string    `bson:"_id" json:"car_id"`
+	ImageIds  []string  `bson:"image_ids" json:"image_ids"`
+	CoverId   string    `bson:"cover_id,omitempty" json:"cover_id,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// PhotoObject - content-addressed (SHA-256) saqlangan rasm obyekti va unga havolalar soni.
+// Bir xil fayl bir necha marta yuklansa obyekt bitta bo'ladi, Refs oshadi
+type PhotoObject struct {
+	Id            string     `bson:"_id" json:"id"` // <bucket>/<key>
+	Bucket        string     `bson:"bucket" json:"bucket"`
+	Key           string     `bson:"key" json:"key"`
+	Sha256        string     `bson:"sha256" json:"sha256"`
+	Refs          int64      `bson:"refs" json:"refs"`
+	DeletingUntil *time.Time `bson:"deleting_until,omitempty" json:"deleting_until,omitempty"` // refs 0 bo'lib obyektlar o'chirilmoqda
+	WritingUntil  *time.Time `bson:"writing_until,omitempty" json:"writing_until,omitempty"`   // obyektlar yozilmoqda, yo'q bo'lsa yozib bo'lingan
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `bson:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,29 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogSender - kanal uchun provayder ulanmaguncha notificationni logga yozadi
+type LogSender struct {
+	channel string
+	log     *slog.Logger
+}
+
+func NewLogSender(channel string, log *slog.Logger) *LogSender {
+	return &LogSender{channel: channel, log: log}
+}
+
+func (s *LogSender) Channel() string {
+	return s.channel
+}
+
+func (s *LogSender) Send(ctx context.Context, n Notification) error {
+	s.log.Info("Notification sent",
+		"channel", s.channel,
+		"type", n.Type,
+		"user_id", n.UserID,
+		"title", n.Title)
+	return nil
+}
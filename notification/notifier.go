@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"wegugin/model"
+)
+
+// Notification - userga yuboriladigan xabar. Type model.NotificationType* qiymatlaridan biri
+type Notification struct {
+	UserID string
+	Type   string
+	Title  string
+	Body   string
+	Data   map[string]string
+}
+
+// Sender - bitta kanal (in_app, push, email) orqali yuborish. Real push/email provayderlari
+// shu interface'ni implement qiladi
+type Sender interface {
+	Channel() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// PreferencesStore - user notification sozlamalari (repo.INotificationPreferencesStorage)
+type PreferencesStore interface {
+	GetByUserID(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+}
+
+// Notifier - har bir kanal uchun userning sozlamalari va quiet hours'ni tekshirib yuboradi
+type Notifier struct {
+	prefs   PreferencesStore
+	senders []Sender
+	log     *slog.Logger
+	now     func() time.Time
+}
+
+func NewNotifier(prefs PreferencesStore, log *slog.Logger, senders ...Sender) *Notifier {
+	return &Notifier{prefs: prefs, senders: senders, log: log, now: time.Now}
+}
+
+// Allows - userning sozlamalari shu turdagi xabarni kanal orqali hozir yuborishga ruxsat beradimi.
+// Notifier'dan tashqarida yuboriladigan xabarlar (masalan websocket) shu tekshiruvdan o'tadi
+func (n *Notifier) Allows(ctx context.Context, userID, notificationType, channel string) (bool, error) {
+	prefs, err := n.prefs.GetByUserID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("get notification preferences: %w", err)
+	}
+	return prefs.Allows(notificationType, channel, n.now()), nil
+}
+
+// Notify - sozlamalar ruxsat bergan kanallarga yuboradi va yuborilgan kanallarni qaytaradi.
+// Bitta kanaldagi xato qolgan kanallarga yuborishni to'xtatmaydi
+func (n *Notifier) Notify(ctx context.Context, msg Notification) ([]string, error) {
+	prefs, err := n.prefs.GetByUserID(ctx, msg.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("get notification preferences: %w", err)
+	}
+
+	at := n.now()
+	var sent []string
+	var errs []error
+	for _, sender := range n.senders {
+		if !prefs.Allows(msg.Type, sender.Channel(), at) {
+			continue
+		}
+		if err := sender.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sender.Channel(), err))
+			continue
+		}
+		sent = append(sent, sender.Channel())
+	}
+	return sent, errors.Join(errs...)
+}
@@ -0,0 +1,110 @@
+package notification
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"wegugin/model"
+)
+
+type fakePrefs map[string]*model.NotificationPreferences
+
+func (f fakePrefs) GetByUserID(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	if p, ok := f[userID]; ok {
+		return p, nil
+	}
+	return model.DefaultNotificationPreferences(userID), nil
+}
+
+type recordSender struct {
+	channel string
+	sent    []Notification
+}
+
+func (s *recordSender) Channel() string { return s.channel }
+
+func (s *recordSender) Send(ctx context.Context, n Notification) error {
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func newTestNotifier(prefs fakePrefs, at time.Time) (*Notifier, map[string]*recordSender) {
+	senders := map[string]*recordSender{}
+	var list []Sender
+	for _, ch := range []string{model.NotificationChannelInApp, model.NotificationChannelPush, model.NotificationChannelEmail} {
+		senders[ch] = &recordSender{channel: ch}
+		list = append(list, senders[ch])
+	}
+	n := NewNotifier(prefs, slog.New(slog.NewTextHandler(io.Discard, nil)), list...)
+	n.now = func() time.Time { return at }
+	return n, senders
+}
+
+func TestNotifyUsesDefaults(t *testing.T) {
+	n, _ := newTestNotifier(fakePrefs{}, time.Now())
+
+	sent, err := n.Notify(context.Background(), Notification{UserID: "u1", Type: model.NotificationTypeChatMessage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{model.NotificationChannelInApp, model.NotificationChannelPush}
+	if !slices.Equal(sent, want) {
+		t.Fatalf("sent = %v, want %v", sent, want)
+	}
+}
+
+func TestNotifySkipsDisabledChannelsAndQuietHours(t *testing.T) {
+	prefs := model.DefaultNotificationPreferences("u1")
+	prefs.TopCarExpiry = model.ChannelPreferences{InApp: false, Push: true, Email: true}
+	prefs.QuietHours = model.QuietHours{Enabled: true, Start: "22:00", End: "07:00", Timezone: "UTC"}
+
+	night := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	n, senders := newTestNotifier(fakePrefs{"u1": prefs}, night)
+
+	sent, err := n.Notify(context.Background(), Notification{UserID: "u1", Type: model.NotificationTypeTopCarExpiry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("sent = %v during quiet hours with in-app disabled", sent)
+	}
+
+	n.now = func() time.Time { return night.Add(10 * time.Hour) }
+	sent, err = n.Notify(context.Background(), Notification{UserID: "u1", Type: model.NotificationTypeTopCarExpiry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{model.NotificationChannelPush, model.NotificationChannelEmail}
+	if !slices.Equal(sent, want) {
+		t.Fatalf("sent = %v, want %v", sent, want)
+	}
+	if len(senders[model.NotificationChannelInApp].sent) != 0 {
+		t.Fatal("in-app sender was called although disabled")
+	}
+}
+
+func TestAllowsUsesPreferences(t *testing.T) {
+	prefs := model.DefaultNotificationPreferences("u1")
+	prefs.ChatMessage.InApp = false
+	n, _ := newTestNotifier(fakePrefs{"u1": prefs}, time.Now())
+
+	for _, tt := range []struct {
+		user string
+		want bool
+	}{
+		{"u1", false},
+		{"u2", true}, // standart sozlamalar
+	} {
+		allowed, err := n.Allows(context.Background(), tt.user, model.NotificationTypeChatMessage, model.NotificationChannelInApp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != tt.want {
+			t.Fatalf("%s: allowed = %t, want %t", tt.user, allowed, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// LocalProvider - development va testlar uchun soxta provider.
+// Haqiqiy to'lov qilinmaydi, webhook esa Sign(secret, body) bilan imzolangan bo'lishi kerak
+type LocalProvider struct {
+	secret string
+}
+
+func NewLocalProvider(secret string) *LocalProvider {
+	return &LocalProvider{secret: secret}
+}
+
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+func (p *LocalProvider) CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error) {
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("invalid amount: %d", req.Amount)
+	}
+
+	id := uuid.NewString()
+	return &Payment{
+		ID:          id,
+		CheckoutURL: "local://checkout/" + id,
+	}, nil
+}
+
+func (p *LocalProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if !VerifySignature(p.secret, body, header.Get(SignatureHeader)) {
+		return nil, errors.New("invalid webhook signature")
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %v", err)
+	}
+	if event.PaymentID == "" {
+		return nil, errors.New("invalid webhook payload: payment_id is required")
+	}
+
+	return &event, nil
+}
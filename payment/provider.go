@@ -0,0 +1,90 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"wegugin/config"
+)
+
+// Webhook event statuslari
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// SignatureHeader - webhook body imzosi shu header'da keladi (hex HMAC-SHA256)
+const SignatureHeader = "X-Signature"
+
+type PaymentRequest struct {
+	OrderID     string // top car ID
+	UserID      string
+	Amount      int64 // minor birliklarda (cent, tiyin)
+	Currency    string
+	Description string
+}
+
+type Payment struct {
+	ID          string // provider tomonidagi payment ID
+	CheckoutURL string // user to'lov qilishi uchun sahifa (bo'lmasa bo'sh)
+}
+
+type WebhookEvent struct {
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`       // "succeeded", "failed"
+	Amount    int64  `json:"amount_minor"` // minor birliklarda (cent, tiyin)
+	Currency  string `json:"currency"`
+}
+
+// Provider - to'lov tizimi uchun interface. Har bir real provider shu interface'ni implement qiladi
+type Provider interface {
+	Name() string
+	CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error)
+	// ParseWebhook - callback imzosini tekshiradi va eventni qaytaradi
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
+
+// NewProvider - config bo'yicha provider tanlash. PAYMENT_PROVIDER berilmagan bo'lsa to'lovlar o'chirilgan:
+// nil provider qaytadi va pullik endpointlar 503 javob beradi. Webhook secret berilmagan bo'lsa yoki
+// soxta local provider dev'dan tashqarida tanlansa xato qaytadi (servis ishga tushmaydi)
+func NewProvider(cfg *config.Config) (Provider, error) {
+	if cfg.Payment.PROVIDER == "" {
+		return nil, nil
+	}
+	if cfg.Payment.WEBHOOK_SECRET == "" {
+		return nil, errors.New("PAYMENT_WEBHOOK_SECRET is not set")
+	}
+
+	switch cfg.Payment.PROVIDER {
+	case "local":
+		if cfg.Server.ENV != "dev" {
+			return nil, fmt.Errorf("payment provider %q is only allowed with APP_ENV=dev", cfg.Payment.PROVIDER)
+		}
+		return NewLocalProvider(cfg.Payment.WEBHOOK_SECRET), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", cfg.Payment.PROVIDER)
+	}
+}
+
+// Sign - body uchun hex HMAC-SHA256 imzo
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature - imzoni constant-time solishtirish
+func VerifySignature(secret string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), got)
+}
@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"net/http"
+	"testing"
+
+	"wegugin/config"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"payment_id":"p1","status":"succeeded"}`)
+	sig := Sign("secret", body)
+
+	if !VerifySignature("secret", body, sig) {
+		t.Fatal("valid signature rejected")
+	}
+	if VerifySignature("other", body, sig) {
+		t.Fatal("signature with another secret accepted")
+	}
+	if VerifySignature("secret", append(body, ' '), sig) {
+		t.Fatal("signature of modified body accepted")
+	}
+	for _, bad := range []string{"", "zz", sig[:len(sig)-2]} {
+		if VerifySignature("secret", body, bad) {
+			t.Fatalf("malformed signature %q accepted", bad)
+		}
+	}
+}
+
+func TestLocalProviderParseWebhook(t *testing.T) {
+	p := NewLocalProvider("secret")
+	signed := func(body string) http.Header {
+		h := http.Header{}
+		h.Set(SignatureHeader, Sign("secret", []byte(body)))
+		return h
+	}
+
+	body := `{"payment_id":"p1","status":"succeeded","amount_minor":2500,"currency":"USD"}`
+	event, err := p.ParseWebhook(signed(body), []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := WebhookEvent{PaymentID: "p1", Status: StatusSucceeded, Amount: 2500, Currency: "USD"}
+	if *event != want {
+		t.Fatalf("event = %+v, want %+v", *event, want)
+	}
+
+	if _, err := p.ParseWebhook(http.Header{}, []byte(body)); err == nil {
+		t.Fatal("unsigned webhook accepted")
+	}
+	if _, err := p.ParseWebhook(signed(body), []byte(`{"payment_id":"p2","status":"succeeded"}`)); err == nil {
+		t.Fatal("webhook with a signature of another body accepted")
+	}
+	for _, bad := range []string{`{"status":"succeeded"}`, `not json`} {
+		if _, err := p.ParseWebhook(signed(bad), []byte(bad)); err == nil {
+			t.Fatalf("invalid payload %q accepted", bad)
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		secret   string
+		env      string
+		wantErr  bool
+	}{
+		{name: "local in dev", provider: "local", secret: "s", env: "dev"},
+		{name: "no secret", provider: "local", env: "dev", wantErr: true},
+		{name: "local in production", provider: "local", secret: "s", env: "production", wantErr: true},
+		{name: "local without env", provider: "local", secret: "s", wantErr: true},
+		{name: "unknown provider", provider: "acme", secret: "s", env: "dev", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Payment.PROVIDER = tt.provider
+			cfg.Payment.WEBHOOK_SECRET = tt.secret
+			cfg.Server.ENV = tt.env
+
+			p, err := NewProvider(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewProvider() = %s, want error", p.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p == nil {
+				t.Fatal("NewProvider() = nil")
+			}
+			if p.Name() != tt.provider {
+				t.Fatalf("provider = %s, want %s", p.Name(), tt.provider)
+			}
+		})
+	}
+}
+
+func TestNewProviderDisabled(t *testing.T) {
+	// Provider tanlanmagan bo'lsa to'lovlar o'chirilgan, servis xatosiz ishga tushadi
+	for _, env := range []string{"", "dev", "production"} {
+		cfg := &config.Config{}
+		cfg.Server.ENV = env
+
+		p, err := NewProvider(cfg)
+		if err != nil || p != nil {
+			t.Fatalf("env %q: NewProvider() = %v, %v, want nil, nil", env, p, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package mongosh
+
+import (
+	"context"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AdminAuditRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewAdminAuditRepository(db *mongo.Database) repo.IAdminAuditStorage {
+	return &AdminAuditRepository{Coll: db.Collection("admin_audit_log")}
+}
+
+// CreateAuditLog - admin amalini yozish
+func (r *AdminAuditRepository) CreateAuditLog(ctx context.Context, entry *model.AdminAuditLog) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	result, err := r.Coll.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetAuditLogs - filter bo'yicha audit log (yangilari birinchi) va umumiy soni
+func (r *AdminAuditRepository) GetAuditLogs(ctx context.Context, filter model.AdminAuditFilter) ([]*model.AdminAuditLog, int64, error) {
+	mongoFilter := bson.M{}
+	if filter.AdminID != "" {
+		mongoFilter["admin_id"] = filter.AdminID
+	}
+	if filter.Action != "" {
+		mongoFilter["action"] = filter.Action
+	}
+	if filter.TopCarID != "" {
+		mongoFilter["top_car_id"] = filter.TopCarID
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 50 // default limit
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(filter.Limit).
+		SetSkip(filter.Skip)
+
+	cursor, err := r.Coll.Find(ctx, mongoFilter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*model.AdminAuditLog
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.Coll.CountDocuments(ctx, mongoFilter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
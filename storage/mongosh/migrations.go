@@ -0,0 +1,196 @@
+package mongosh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration - Mongo uchun bitta migration. Version o'sib boruvchi va o'zgarmas bo'lishi kerak
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+type appliedMigration struct {
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Yangi migrationlar ro'yxat oxiriga qo'shiladi, eskilari o'zgartirilmaydi.
+// Bir nechta replika bir vaqtda ishga tushganda Up ikki marta bajarilishi mumkin, shuning uchun idempotent bo'lishi kerak
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create topcars indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("topcars"), []mongo.IndexModel{
+				{Keys: bson.D{{Key: "car_id", Value: 1}, {Key: "finished_at", Value: 1}}},
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "finished_at", Value: 1}}},
+				{Keys: bson.D{{Key: "category", Value: 1}, {Key: "finished_at", Value: 1}}},
+				{Keys: bson.D{{Key: "deleted_at", Value: 1}, {Key: "finished_at", Value: 1}}},
+				{Keys: bson.D{{Key: "status", Value: 1}, {Key: "starts_at", Value: 1}}},
+				{
+					Keys: bson.D{{Key: "payment_id", Value: 1}},
+					Options: options.Index().SetUnique(true).
+						SetPartialFilterExpression(bson.M{"payment_id": bson.M{"$type": "string"}}),
+				},
+			})
+		},
+	},
+	{
+		Version:     2,
+		Description: "create topcar_stats indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("topcar_stats"), []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "top_car_id", Value: 1}, {Key: "day", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+			})
+		},
+	},
+	{
+		Version:     3,
+		Description: "create notification_preferences indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("notification_preferences"), []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+			})
+		},
+	},
+	{
+		Version:     4,
+		Description: "create topcars change payment indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Webhook reschedule/extend to'lovlarini shu maydonlar bo'yicha qidiradi
+			return createIndexes(ctx, db.Collection("topcars"), []mongo.IndexModel{
+				{Keys: bson.D{{Key: "pending_change.payment_id", Value: 1}}},
+				{Keys: bson.D{{Key: "applied_changes.payment_id", Value: 1}}},
+			})
+		},
+	},
+	{
+		Version:     5,
+		Description: "create payment_refunds indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("payment_refunds"), []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "payment_id", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+			})
+		},
+	},
+	{
+		Version:     6,
+		Description: "create admin_audit_log indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("admin_audit_log"), []mongo.IndexModel{
+				{Keys: bson.D{{Key: "created_at", Value: -1}}},
+				{Keys: bson.D{{Key: "admin_id", Value: 1}, {Key: "created_at", Value: -1}}},
+				{Keys: bson.D{{Key: "top_car_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			})
+		},
+	},
+	{
+		Version:     7,
+		Description: "create topcars analytics indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Analytics pipeline'idagi sana oraliqlari bo'yicha $match uchun
+			return createIndexes(ctx, db.Collection("topcars"), []mongo.IndexModel{
+				{Keys: bson.D{{Key: "created_at", Value: 1}}},
+				{Keys: bson.D{{Key: "starts_at", Value: 1}}},
+				{Keys: bson.D{{Key: "finished_at", Value: 1}}},
+			})
+		},
+	},
+}
+
+func createIndexes(ctx context.Context, coll *mongo.Collection, indexes []mongo.IndexModel) error {
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// PendingMigrations - hali qo'llanilmagan migrationlar (version bo'yicha tartiblangan)
+func PendingMigrations(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	cursor, err := db.Collection("schema_migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var applied []appliedMigration
+	if err = cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Version < pending[j].Version
+	})
+
+	return pending, nil
+}
+
+// RunMigrations - pending migrationlarni ketma-ket qo'llash va schema_migrations'ga yozish.
+// Qo'llanilgan migrationlar ro'yxati qaytariladi
+func RunMigrations(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	// Bir nechta instance bir vaqtda ishga tushsa bitta version ikki marta yozilmasligi uchun
+	_, err := db.Collection("schema_migrations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := m.Up(ctx, db); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %v", m.Version, m.Description, err)
+		}
+
+		_, err := db.Collection("schema_migrations").InsertOne(ctx, appliedMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now(),
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			// Boshqa replika shu migrationni bir vaqtda qo'llab ulgurgan (Up idempotent bo'lishi kerak)
+			continue
+		}
+		if err != nil {
+			return applied, fmt.Errorf("failed to record migration %d: %v", m.Version, err)
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
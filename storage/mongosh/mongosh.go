@@ -2,9 +2,11 @@ package mongosh
 
 import (
 	"context"
+	"errors"
 
 	"wegugin/config"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -26,3 +28,41 @@ func Connect(ctx context.Context) (*mongo.Database, error) {
 
 	return db, nil
 }
+
+// RequireReplicaSet - top car promotionlari transaction bilan yoziladi, transactionlar esa
+// standalone Mongo'da ishlamaydi. Replica set (bitta node bo'lsa ham) bo'lmasa xato qaytadi
+func RequireReplicaSet(ctx context.Context, db *mongo.Database) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"` // mongos uchun "isdbgrid"
+	}
+	err := db.Client().Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return err
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return errors.New("mongo must run as a replica set (transactions are required), standalone server detected")
+	}
+	return nil
+}
+
+// distinctStrings - maydonning takrorlanmas string qiymatlari
+func distinctStrings(ctx context.Context, coll *mongo.Collection, field string, filter ...bson.M) ([]string, error) {
+	query := bson.M{}
+	if len(filter) > 0 {
+		query = filter[0]
+	}
+
+	values, err := coll.Distinct(ctx, field, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok && s != "" {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package mongosh
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationPreferencesRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewNotificationPreferencesRepository(db *mongo.Database) repo.INotificationPreferencesStorage {
+	return &NotificationPreferencesRepository{Coll: db.Collection("notification_preferences")}
+}
+
+// GetByUserID - user sozlamalarini olish, agar saqlanmagan bo'lsa default qaytariladi
+func (r *NotificationPreferencesRepository) GetByUserID(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	var prefs model.NotificationPreferences
+	err := r.Coll.FindOne(ctx, bson.M{"user_id": userID}).Decode(&prefs)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.DefaultNotificationPreferences(userID), nil
+		}
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+// Upsert - user sozlamalarini yaratish yoki yangilash
+func (r *NotificationPreferencesRepository) Upsert(ctx context.Context, prefs *model.NotificationPreferences) error {
+	now := time.Now()
+	prefs.UpdatedAt = now
+
+	filter := bson.M{"user_id": prefs.UserId}
+	update := bson.M{
+		"$set": bson.M{
+			"chat_message":           prefs.ChatMessage,
+			"top_car_expiry":         prefs.TopCarExpiry,
+			"saved_car_price_change": prefs.SavedCarPriceChange,
+			"quiet_hours":            prefs.QuietHours,
+			"updated_at":             now,
+		},
+		"$setOnInsert": bson.M{
+			"created_at": now,
+		},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	return r.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(prefs)
+}
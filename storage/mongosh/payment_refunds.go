@@ -0,0 +1,98 @@
+package mongosh
+
+import (
+	"context"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PaymentRefundsRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewPaymentRefundsRepository(db *mongo.Database) repo.IPaymentRefundsStorage {
+	return &PaymentRefundsRepository{Coll: db.Collection("payment_refunds")}
+}
+
+// RequireRefund - to'lovni qaytarilishi kerak deb yozish. Webhook qayta kelganda
+// yozuv takrorlanmaydi (payment_id unique), mavjud yozuv o'zgartirilmaydi
+func (r *PaymentRefundsRepository) RequireRefund(ctx context.Context, refund *model.PaymentRefund) error {
+	if refund.CreatedAt.IsZero() {
+		refund.CreatedAt = time.Now()
+	}
+	refund.Status = model.PaymentRefundStatusRequired
+
+	_, err := r.Coll.UpdateOne(ctx,
+		bson.M{"payment_id": refund.PaymentId},
+		bson.M{"$setOnInsert": refund},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// Parallel webhook yozib ulgurgan
+		return nil
+	}
+	return err
+}
+
+// GetRefunds - status bo'yicha qaytariladigan to'lovlar (eskilari birinchi) va umumiy soni
+func (r *PaymentRefundsRepository) GetRefunds(ctx context.Context, status string, limit, skip int64) ([]*model.PaymentRefund, int64, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if limit <= 0 {
+		limit = 50 // default limit
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+
+	cursor, err := r.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var refunds []*model.PaymentRefund
+	if err = cursor.All(ctx, &refunds); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.Coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return refunds, total, nil
+}
+
+// MarkRefunded - provider orqali qaytarilgan to'lovni belgilash. Qaytarilishi kutilayotgan
+// yozuv topilmasa mongo.ErrNoDocuments qaytadi
+func (r *PaymentRefundsRepository) MarkRefunded(ctx context.Context, paymentID, adminID string, refundedAt time.Time) (*model.PaymentRefund, error) {
+	filter := bson.M{
+		"payment_id": paymentID,
+		"status":     model.PaymentRefundStatusRequired,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      model.PaymentRefundStatusRefunded,
+			"refunded_at": refundedAt,
+			"refunded_by": adminID,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var refund model.PaymentRefund
+	if err := r.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
@@ -0,0 +1,198 @@
+package mongosh
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// photoObjectDeleteTTL - refs 0 bo'lgan obyektlarni o'chirish uchun ajratilgan vaqt. Jarayon
+	// o'rtada to'xtasa shundan keyin obyekt qayta ishlatilishi (va qayta yozilishi) mumkin
+	photoObjectDeleteTTL   = time.Minute
+	photoObjectAcquireWait = 3 * time.Second
+	photoObjectRetry       = 50 * time.Millisecond
+	// photoObjectWriteTTL - obyektlarni yozish uchun ajratilgan vaqt. Yozuvchi shu vaqtda Complete
+	// chaqirmasa (jarayon to'xtagan) kutayotgan dublikat yozishni o'z zimmasiga oladi
+	photoObjectWriteTTL      = 2 * time.Minute
+	photoObjectCompleteWait  = 15 * time.Second
+	photoObjectCompleteRetry = 200 * time.Millisecond
+)
+
+type PhotoObjectsRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewPhotoObjectsRepository(db *mongo.Database) repo.IPhotoObjectsStorage {
+	return &PhotoObjectsRepository{Coll: db.Collection("photo_objects")}
+}
+
+func photoObjectID(bucket, key string) string {
+	return bucket + "/" + key
+}
+
+// Acquire - obyektga havola qo'shish. created=true bo'lsa bu birinchi havola va obyektlarni
+// chaqiruvchi yozib, keyin Complete (xato bo'lsa Abandon) chaqirishi kerak. Boshqalar yozish
+// tugashini AwaitComplete bilan kutadi. Obyekt o'chirilayotgan bo'lsa o'chirish tugashi kutiladi
+func (r *PhotoObjectsRepository) Acquire(ctx context.Context, bucket, key, hash string) (bool, error) {
+	id := photoObjectID(bucket, key)
+	deadline := time.Now().Add(photoObjectAcquireWait)
+
+	for {
+		now := time.Now()
+		// O'chirilayotgan obyekt filter'ga mos kelmaydi va upsert duplicate key xatosini beradi
+		filter := bson.M{"_id": id, "$or": bson.A{
+			bson.M{"deleting_until": bson.M{"$exists": false}},
+			bson.M{"deleting_until": bson.M{"$lt": now}},
+		}}
+		// Birinchi havola bilan bir vaqtda (atomik) yozish boshlangani belgilanadi
+		update := mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"refs":       bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$refs", 0}}, 1}},
+				"updated_at": now,
+				"bucket":     bucket,
+				"key":        key,
+				"sha256":     bson.M{"$ifNull": bson.A{"$sha256", hash}},
+				"created_at": bson.M{"$ifNull": bson.A{"$created_at", now}},
+			}}},
+			{{Key: "$set", Value: bson.M{
+				"writing_until": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$refs", 1}}, now.Add(photoObjectWriteTTL), "$writing_until"}},
+			}}},
+			{{Key: "$unset", Value: "deleting_until"}},
+		}
+		opts := options.FindOneAndUpdate().
+			SetUpsert(true).
+			SetReturnDocument(options.After)
+
+		var obj model.PhotoObject
+		err := r.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&obj)
+		if err == nil {
+			return obj.Refs == 1, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return false, err
+		}
+		if time.Now().After(deadline) {
+			return false, repo.ErrPhotoObjectBusy
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(photoObjectRetry):
+		}
+	}
+}
+
+// Release - havolani olib tashlash. true qaytsa obyektlar o'chirilishi kerak (keyin Forget):
+// bu oxirgi havola edi yoki obyekt hisobga olinmagan (dedup'dan oldin yuklangan)
+func (r *PhotoObjectsRepository) Release(ctx context.Context, bucket, key string) (bool, error) {
+	id := photoObjectID(bucket, key)
+	now := time.Now()
+
+	var obj model.PhotoObject
+	err := r.Coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "refs": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"refs": -1}, "$set": bson.M{"updated_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&obj)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, err := r.Coll.CountDocuments(ctx, bson.M{"_id": id})
+		return count == 0, err
+	}
+	if err != nil {
+		return false, err
+	}
+	if obj.Refs > 0 {
+		return false, nil
+	}
+
+	// Shu orada boshqa yuklash havola qo'shgan bo'lsa filter mos kelmaydi va obyektlar qoladi
+	res, err := r.Coll.UpdateOne(ctx,
+		bson.M{"_id": id, "refs": 0, "deleting_until": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleting_until": now.Add(photoObjectDeleteTTL)}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// Complete - obyektlar to'liq yozilganini belgilash, shundan keyin dublikatlar ularni ishlatadi
+func (r *PhotoObjectsRepository) Complete(ctx context.Context, bucket, key string) error {
+	_, err := r.Coll.UpdateOne(ctx,
+		bson.M{"_id": photoObjectID(bucket, key)},
+		bson.M{"$unset": bson.M{"writing_until": ""}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	return err
+}
+
+// Abandon - yozish muvaffaqiyatsiz tugadi: kutayotgan dublikatlardan biri yozishni o'z zimmasiga oladi
+func (r *PhotoObjectsRepository) Abandon(ctx context.Context, bucket, key string) error {
+	_, err := r.Coll.UpdateOne(ctx,
+		bson.M{"_id": photoObjectID(bucket, key), "writing_until": bson.M{"$exists": true}},
+		bson.M{"$set": bson.M{"writing_until": time.Time{}, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// AwaitComplete - obyektlar yozib bo'linishini kutish (false). Yozuvchi voz kechgan yoki vaqti tugagan
+// bo'lsa yozish chaqiruvchiga o'tadi (true, keyin Complete yoki Abandon). Kutish vaqti tugasa ErrPhotoObjectBusy
+func (r *PhotoObjectsRepository) AwaitComplete(ctx context.Context, bucket, key string) (bool, error) {
+	id := photoObjectID(bucket, key)
+	deadline := time.Now().Add(photoObjectCompleteWait)
+
+	for {
+		var obj model.PhotoObject
+		err := r.Coll.FindOne(ctx, bson.M{"_id": id}).Decode(&obj)
+		if err != nil {
+			return false, err
+		}
+		// writing_until bo'lmagan (eski) yozuvlar yozib bo'lingan hisoblanadi
+		if obj.WritingUntil == nil {
+			return false, nil
+		}
+
+		now := time.Now()
+		if obj.WritingUntil.Before(now) {
+			res, err := r.Coll.UpdateOne(ctx,
+				bson.M{"_id": id, "writing_until": obj.WritingUntil},
+				bson.M{"$set": bson.M{"writing_until": now.Add(photoObjectWriteTTL), "updated_at": now}},
+			)
+			if err != nil {
+				return false, err
+			}
+			if res.ModifiedCount == 1 {
+				return true, nil
+			}
+			// Boshqa dublikat yozishni olib ulgurgan
+		}
+		if now.After(deadline) {
+			return false, repo.ErrPhotoObjectBusy
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(photoObjectCompleteRetry):
+		}
+	}
+}
+
+// Forget - obyektlar o'chirilgandan keyin yozuvni olib tashlash
+func (r *PhotoObjectsRepository) Forget(ctx context.Context, bucket, key string) error {
+	_, err := r.Coll.DeleteOne(ctx, bson.M{"_id": photoObjectID(bucket, key), "refs": 0})
+	return err
+}
+
+// ReferencedKeys - bucketdagi havolasi bor (refs > 0) obyektlar kalitlari
+func (r *PhotoObjectsRepository) ReferencedKeys(ctx context.Context, bucket string) ([]string, error) {
+	return distinctStrings(ctx, r.Coll, "key", bson.M{"bucket": bucket, "refs": bson.M{"$gt": 0}})
+}
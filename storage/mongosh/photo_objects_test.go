@@ -0,0 +1,37 @@
+package mongosh
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPhotoObjectDuplicateWaitsForWriter(t *testing.T) {
+	r := NewPhotoObjectsRepository(testDB(t))
+	ctx := context.Background()
+
+	created, err := r.Acquire(ctx, "photos", "a_original.jpg", "a")
+	if err != nil || !created {
+		t.Fatalf("first Acquire = %v, %v, want created", created, err)
+	}
+	created, err = r.Acquire(ctx, "photos", "a_original.jpg", "a")
+	if err != nil || created {
+		t.Fatalf("second Acquire = %v, %v, want a duplicate", created, err)
+	}
+
+	// Yozuvchi voz kechsa yozish dublikatga o'tadi
+	if err := r.Abandon(ctx, "photos", "a_original.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := r.AwaitComplete(ctx, "photos", "a_original.jpg")
+	if err != nil || !claimed {
+		t.Fatalf("AwaitComplete = %v, %v, want the write claimed", claimed, err)
+	}
+
+	if err := r.Complete(ctx, "photos", "a_original.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err = r.AwaitComplete(ctx, "photos", "a_original.jpg")
+	if err != nil || claimed {
+		t.Fatalf("AwaitComplete after Complete = %v, %v, want complete", claimed, err)
+	}
+}
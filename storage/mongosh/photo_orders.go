@@ -0,0 +1,121 @@
+package mongosh
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PhotoOrdersRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewPhotoOrdersRepository(db *mongo.Database) repo.IPhotoOrdersStorage {
+	return &PhotoOrdersRepository{Coll: db.Collection("photo_orders")}
+}
+
+// GetByCarID - car rasmlari tartibi, saqlanmagan bo'lsa bo'sh tartib qaytariladi
+func (r *PhotoOrdersRepository) GetByCarID(ctx context.Context, carID string) (*model.PhotoOrder, error) {
+	var order model.PhotoOrder
+	err := r.Coll.FindOne(ctx, bson.M{"_id": carID}).Decode(&order)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &model.PhotoOrder{CarId: carID}, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// GetByCarIDs - bir nechta car uchun tartiblar (saqlanmaganlari natijada bo'lmaydi)
+func (r *PhotoOrdersRepository) GetByCarIDs(ctx context.Context, carIDs []string) (map[string]*model.PhotoOrder, error) {
+	result := make(map[string]*model.PhotoOrder, len(carIDs))
+	if len(carIDs) == 0 {
+		return result, nil
+	}
+
+	cursor, err := r.Coll.Find(ctx, bson.M{"_id": bson.M{"$in": carIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var order model.PhotoOrder
+		if err := cursor.Decode(&order); err != nil {
+			return nil, err
+		}
+		result[order.CarId] = &order
+	}
+
+	return result, cursor.Err()
+}
+
+// SetOrder - rasmlar tartibini almashtirish
+func (r *PhotoOrdersRepository) SetOrder(ctx context.Context, carID string, imageIDs []string) (*model.PhotoOrder, error) {
+	return r.upsert(ctx, carID, bson.M{"$set": bson.M{
+		"image_ids":  imageIDs,
+		"updated_at": time.Now(),
+	}})
+}
+
+// SetCover - cover rasmini belgilash
+func (r *PhotoOrdersRepository) SetCover(ctx context.Context, carID, imageID string) (*model.PhotoOrder, error) {
+	return r.upsert(ctx, carID, bson.M{
+		"$set": bson.M{
+			"cover_id":   imageID,
+			"updated_at": time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"image_ids": bson.A{},
+		},
+	})
+}
+
+// RemoveImage - o'chirilgan rasmni tartibdan chiqarish, cover bo'lsa cover ham olib tashlanadi
+func (r *PhotoOrdersRepository) RemoveImage(ctx context.Context, carID, imageID string) error {
+	now := time.Now()
+	_, err := r.Coll.UpdateOne(ctx, bson.M{"_id": carID}, bson.M{
+		"$pull": bson.M{"image_ids": imageID},
+		"$set":  bson.M{"updated_at": now},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Coll.UpdateOne(ctx, bson.M{"_id": carID, "cover_id": imageID}, bson.M{
+		"$unset": bson.M{"cover_id": ""},
+		"$set":   bson.M{"updated_at": now},
+	})
+	return err
+}
+
+func (r *PhotoOrdersRepository) DeleteByCarID(ctx context.Context, carID string) error {
+	_, err := r.Coll.DeleteOne(ctx, bson.M{"_id": carID})
+	return err
+}
+
+// GetCarIDs - rasm tartibi saqlangan barcha carlar
+func (r *PhotoOrdersRepository) GetCarIDs(ctx context.Context) ([]string, error) {
+	return distinctStrings(ctx, r.Coll, "_id")
+}
+
+func (r *PhotoOrdersRepository) upsert(ctx context.Context, carID string, update bson.M) (*model.PhotoOrder, error) {
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var order model.PhotoOrder
+	if err := r.Coll.FindOneAndUpdate(ctx, bson.M{"_id": carID}, update, opts).Decode(&order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
@@ -0,0 +1,28 @@
+package mongosh
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestPhotoOrdersGetCarIDs(t *testing.T) {
+	r := NewPhotoOrdersRepository(testDB(t))
+	ctx := context.Background()
+
+	if _, err := r.SetOrder(ctx, "car-1", []string{"img-1", "img-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SetCover(ctx, "car-2", "img-3"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetCarIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "car-1" || got[1] != "car-2" {
+		t.Fatalf("car ids = %v, want [car-1 car-2]", got)
+	}
+}
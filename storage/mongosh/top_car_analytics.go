@@ -0,0 +1,208 @@
+package mongosh
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"wegugin/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Interval bo'yicha $dateToString formati (hafta ISO 8601 bo'yicha)
+var analyticsPeriodFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V",
+	"month": "%Y-%m",
+}
+
+type analyticsGroup struct {
+	ID struct {
+		Period   string `bson:"period"`
+		Category string `bson:"category"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+type analyticsFacets struct {
+	Created    []analyticsGroup     `bson:"created"`
+	Active     []analyticsGroup     `bson:"active"`
+	Expired    []analyticsGroup     `bson:"expired"`
+	Deleted    []analyticsGroup     `bson:"deleted"`
+	TopSellers []model.TopCarSeller `bson:"top_sellers"`
+}
+
+// GetTopCarAnalytics - [From, To) oralig'idagi promotionlar hisoboti. Bitta aggregation'da
+// $facet orqali har bir hisoblagich o'z sana maydoni bo'yicha period va category'ga guruhlanadi
+func (r *TopCarsRepository) GetTopCarAnalytics(ctx context.Context, filter model.TopCarAnalyticsFilter) (*model.TopCarAnalytics, error) {
+	format, ok := analyticsPeriodFormats[filter.Interval]
+	if !ok {
+		format = analyticsPeriodFormats["day"]
+	}
+	timezone := filter.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if filter.TopSellersLimit <= 0 {
+		filter.TopSellersLimit = 10
+	}
+
+	inRange := bson.M{"$gte": filter.From, "$lt": filter.To}
+
+	// Muddati tugashi kelajakda bo'lganlar hali expired emas
+	expiredTo := filter.To
+	if now := time.Now(); now.Before(expiredTo) {
+		expiredTo = now
+	}
+
+	// status maydoni qo'shilishidan oldingi yozuvlar bepul yaratilgan va active hisoblanadi
+	ranStatuses := bson.A{model.TopCarStatusActive, nil}
+
+	groupBy := func(field string) bson.D {
+		return bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"period": bson.M{"$dateToString": bson.M{
+					"format":   format,
+					"date":     "$" + field,
+					"timezone": timezone,
+				}},
+				"category": "$category",
+			},
+			"count": bson.M{"$sum": 1},
+		}}}
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"created_at": inRange},
+			bson.M{"starts_at": inRange},
+			bson.M{"finished_at": inRange},
+			bson.M{"deleted_at": inRange},
+		}}}},
+		// starts_at maydoni qo'shilishidan oldingi yozuvlar created_at vaqtida boshlangan
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"effective_starts_at": bson.M{"$ifNull": bson.A{"$starts_at", "$created_at"}},
+		}}},
+		bson.D{{Key: "$facet", Value: bson.M{
+			"created": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{"created_at": inRange}}},
+				groupBy("created_at"),
+			},
+			"active": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{
+					"effective_starts_at": inRange,
+					"status":              bson.M{"$in": ranStatuses},
+				}}},
+				groupBy("effective_starts_at"),
+			},
+			"expired": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{
+					"finished_at": bson.M{"$gte": filter.From, "$lt": expiredTo},
+					"status":      bson.M{"$in": ranStatuses},
+					// Cleanup job muddati tugaganlarni ham soft delete qiladi, ular expired hisoblanadi
+					"$expr": bson.M{"$or": bson.A{
+						bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$deleted_at", nil}}, nil}},
+						bson.M{"$lte": bson.A{"$finished_at", "$deleted_at"}},
+					}},
+				}}},
+				groupBy("finished_at"),
+			},
+			"deleted": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{
+					"deleted_at": inRange,
+					"$expr":      bson.M{"$gt": bson.A{"$finished_at", "$deleted_at"}},
+				}}},
+				groupBy("deleted_at"),
+			},
+			"top_sellers": bson.A{
+				bson.D{{Key: "$match", Value: bson.M{
+					"created_at": inRange,
+					"status":     bson.M{"$in": bson.A{model.TopCarStatusPaid, model.TopCarStatusActive, nil}},
+				}}},
+				bson.D{{Key: "$group", Value: bson.M{
+					"_id":          "$user_id",
+					"promotions":   bson.M{"$sum": 1},
+					"amount_minor": bson.M{"$sum": "$amount_minor"},
+				}}},
+				bson.D{{Key: "$sort", Value: bson.D{
+					{Key: "promotions", Value: -1},
+					{Key: "amount_minor", Value: -1},
+					{Key: "_id", Value: 1},
+				}}},
+				bson.D{{Key: "$limit", Value: filter.TopSellersLimit}},
+			},
+		}}},
+	}
+
+	cursor, err := r.Coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var facets []analyticsFacets
+	if err = cursor.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+
+	result := &model.TopCarAnalytics{
+		Series:     []model.TopCarAnalyticsBucket{},
+		Totals:     []model.TopCarAnalyticsBucket{},
+		TopSellers: []model.TopCarSeller{},
+	}
+	if len(facets) == 0 {
+		return result, nil
+	}
+	f := facets[0]
+
+	type key struct{ period, category string }
+	series := make(map[key]*model.TopCarAnalyticsBucket)
+	totals := make(map[string]*model.TopCarAnalyticsBucket)
+
+	add := func(groups []analyticsGroup, counter func(b *model.TopCarAnalyticsBucket) *int64) {
+		for _, g := range groups {
+			k := key{g.ID.Period, g.ID.Category}
+			b, ok := series[k]
+			if !ok {
+				b = &model.TopCarAnalyticsBucket{Period: k.period, Category: k.category}
+				series[k] = b
+			}
+			*counter(b) += g.Count
+
+			t, ok := totals[k.category]
+			if !ok {
+				t = &model.TopCarAnalyticsBucket{Category: k.category}
+				totals[k.category] = t
+			}
+			*counter(t) += g.Count
+		}
+	}
+	add(f.Created, func(b *model.TopCarAnalyticsBucket) *int64 { return &b.Created })
+	add(f.Active, func(b *model.TopCarAnalyticsBucket) *int64 { return &b.Active })
+	add(f.Expired, func(b *model.TopCarAnalyticsBucket) *int64 { return &b.Expired })
+	add(f.Deleted, func(b *model.TopCarAnalyticsBucket) *int64 { return &b.Deleted })
+
+	for _, b := range series {
+		result.Series = append(result.Series, *b)
+	}
+	sort.Slice(result.Series, func(i, j int) bool {
+		if result.Series[i].Period != result.Series[j].Period {
+			return result.Series[i].Period < result.Series[j].Period
+		}
+		return result.Series[i].Category < result.Series[j].Category
+	})
+
+	for _, t := range totals {
+		result.Totals = append(result.Totals, *t)
+	}
+	sort.Slice(result.Totals, func(i, j int) bool {
+		return result.Totals[i].Category < result.Totals[j].Category
+	})
+
+	if f.TopSellers != nil {
+		result.TopSellers = f.TopSellers
+	}
+
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package mongosh
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TopCarStatsRepository struct {
+	Coll *mongo.Collection
+}
+
+func NewTopCarStatsRepository(db *mongo.Database) repo.ITopCarStatsStorage {
+	return &TopCarStatsRepository{Coll: db.Collection("topcar_stats")}
+}
+
+// AddDailyStats - kunlik hisoblagichlarga qo'shish (har bir promotion va kun uchun bitta document).
+// Xato bo'lsa yozilmagan hisoblagichlar qaytadi: unordered bulk write'da qolganlari yozilgan bo'ladi,
+// shuning uchun qayta qo'shilsa ikki marta hisoblanadi. Qaysilari yozilgani noma'lum bo'lsa hammasi qaytadi
+func (r *TopCarStatsRepository) AddDailyStats(ctx context.Context, stats []model.TopCarDailyStats) ([]model.TopCarDailyStats, error) {
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(stats))
+	for _, st := range stats {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"top_car_id": st.TopCarId, "day": st.Day}).
+			SetUpdate(bson.M{"$inc": bson.M{
+				"impressions": st.Impressions,
+				"clicks":      st.Clicks,
+			}}).
+			SetUpsert(true))
+	}
+
+	_, err := r.Coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+		return stats, err
+	}
+	failed := make([]model.TopCarDailyStats, 0, len(bulkErr.WriteErrors))
+	for _, we := range bulkErr.WriteErrors {
+		if we.Index >= 0 && we.Index < len(stats) {
+			failed = append(failed, stats[we.Index])
+		}
+	}
+	return failed, err
+}
+
+// GetDailyStats - [from, to] oralig'idagi kunlik statistikani olish
+func (r *TopCarStatsRepository) GetDailyStats(ctx context.Context, topCarID string, from, to time.Time) ([]model.TopCarDailyStats, error) {
+	filter := bson.M{
+		"top_car_id": topCarID,
+		"day":        bson.M{"$gte": from, "$lte": to},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "day", Value: 1}})
+
+	cursor, err := r.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var stats []model.TopCarDailyStats
+	if err = cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
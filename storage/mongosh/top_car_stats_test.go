@@ -0,0 +1,49 @@
+package mongosh
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"wegugin/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestAddDailyStatsReturnsOnlyFailedWrites(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	// Manfiy hisoblagichni rad etadigan validator bilan bitta yozuvni yiqitamiz
+	err := db.CreateCollection(ctx, "topcar_stats", options.CreateCollection().
+		SetValidator(bson.M{"clicks": bson.M{"$gte": 0}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewTopCarStatsRepository(db)
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	stats := []model.TopCarDailyStats{
+		{TopCarId: "a", Day: day, Impressions: 5, Clicks: 1},
+		{TopCarId: "b", Day: day, Impressions: 3, Clicks: -1},
+		{TopCarId: "c", Day: day, Impressions: 2, Clicks: 0},
+	}
+
+	failed, err := r.AddDailyStats(ctx, stats)
+	if err == nil {
+		t.Fatal("AddDailyStats succeeded with an invalid write")
+	}
+	if len(failed) != 1 || failed[0].TopCarId != "b" {
+		t.Fatalf("failed = %+v, want only b", failed)
+	}
+
+	for _, id := range []string{"a", "c"} {
+		got, err := r.GetDailyStats(ctx, id, day, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("%s: %d daily stats written, want 1", id, len(got))
+		}
+	}
+}
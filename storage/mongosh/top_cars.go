@@ -3,6 +3,9 @@ package mongosh
 import (
 	"context"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
 	"time"
 
 	"wegugin/model"
@@ -12,101 +15,214 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
+// carTxTimeout - car transaction'i write conflict sababli shu vaqt ichida qayta urinib ko'riladi,
+// keyin repo.ErrTopCarBusy qaytadi
+const carTxTimeout = 5 * time.Second
+
 type TopCarsRepository struct {
-	Coll *mongo.Collection
+	Coll   *mongo.Collection
+	Guards *mongo.Collection
 }
 
 func NewTopCarsRepository(db *mongo.Database) repo.ITopCarsStorage {
-	return &TopCarsRepository{Coll: db.Collection("topcars")}
+	return &TopCarsRepository{
+		Coll:   db.Collection("topcars"),
+		Guards: db.Collection("topcar_guards"),
+	}
 }
 
-func (r *TopCarsRepository) CreateTopCar(ctx context.Context, topCar *model.TopCars) error {
-	// Avval shu car allaqachon active top carlar orasida borligini tekshirish
-	existsFilter := bson.M{
-		"car_id":      topCar.CarId,
-		"finished_at": bson.M{"$gt": time.Now()}, // hali muddati o'tmagan
-		"deleted_at":  nil,                       // o'chirilmagan
+// inCarTransaction - car promotionlarini bitta transaction ichida o'zgartirish (replica set kerak).
+// Transaction avval carning guard hujjatini (topcar_guards'da _id = carID) yangilaydi: shu car uchun
+// parallel transactionlar bitta hujjatga yozgani uchun bittasi write conflict oladi va driver uni
+// boshidan qayta bajaradi. Shuning uchun fn ichidagi overlap tekshiruvi va yozish orasida boshqa
+// so'rov shu car uchun promotion yoza olmaydi. fn qaytargan xato transaction'ni bekor qiladi
+func (r *TopCarsRepository) inCarTransaction(ctx context.Context, carID string, fn func(sc mongo.SessionContext) error) error {
+	return r.inGuardTransaction(ctx, carID, fn)
+}
+
+// inCategoryTransaction - category active promotionlari sonini o'zgartirish (slotlar cheklovi uchun).
+// Guard hujjati "category:<category>", shuning uchun bir category uchun sanash va active qilish ketma-ket bajariladi
+func (r *TopCarsRepository) inCategoryTransaction(ctx context.Context, category string, fn func(sc mongo.SessionContext) error) error {
+	return r.inGuardTransaction(ctx, "category:"+category, fn)
+}
+
+// inGuardTransaction - fn'ni topcar_guards'dagi guardID hujjatini yangilaydigan transaction ichida bajarish
+func (r *TopCarsRepository) inGuardTransaction(ctx context.Context, guardID string, fn func(sc mongo.SessionContext) error) error {
+	// Guard transactiondan tashqarida yaratiladi: transaction ichidagi parallel upsertlar
+	// qayta urinilmaydigan duplicate key xatosini berishi mumkin
+	_, err := r.Guards.UpdateOne(ctx,
+		bson.M{"_id": guardID},
+		bson.M{"$setOnInsert": bson.M{"version": 0}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
 	}
 
-	count, err := r.Coll.CountDocuments(ctx, existsFilter)
+	session, err := r.Coll.Database().Client().StartSession()
 	if err != nil {
 		return err
 	}
+	defer session.EndSession(context.Background())
+
+	txCtx, cancel := context.WithTimeout(ctx, carTxTimeout)
+	defer cancel()
 
-	if count > 0 {
-		return errors.New("topCar already exists in this category and is still active")
+	opts := options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+	_, err = session.WithTransaction(txCtx, func(sc mongo.SessionContext) (interface{}, error) {
+		_, err := r.Guards.UpdateOne(sc, bson.M{"_id": guardID}, bson.M{"$inc": bson.M{"version": 1}})
+		if err != nil {
+			return nil, err
+		}
+		return nil, fn(sc)
+	}, opts)
+
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorLabel("TransientTransactionError") {
+		return repo.ErrTopCarBusy
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return repo.ErrTopCarOverlap
 	}
+	return err
+}
 
+func (r *TopCarsRepository) CreateTopCar(ctx context.Context, topCar *model.TopCars) error {
 	if topCar.CreatedAt.IsZero() {
 		topCar.CreatedAt = time.Now()
 	}
+	if topCar.StartsAt.IsZero() {
+		topCar.StartsAt = topCar.CreatedAt
+	}
+
+	// Transaction qayta bajarilganda ham bir xil ID bilan yoziladi
+	id := topCar.ID
+	if id.IsZero() {
+		id = primitive.NewObjectID()
+	}
+	doc := *topCar
+	doc.ID = id
+
+	err := r.inCarTransaction(ctx, topCar.CarId, func(sc mongo.SessionContext) error {
+		// Shu car uchun vaqt oralig'i ustma-ust tushadigan promotion borligini tekshirish
+		exists, err := r.HasOverlappingTopCar(sc, topCar.CarId, topCar.StartsAt, topCar.FinishedAt, primitive.NilObjectID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return repo.ErrTopCarOverlap
+		}
 
-	result, err := r.Coll.InsertOne(ctx, topCar)
+		_, err = r.Coll.InsertOne(sc, doc)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	topCar.ID = result.InsertedID.(primitive.ObjectID)
+	topCar.ID = id
 	return nil
 }
 
-// GetListOfTopCars - filterlar bilan top carlar ro'yxatini olish
-func (r *TopCarsRepository) GetListOfTopCars(ctx context.Context, filter model.TopCarsFilter) ([]*model.TopCars, error) {
-	// Filter yaratish
-	mongoFilter := bson.M{}
-
-	// Deleted itemlarni ko'rsatmaslik (agar show_deleted false bo'lsa)
-	if !filter.ShowDeleted {
-		mongoFilter["deleted_at"] = nil
+// HasOverlappingTopCar - car uchun [startsAt, finishedAt) oralig'i bilan ustma-ust tushadigan
+// o'chirilmagan promotion borligini tekshirish (excludeID hisobga olinmaydi)
+func (r *TopCarsRepository) HasOverlappingTopCar(ctx context.Context, carID string, startsAt, finishedAt time.Time, excludeID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"car_id":      carID,
+		"finished_at": bson.M{"$gt": startsAt},              // berilgan oraliq boshlanishidan keyin tugaydi
+		"deleted_at":  nil,                                  // o'chirilmagan
+		"$or":         startsAtCondition("$lt", finishedAt), // berilgan oraliq tugashidan oldin boshlanadi
 	}
-
-	// Expired itemlarni ko'rsatmaslik (agar show_expired false bo'lsa)
-	if !filter.ShowExpired {
-		mongoFilter["finished_at"] = bson.M{"$gt": time.Now()}
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
 	}
 
-	// Category filter
-	if filter.Category != "" {
-		mongoFilter["category"] = filter.Category
+	count, err := r.Coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
 	}
 
-	// User ID filter
-	if filter.UserID != "" {
-		mongoFilter["user_id"] = filter.UserID
+	return count > 0, nil
+}
+
+// firstOverlapping - [startsAt, finishedAt) bilan ustma-ust tushadigan eng erta boshlanuvchi
+// o'chirilmagan promotion (excludeID hisobga olinmaydi), bo'lmasa nil
+func (r *TopCarsRepository) firstOverlapping(ctx context.Context, carID string, startsAt, finishedAt time.Time, excludeID primitive.ObjectID) (*model.TopCars, error) {
+	filter := bson.M{
+		"_id":         bson.M{"$ne": excludeID},
+		"car_id":      carID,
+		"finished_at": bson.M{"$gt": startsAt},
+		"deleted_at":  nil,
+		"$or":         startsAtCondition("$lt", finishedAt),
 	}
 
-	// Car ID filter
-	if filter.CarID != "" {
-		mongoFilter["car_id"] = filter.CarID
+	var topCar model.TopCars
+	opts := options.FindOne().SetSort(bson.D{{Key: "starts_at", Value: 1}})
+	err := r.Coll.FindOne(ctx, filter, opts).Decode(&topCar)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if topCar.StartsAt.IsZero() {
+		topCar.StartsAt = topCar.CreatedAt
 	}
 
-	// Sort options
-	var sort bson.D
-	switch filter.SortBy {
-	case "finished_at_asc":
-		sort = bson.D{{Key: "finished_at", Value: 1}}
-	case "finished_at_desc":
-		sort = bson.D{{Key: "finished_at", Value: -1}}
-	case "created_at_asc":
-		sort = bson.D{{Key: "created_at", Value: 1}}
-	case "created_at_desc":
-		sort = bson.D{{Key: "created_at", Value: -1}}
-	default:
-		// Default: muddati tezroq tugaydiganlar birinchi
-		sort = bson.D{{Key: "finished_at", Value: 1}}
+	return &topCar, nil
+}
+
+// startsAtCondition - starts_at bo'yicha shart ("$lt", "$lte", ...).
+// starts_at maydoni qo'shilishidan oldingi yozuvlar created_at vaqtida boshlangan.
+func startsAtCondition(op string, t time.Time) bson.A {
+	return bson.A{
+		bson.M{"starts_at": bson.M{op: t}},
+		bson.M{"starts_at": bson.M{"$exists": false}, "created_at": bson.M{op: t}},
 	}
+}
+
+// GetListOfTopCars - filterlar bilan top carlar ro'yxatini olish
+func (r *TopCarsRepository) GetListOfTopCars(ctx context.Context, filter model.TopCarsFilter) ([]*model.TopCars, error) {
+	// Filter yaratish
+	mongoFilter := buildTopCarsFilter(filter)
 
 	// Limit va Skip
 	if filter.Limit == 0 {
 		filter.Limit = 50 // default limit
 	}
 
+	if filter.SortBy == "rotation" {
+		return r.getRotatedTopCars(ctx, mongoFilter, filter)
+	}
+
+	// Sort options, bir xil qiymatlar uchun _id bo'yicha barqaror tartib
+	field, direction := filter.SortField()
+	sort := bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+
 	opts := options.Find().
 		SetSort(sort).
-		SetLimit(filter.Limit).
-		SetSkip(filter.Skip)
+		SetLimit(filter.Limit)
+
+	// Cursor berilgan bo'lsa oxirgi elementdan keyingilar olinadi, aks holda skip
+	if filter.After != nil {
+		op := "$gt"
+		if direction < 0 {
+			op = "$lt"
+		}
+		and, _ := mongoFilter["$and"].(bson.A)
+		mongoFilter["$and"] = append(and, bson.M{"$or": bson.A{
+			bson.M{field: bson.M{op: filter.After.Value}},
+			bson.M{field: filter.After.Value, "_id": bson.M{op: filter.After.ID}},
+		}})
+	} else {
+		opts.SetSkip(filter.Skip)
+	}
 
 	cursor, err := r.Coll.Find(ctx, mongoFilter, opts)
 	if err != nil {
@@ -122,11 +238,48 @@ func (r *TopCarsRepository) GetListOfTopCars(ctx context.Context, filter model.T
 	return topCars, nil
 }
 
+// getRotatedTopCars - barcha mos promotionlarni RotationSeed bo'yicha aralashtirib qaytarish.
+// Seed bir vaqt oralig'ida o'zgarmagani uchun pagination barqaror, oraliq almashganda tartib ham almashadi.
+// Active promotionlar soni slotlar bilan cheklangani uchun hammasini xotirada saralash mumkin
+func (r *TopCarsRepository) getRotatedTopCars(ctx context.Context, mongoFilter bson.M, filter model.TopCarsFilter) ([]*model.TopCars, error) {
+	cursor, err := r.Coll.Find(ctx, mongoFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var topCars []*model.TopCars
+	if err = cursor.All(ctx, &topCars); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[primitive.ObjectID]uint64, len(topCars))
+	for _, topCar := range topCars {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%d:%s", filter.RotationSeed, topCar.ID.Hex())
+		keys[topCar.ID] = h.Sum64()
+	}
+	sort.Slice(topCars, func(i, j int) bool {
+		return keys[topCars[i].ID] < keys[topCars[j].ID]
+	})
+
+	if filter.Skip >= int64(len(topCars)) {
+		return []*model.TopCars{}, nil
+	}
+	end := filter.Skip + filter.Limit
+	if end > int64(len(topCars)) {
+		end = int64(len(topCars))
+	}
+
+	return topCars[filter.Skip:end], nil
+}
+
 // DeleteFinishedTopCars - muddati o'tgan top carlarni o'chirish
 func (r *TopCarsRepository) DeleteFinishedTopCars(ctx context.Context) (int64, error) {
 	filter := bson.M{
 		"finished_at": bson.M{"$lt": time.Now()},
 		"deleted_at":  nil,
+		"status":      bson.M{"$ne": model.TopCarStatusPaid}, // waitlistdagilar active bo'lganda muddati suriladi
 	}
 
 	update := bson.M{
@@ -143,6 +296,35 @@ func (r *TopCarsRepository) DeleteFinishedTopCars(ctx context.Context) (int64, e
 	return result.ModifiedCount, nil
 }
 
+// ClaimExpiryNotification - muddati tugagan, lekin egasiga hali xabar berilmagan bitta promotionni
+// belgilab qaytaradi (bir nechta replika bir xil promotion uchun ikki marta yubormasligi uchun).
+// Qolmagan bo'lsa nil qaytadi. Egasi yoki admin muddatidan oldin o'chirganlari hisobga olinmaydi
+func (r *TopCarsRepository) ClaimExpiryNotification(ctx context.Context, finishedAfter time.Time) (*model.TopCars, error) {
+	now := time.Now()
+	filter := bson.M{
+		"finished_at":        bson.M{"$lt": now, "$gte": finishedAfter},
+		"status":             model.TopCarStatusActive,
+		"expiry_notified_at": nil,
+		"delete_reason":      bson.M{"$exists": false},
+		"$expr": bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$deleted_at", nil}}, nil}},
+			bson.M{"$gte": bson.A{"$deleted_at", "$finished_at"}},
+		}},
+	}
+	update := bson.M{"$set": bson.M{"expiry_notified_at": now}}
+
+	var topCar model.TopCars
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&topCar)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &topCar, nil
+}
+
 // DeleteByID - ID bo'yicha o'chirish
 func (r *TopCarsRepository) DeleteByID(ctx context.Context, id primitive.ObjectID) error {
 	filter := bson.M{
@@ -218,6 +400,22 @@ func (r *TopCarsRepository) GetByID(ctx context.Context, id primitive.ObjectID)
 	return &topCar, nil
 }
 
+// GetDeletedByID - soft delete qilingan top carni ID bo'yicha olish
+func (r *TopCarsRepository) GetDeletedByID(ctx context.Context, id primitive.ObjectID) (*model.TopCars, error) {
+	filter := bson.M{
+		"_id":        id,
+		"deleted_at": bson.M{"$ne": nil},
+	}
+
+	var topCar model.TopCars
+	err := r.Coll.FindOne(ctx, filter).Decode(&topCar)
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
 // UpdateTopCar - top carni yangilash
 func (r *TopCarsRepository) UpdateTopCar(ctx context.Context, id primitive.ObjectID, updateData bson.M) error {
 	filter := bson.M{
@@ -235,27 +433,603 @@ func (r *TopCarsRepository) UpdateTopCar(ctx context.Context, id primitive.Objec
 
 // CountTopCars - top carlar sonini hisoblash
 func (r *TopCarsRepository) CountTopCars(ctx context.Context, filter model.TopCarsFilter) (int64, error) {
+	return r.Coll.CountDocuments(ctx, buildTopCarsFilter(filter))
+}
+
+// buildTopCarsFilter - GetListOfTopCars va CountTopCars uchun umumiy mongo filter
+func buildTopCarsFilter(filter model.TopCarsFilter) bson.M {
 	mongoFilter := bson.M{}
+	var and bson.A
+	now := time.Now()
 
+	// Deleted itemlarni ko'rsatmaslik (agar show_deleted false bo'lsa)
 	if !filter.ShowDeleted {
 		mongoFilter["deleted_at"] = nil
 	}
 
+	// Expired itemlarni ko'rsatmaslik (agar show_expired false bo'lsa)
 	if !filter.ShowExpired {
-		mongoFilter["finished_at"] = bson.M{"$gt": time.Now()}
+		mongoFilter["finished_at"] = bson.M{"$gt": now}
+	}
+
+	// Hali boshlanmagan itemlarni ko'rsatmaslik (agar show_scheduled false bo'lsa).
+	// paid statusdagi promotionlar starts_at yoki bo'sh slot kutmoqda (waitlist)
+	if !filter.ShowScheduled {
+		and = append(and, bson.M{"$or": startsAtCondition("$lte", now)})
+		mongoFilter["status"] = bson.M{"$ne": model.TopCarStatusPaid}
+	}
+
+	// To'lanmagan itemlarni ko'rsatmaslik (agar show_unpaid false bo'lsa).
+	// status maydoni qo'shilishidan oldingi yozuvlar bepul yaratilgan va active hisoblanadi
+	if !filter.ShowUnpaid {
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"status": bson.M{"$in": bson.A{model.TopCarStatusPaid, model.TopCarStatusActive}}},
+			bson.M{"status": bson.M{"$exists": false}},
+		}})
 	}
 
+	// Category filter
 	if filter.Category != "" {
 		mongoFilter["category"] = filter.Category
 	}
 
+	// User ID filter
 	if filter.UserID != "" {
 		mongoFilter["user_id"] = filter.UserID
 	}
 
+	// Car ID filter
 	if filter.CarID != "" {
 		mongoFilter["car_id"] = filter.CarID
 	}
 
-	return r.Coll.CountDocuments(ctx, mongoFilter)
+	if len(and) > 0 {
+		mongoFilter["$and"] = and
+	}
+
+	return mongoFilter
+}
+
+// ExtendTopCar - promotion muddatini uzaytirish va tarixga yozish.
+// Uzaytirilgan qism shu carning boshqa promotioni bilan ustma-ust tushsa repo.ErrTopCarOverlap qaytadi.
+// finished_at o'zgarmagan va o'chirilmagan bo'lsagina yangilanadi, aks holda mongo.ErrNoDocuments qaytadi
+func (r *TopCarsRepository) ExtendTopCar(ctx context.Context, id primitive.ObjectID, extension model.TopCarExtension) (*model.TopCars, error) {
+	current, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"_id":         id,
+		"deleted_at":  nil,
+		"finished_at": extension.PreviousFinishedAt,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"finished_at": extension.FinishedAt,
+		},
+		"$push": bson.M{
+			"extensions": extension,
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var topCar model.TopCars
+	err = r.inCarTransaction(ctx, current.CarId, func(sc mongo.SessionContext) error {
+		overlaps, err := r.HasOverlappingTopCar(sc, current.CarId, extension.PreviousFinishedAt, extension.FinishedAt, id)
+		if err != nil {
+			return err
+		}
+		if overlaps {
+			return repo.ErrTopCarOverlap
+		}
+
+		return r.Coll.FindOneAndUpdate(sc, filter, update, opts).Decode(&topCar)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
+// RescheduleTopCar - promotion category va vaqt oralig'ini o'zgartirish.
+// Yangi oraliq shu carning boshqa promotioni bilan ustma-ust tushsa repo.ErrTopCarOverlap qaytadi
+func (r *TopCarsRepository) RescheduleTopCar(ctx context.Context, id primitive.ObjectID, category string, startsAt, finishedAt time.Time) (*model.TopCars, error) {
+	current, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"_id":        id,
+		"deleted_at": nil,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"category":    category,
+			"starts_at":   startsAt,
+			"finished_at": finishedAt,
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var topCar model.TopCars
+	err = r.inCarTransaction(ctx, current.CarId, func(sc mongo.SessionContext) error {
+		overlaps, err := r.HasOverlappingTopCar(sc, current.CarId, startsAt, finishedAt, id)
+		if err != nil {
+			return err
+		}
+		if overlaps {
+			return repo.ErrTopCarOverlap
+		}
+
+		return r.Coll.FindOneAndUpdate(sc, filter, update, opts).Decode(&topCar)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
+// RestoreTopCar - soft delete qilingan promotionni tiklash.
+// O'chirilmagan yoki topilmagan bo'lsa mongo.ErrNoDocuments, muddati tugamagan oralig'i
+// shu carning boshqa promotioni bilan ustma-ust tushsa repo.ErrTopCarOverlap qaytadi
+func (r *TopCarsRepository) RestoreTopCar(ctx context.Context, id primitive.ObjectID) (*model.TopCars, error) {
+	filter := bson.M{
+		"_id":        id,
+		"deleted_at": bson.M{"$ne": nil},
+	}
+
+	var current model.TopCars
+	if err := r.Coll.FindOne(ctx, filter).Decode(&current); err != nil {
+		return nil, err
+	}
+
+	startsAt := current.StartsAt
+	if startsAt.IsZero() {
+		startsAt = current.CreatedAt
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"deleted_at": nil},
+		"$unset": bson.M{"delete_reason": ""},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var topCar model.TopCars
+	err := r.inCarTransaction(ctx, current.CarId, func(sc mongo.SessionContext) error {
+		overlaps, err := r.HasOverlappingTopCar(sc, current.CarId, startsAt, current.FinishedAt, id)
+		if err != nil {
+			return err
+		}
+		if overlaps {
+			return repo.ErrTopCarOverlap
+		}
+
+		return r.Coll.FindOneAndUpdate(sc, filter, update, opts).Decode(&topCar)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
+// GetDeletedBefore - before'dan oldin soft delete qilinganlar (arxivlash uchun xom hujjatlar, eskilari birinchi)
+func (r *TopCarsRepository) GetDeletedBefore(ctx context.Context, before time.Time, limit int64) ([]bson.Raw, error) {
+	filter := bson.M{
+		"deleted_at": bson.M{"$ne": nil, "$lt": before},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := r.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.Raw
+	for cursor.Next(ctx) {
+		// cursor.Current keyingi Next'da qayta ishlatiladi, shuning uchun nusxa olinadi
+		docs = append(docs, append(bson.Raw(nil), cursor.Current...))
+	}
+
+	return docs, cursor.Err()
+}
+
+// PurgeTopCars - soft delete qilingan promotionlarni butunlay o'chirish.
+// Shu orada tiklanganlari (deleted_at o'zgargan) o'chirilmaydi
+func (r *TopCarsRepository) PurgeTopCars(ctx context.Context, ids []primitive.ObjectID, deletedBefore time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"_id":        bson.M{"$in": ids},
+		"deleted_at": bson.M{"$ne": nil, "$lt": deletedBefore},
+	}
+
+	result, err := r.Coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// GetByPaymentID - payment ID bo'yicha top car olish (o'chirilganlari ham).
+// Asosiy to'lov, kutilayotgan yoki qo'llangan o'zgarish to'lovi bo'yicha qidiriladi
+func (r *TopCarsRepository) GetByPaymentID(ctx context.Context, paymentID string) (*model.TopCars, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"payment_id": paymentID},
+		bson.M{"pending_change.payment_id": paymentID},
+		bson.M{"applied_changes.payment_id": paymentID},
+	}}
+
+	var topCar model.TopCars
+	err := r.Coll.FindOne(ctx, filter).Decode(&topCar)
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
+// MarkTopCarPaid - pending promotionni paid qilish. Active bo'lishi ActivateStartedTopCars orqali bo'ladi.
+// Faqat pending holatdagi promotion yangilanadi, aks holda mongo.ErrNoDocuments qaytadi
+func (r *TopCarsRepository) MarkTopCarPaid(ctx context.Context, paymentID string, paidAt time.Time) (*model.TopCars, error) {
+	filter := bson.M{
+		"payment_id": paymentID,
+		"status":     model.TopCarStatusPending,
+		"deleted_at": nil,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":  model.TopCarStatusPaid,
+			"paid_at": paidAt,
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var topCar model.TopCars
+	err := r.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&topCar)
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
+// CancelTopCarPayment - to'lov amalga oshmagan pending promotionni bekor qilish
+func (r *TopCarsRepository) CancelTopCarPayment(ctx context.Context, paymentID string) error {
+	filter := bson.M{
+		"payment_id": paymentID,
+		"status":     model.TopCarStatusPending,
+		"deleted_at": nil,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     model.TopCarStatusCancelled,
+			"deleted_at": time.Now(),
+		},
+	}
+
+	_, err := r.Coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// SetPendingChange - to'lov kutayotgan o'zgarishni saqlash. Promotionda staleBefore'dan keyin
+// yaratilgan boshqa pending o'zgarish bo'lsa repo.ErrTopCarChangePending, o'chirilgan yoki
+// topilmagan bo'lsa mongo.ErrNoDocuments qaytadi
+func (r *TopCarsRepository) SetPendingChange(ctx context.Context, id primitive.ObjectID, change model.TopCarChange, staleBefore time.Time) (*model.TopCars, error) {
+	filter := bson.M{
+		"_id":        id,
+		"deleted_at": nil,
+		"$or": bson.A{
+			bson.M{"pending_change": nil},
+			bson.M{"pending_change.created_at": bson.M{"$lt": staleBefore}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"pending_change": change}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var topCar model.TopCars
+	err := r.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&topCar)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, getErr := r.GetByID(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, repo.ErrTopCarChangePending
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
+// ApplyPendingChange - to'lovi tasdiqlangan o'zgarishni qo'llash: yangi oraliq yoziladi, narx farqi
+// amount'ga qo'shiladi va o'zgarish applied_changes'ga ko'chiriladi. Yangi oraliq shu carning boshqa
+// promotioni bilan ustma-ust tushsa repo.ErrTopCarOverlap, o'zgarish topilmasa mongo.ErrNoDocuments qaytadi
+func (r *TopCarsRepository) ApplyPendingChange(ctx context.Context, paymentID string, paidAt time.Time) (*model.TopCars, error) {
+	var current model.TopCars
+	err := r.Coll.FindOne(ctx, bson.M{"pending_change.payment_id": paymentID, "deleted_at": nil}).Decode(&current)
+	if err != nil {
+		return nil, err
+	}
+	change := *current.PendingChange
+	change.PaidAt = &paidAt
+
+	filter := bson.M{
+		"_id":                       current.ID,
+		"deleted_at":                nil,
+		"pending_change.payment_id": paymentID,
+	}
+	set := bson.M{"finished_at": change.FinishedAt}
+	push := bson.M{"applied_changes": change}
+	inc := bson.M{"amount_minor": change.Amount}
+	overlapFrom := change.StartsAt
+
+	switch change.Kind {
+	case model.TopCarChangeExtend:
+		// Uzaytirish paytidagi finished_at o'zgarmagan bo'lishi kerak
+		filter["finished_at"] = change.PreviousFinishedAt
+		overlapFrom = change.PreviousFinishedAt
+		push["extensions"] = model.TopCarExtension{
+			Category:           change.Category,
+			PreviousFinishedAt: change.PreviousFinishedAt,
+			FinishedAt:         change.FinishedAt,
+			ExtendedBy:         change.RequestedBy,
+			ExtendedAt:         paidAt,
+		}
+	default:
+		set["category"] = change.Category
+		set["starts_at"] = change.StartsAt
+		// Narx farqi asosiy oraliq narxiga qo'shiladi, uzaytirishlar alohida qoladi
+		inc["base_amount_minor"] = change.Amount
+	}
+
+	update := bson.M{
+		"$set":   set,
+		"$inc":   inc,
+		"$push":  push,
+		"$unset": bson.M{"pending_change": ""},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var topCar model.TopCars
+	err = r.inCarTransaction(ctx, current.CarId, func(sc mongo.SessionContext) error {
+		overlaps, err := r.HasOverlappingTopCar(sc, current.CarId, overlapFrom, change.FinishedAt, current.ID)
+		if err != nil {
+			return err
+		}
+		if overlaps {
+			return repo.ErrTopCarOverlap
+		}
+
+		return r.Coll.FindOneAndUpdate(sc, filter, update, opts).Decode(&topCar)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &topCar, nil
+}
+
+// ClearPendingChange - to'lovi amalga oshmagan o'zgarishni olib tashlash (promotion o'zgarmaydi)
+func (r *TopCarsRepository) ClearPendingChange(ctx context.Context, paymentID string) error {
+	_, err := r.Coll.UpdateOne(ctx,
+		bson.M{"pending_change.payment_id": paymentID},
+		bson.M{"$unset": bson.M{"pending_change": ""}},
+	)
+	return err
+}
+
+// CancelStalePendingTopCars - createdBefore dan oldin yaratilgan va hali to'lanmagan promotionlarni bekor qilish
+func (r *TopCarsRepository) CancelStalePendingTopCars(ctx context.Context, createdBefore time.Time) (int64, error) {
+	filter := bson.M{
+		"status":     model.TopCarStatusPending,
+		"created_at": bson.M{"$lt": createdBefore},
+		"deleted_at": nil,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     model.TopCarStatusCancelled,
+			"deleted_at": time.Now(),
+		},
+	}
+
+	result, err := r.Coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// GetActiveCarIDs - o'chirilmagan va muddati tugamagan promotionlarga ega carlar ID'lari
+func (r *TopCarsRepository) GetActiveCarIDs(ctx context.Context) ([]string, error) {
+	filter := bson.M{
+		"finished_at": bson.M{"$gt": time.Now()},
+		"deleted_at":  nil,
+	}
+
+	values, err := r.Coll.Distinct(ctx, "car_id", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	carIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			carIDs = append(carIDs, id)
+		}
+	}
+
+	return carIDs, nil
+}
+
+// GetAllCarIDs - promotion sotib olingan barcha carlar va ularning egalari (o'chirilgan va
+// muddati tugaganlari ham). Photo GC ListCars'da ko'rinmaydigan carlarni topish uchun ishlatadi
+func (r *TopCarsRepository) GetAllCarIDs(ctx context.Context) (carIDs, userIDs []string, err error) {
+	if carIDs, err = distinctStrings(ctx, r.Coll, "car_id"); err != nil {
+		return nil, nil, err
+	}
+	if userIDs, err = distinctStrings(ctx, r.Coll, "user_id"); err != nil {
+		return nil, nil, err
+	}
+	return carIDs, userIDs, nil
+}
+
+// RetireByCarID - car o'chirilgan yoki sotilgan bo'lsa uning barcha promotionlarini sabab bilan o'chirish
+func (r *TopCarsRepository) RetireByCarID(ctx context.Context, carID, reason string) (int64, error) {
+	filter := bson.M{
+		"car_id":     carID,
+		"deleted_at": nil,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"deleted_at":    time.Now(),
+			"delete_reason": reason,
+		},
+	}
+
+	result, err := r.Coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// CountActiveTopCars - category bo'yicha hozir slot egallab turgan promotionlar soni
+func (r *TopCarsRepository) CountActiveTopCars(ctx context.Context, category string) (int64, error) {
+	filter := bson.M{
+		"category":    category,
+		"finished_at": bson.M{"$gt": time.Now()},
+		"deleted_at":  nil,
+		"$or": bson.A{
+			bson.M{"status": model.TopCarStatusActive},
+			bson.M{"status": bson.M{"$exists": false}},
+		},
+	}
+
+	return r.Coll.CountDocuments(ctx, filter)
+}
+
+// ActivateStartedTopCars - boshlanish vaqti kelgan paid promotionlarni bo'sh slotlar soniga qarab active qilish.
+// Slot bo'lmasa promotion paid holatda navbatda (waitlist) qoladi, navbat paid_at bo'yicha.
+// Kechikib active bo'lgan promotionning oynasi surilib, to'liq davomiyligi saqlanadi.
+// slots[category] == 0 bo'lsa cheklov yo'q
+func (r *TopCarsRepository) ActivateStartedTopCars(ctx context.Context, slots map[string]int) (int64, error) {
+	var activated int64
+	now := time.Now()
+
+	for category, limit := range slots {
+		n, err := r.activateCategory(ctx, category, limit, now)
+		if errors.Is(err, repo.ErrTopCarBusy) {
+			continue // keyingi ishga tushishda qayta urinib ko'riladi
+		}
+		if err != nil {
+			return activated, err
+		}
+		activated += n
+	}
+
+	return activated, nil
+}
+
+// activateCategory - bitta category uchun bo'sh slotlarni sanash va navbatdagilarni active qilish.
+// Ikkalasi category guard'i bilan bitta transactionda bajariladi: parallel replikalar slotlar sonidan
+// ko'p promotionni active qila olmaydi. Har bir car guard'i ham yangilanadi, shuning uchun oyna
+// shu car promotionlarining parallel o'zgarishlari bilan to'qnashmaydi
+func (r *TopCarsRepository) activateCategory(ctx context.Context, category string, limit int, now time.Time) (int64, error) {
+	var activated int64
+	err := r.inCategoryTransaction(ctx, category, func(sc mongo.SessionContext) error {
+		activated = 0 // transaction qayta bajarilishi mumkin
+
+		filter := bson.M{
+			"category":   category,
+			"status":     model.TopCarStatusPaid,
+			"starts_at":  bson.M{"$lte": now},
+			"deleted_at": nil,
+		}
+
+		opts := options.Find().SetSort(bson.D{{Key: "paid_at", Value: 1}, {Key: "_id", Value: 1}})
+		if limit > 0 {
+			active, err := r.CountActiveTopCars(sc, category)
+			if err != nil {
+				return err
+			}
+			free := int64(limit) - active
+			if free <= 0 {
+				return nil
+			}
+			opts.SetLimit(free)
+		}
+
+		cursor, err := r.Coll.Find(sc, filter, opts)
+		if err != nil {
+			return err
+		}
+		var waiting []*model.TopCars
+		if err = cursor.All(sc, &waiting); err != nil {
+			return err
+		}
+
+		for _, topCar := range waiting {
+			// Guard promotion yaratilganda (CreateTopCar) yozilgan
+			_, err := r.Guards.UpdateOne(sc, bson.M{"_id": topCar.CarId}, bson.M{"$inc": bson.M{"version": 1}})
+			if err != nil {
+				return err
+			}
+
+			set := bson.M{"status": model.TopCarStatusActive}
+			if topCar.StartsAt.Before(now.Add(-time.Minute)) {
+				// Kechikib boshlangan promotion to'liq muddatini oladi, lekin carning keyingi
+				// promotioniga o'tib ketmaydi
+				finishedAt := now.Add(topCar.FinishedAt.Sub(topCar.StartsAt))
+				next, err := r.firstOverlapping(sc, topCar.CarId, now, finishedAt, topCar.ID)
+				if err != nil {
+					return err
+				}
+				if next != nil {
+					finishedAt = next.StartsAt
+				}
+				if finishedAt.After(topCar.FinishedAt) {
+					set["starts_at"] = now
+					set["finished_at"] = finishedAt
+				}
+			}
+
+			result, err := r.Coll.UpdateOne(sc, bson.M{"_id": topCar.ID, "status": model.TopCarStatusPaid}, bson.M{"$set": set})
+			if err != nil {
+				return err
+			}
+			activated += result.ModifiedCount
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return activated, nil
 }
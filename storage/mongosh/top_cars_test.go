@@ -0,0 +1,266 @@
+package mongosh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDB - har bir test uchun alohida baza. Transactionlar uchun replica set kerak,
+// shuning uchun MDB_TEST_ADDRESS (masalan mongodb://localhost:27017/?replicaSet=rs0) berilmasa test o'tkazib yuboriladi
+func testDB(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MDB_TEST_ADDRESS")
+	if uri == "" {
+		t.Skip("MDB_TEST_ADDRESS is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database(fmt.Sprintf("wegugin_test_%s", primitive.NewObjectID().Hex()))
+	if err := RequireReplicaSet(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+func paidTopCar(carID string, startsAt time.Time, duration time.Duration) *model.TopCars {
+	return &model.TopCars{
+		CarId:      carID,
+		UserId:     "user-1",
+		Category:   "daily",
+		StartsAt:   startsAt,
+		FinishedAt: startsAt.Add(duration),
+		Status:     model.TopCarStatusPaid,
+	}
+}
+
+func TestCreateTopCarConcurrentOverlapOnlyOneWins(t *testing.T) {
+	r := NewTopCarsRepository(testDB(t)).(*TopCarsRepository)
+	ctx := context.Background()
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Har xil, lekin hammasi bir-biri bilan ustma-ust tushadigan oraliqlar
+			errs[i] = r.CreateTopCar(ctx, paidTopCar("car-1", start.Add(time.Duration(i)*time.Minute), 24*time.Hour))
+		}(i)
+	}
+	wg.Wait()
+
+	var created int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, repo.ErrTopCarOverlap), errors.Is(err, repo.ErrTopCarBusy):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("created = %d, want exactly 1", created)
+	}
+
+	count, err := r.Coll.CountDocuments(ctx, bson.M{"car_id": "car-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("stored promotions = %d, want 1", count)
+	}
+}
+
+func TestCreateTopCarAllowsAdjacentWindows(t *testing.T) {
+	r := NewTopCarsRepository(testDB(t))
+	ctx := context.Background()
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	if err := r.CreateTopCar(ctx, paidTopCar("car-1", start, 24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateTopCar(ctx, paidTopCar("car-1", start.Add(24*time.Hour), 24*time.Hour)); err != nil {
+		t.Fatalf("adjacent window rejected: %v", err)
+	}
+	err := r.CreateTopCar(ctx, paidTopCar("car-1", start.Add(12*time.Hour), time.Hour))
+	if !errors.Is(err, repo.ErrTopCarOverlap) {
+		t.Fatalf("overlapping window: err = %v, want ErrTopCarOverlap", err)
+	}
+	// Boshqa car uchun xuddi shu oraliq band emas
+	if err := r.CreateTopCar(ctx, paidTopCar("car-2", start, 24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestActivateStartedTopCarsDoesNotOverlapNextPromotion(t *testing.T) {
+	r := NewTopCarsRepository(testDB(t)).(*TopCarsRepository)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	// Slot kutib 2 soat kechikkan 24 soatlik promotion va u tugagandan 1 soat keyin boshlanadigan promotion
+	late := paidTopCar("car-1", now.Add(-2*time.Hour), 24*time.Hour)
+	next := paidTopCar("car-1", late.FinishedAt.Add(time.Hour), 24*time.Hour)
+	for _, topCar := range []*model.TopCars{late, next} {
+		if err := r.CreateTopCar(ctx, topCar); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	activated, err := r.ActivateStartedTopCars(ctx, map[string]int{"daily": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activated != 1 {
+		t.Fatalf("activated = %d, want 1", activated)
+	}
+
+	got, err := r.GetByID(ctx, late.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.TopCarStatusActive {
+		t.Fatalf("status = %q, want active", got.Status)
+	}
+	if got.FinishedAt.After(next.StartsAt) {
+		t.Fatalf("shifted finished_at %v overlaps next promotion starting %v", got.FinishedAt, next.StartsAt)
+	}
+	if !got.FinishedAt.After(late.FinishedAt) {
+		t.Fatalf("finished_at %v was not shifted for the late start", got.FinishedAt)
+	}
+}
+
+func TestActivateStartedTopCarsConcurrentRespectsSlots(t *testing.T) {
+	r := NewTopCarsRepository(testDB(t)).(*TopCarsRepository)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	const waiting, slots = 10, 3
+	for i := 0; i < waiting; i++ {
+		if err := r.CreateTopCar(ctx, paidTopCar(fmt.Sprintf("car-%d", i), now.Add(-time.Minute), 24*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Bir nechta replika bir vaqtda ishga tushgan
+	const workers = 5
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = r.ActivateStartedTopCars(ctx, map[string]int{"daily": slots})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	active, err := r.CountActiveTopCars(ctx, "daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active > slots {
+		t.Fatalf("active = %d, want at most %d", active, slots)
+	}
+}
+
+func TestChangesRacingIntoSameWindowOnlyOneWins(t *testing.T) {
+	r := NewTopCarsRepository(testDB(t)).(*TopCarsRepository)
+	ctx := context.Background()
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	// Birinchisi uzaytiriladi, ikkinchisi [start+48h, start+72h) oralig'iga ko'chiriladi
+	extended := paidTopCar("car-1", start, 24*time.Hour)
+	moved := paidTopCar("car-1", start.Add(96*time.Hour), 24*time.Hour)
+	for _, topCar := range []*model.TopCars{extended, moved} {
+		if err := r.CreateTopCar(ctx, topCar); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changes := []func() error{
+		func() error {
+			_, err := r.ExtendTopCar(ctx, extended.ID, model.TopCarExtension{
+				Category:           "custom",
+				PreviousFinishedAt: extended.FinishedAt,
+				FinishedAt:         start.Add(60 * time.Hour),
+			})
+			return err
+		},
+		func() error {
+			_, err := r.RescheduleTopCar(ctx, moved.ID, "daily", start.Add(48*time.Hour), start.Add(72*time.Hour))
+			return err
+		},
+		func() error {
+			return r.CreateTopCar(ctx, paidTopCar("car-1", start.Add(36*time.Hour), 24*time.Hour))
+		},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(changes))
+	for i, change := range changes {
+		wg.Add(1)
+		go func(i int, change func() error) {
+			defer wg.Done()
+			errs[i] = change()
+		}(i, change)
+	}
+	wg.Wait()
+
+	var applied int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			applied++
+		case errors.Is(err, repo.ErrTopCarOverlap), errors.Is(err, repo.ErrTopCarBusy):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if applied != 1 {
+		t.Fatalf("applied = %d, want exactly 1 (errors: %v)", applied, errs)
+	}
+
+	// Saqlangan promotionlar bir-biri bilan ustma-ust tushmaydi
+	list, err := r.GetListOfTopCars(ctx, model.TopCarsFilter{CarID: "car-1", ShowExpired: true, ShowScheduled: true, ShowUnpaid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range list {
+		for _, b := range list[i+1:] {
+			if a.StartsAt.Before(b.FinishedAt) && b.StartsAt.Before(a.FinishedAt) {
+				t.Fatalf("promotions %s and %s overlap", a.ID.Hex(), b.ID.Hex())
+			}
+		}
+	}
+}
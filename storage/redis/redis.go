@@ -2,13 +2,18 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 	"wegugin/config"
+	"wegugin/model"
 	"wegugin/storage/repo"
 
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cast"
 )
 
 func ConnectRDB() *redis.Client {
@@ -61,3 +66,379 @@ func (s RedisRepository) DeleteStatus(ctx context.Context, TyperId string) error
 	}
 	return nil
 }
+
+const topCarStatsDirtyKey = "topcar:stats:dirty"
+
+// topCarStatsKey - promotion va kun uchun hisoblagich (hash: impressions, clicks)
+func topCarStatsKey(topCarID string, day time.Time) string {
+	return fmt.Sprintf("topcar:stats:%s:%s", topCarID, day.UTC().Format("2006-01-02"))
+}
+
+func (s RedisRepository) incrTopCarCounter(ctx context.Context, topCarIDs []string, field string, at time.Time) error {
+	if len(topCarIDs) == 0 {
+		return nil
+	}
+
+	_, err := s.Rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range topCarIDs {
+			key := topCarStatsKey(id, at)
+			pipe.HIncrBy(ctx, key, field, 1)
+			// Flush ishlamay qolsa ham Redis'da abadiy qolib ketmasligi uchun
+			pipe.Expire(ctx, key, 7*24*time.Hour)
+			pipe.SAdd(ctx, topCarStatsDirtyKey, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to increment top car counters in Redis")
+	}
+	return nil
+}
+
+func (s RedisRepository) IncrTopCarImpressions(ctx context.Context, topCarIDs []string, at time.Time) error {
+	return s.incrTopCarCounter(ctx, topCarIDs, "impressions", at)
+}
+
+// IncrTopCarClick - bitta visitor (IP yoki sessiya) uchun window ichida faqat birinchi click hisoblanadi.
+// Click hisoblangan bo'lsa true qaytadi
+func (s RedisRepository) IncrTopCarClick(ctx context.Context, topCarID, visitor string, window time.Duration, at time.Time) (bool, error) {
+	key := fmt.Sprintf("topcar:click:%s:%s", topCarID, visitor)
+	first, err := s.Rdb.SetNX(ctx, key, 1, window).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check top car click in Redis")
+	}
+	if !first {
+		return false, nil
+	}
+	return true, s.incrTopCarCounter(ctx, []string{topCarID}, "clicks", at)
+}
+
+// GetTopCarCounters - hali Mongo'ga yozilmagan hisoblagichlarni olish
+func (s RedisRepository) GetTopCarCounters(ctx context.Context, topCarID string, days []time.Time) ([]model.TopCarDailyStats, error) {
+	cmds := make([]*redis.MapStringStringCmd, len(days))
+	_, err := s.Rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, day := range days {
+			cmds[i] = pipe.HGetAll(ctx, topCarStatsKey(topCarID, day))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get top car counters from Redis")
+	}
+
+	var stats []model.TopCarDailyStats
+	for i, cmd := range cmds {
+		values := cmd.Val()
+		if len(values) == 0 {
+			continue
+		}
+		stats = append(stats, model.TopCarDailyStats{
+			TopCarId:    topCarID,
+			Day:         days[i],
+			Impressions: cast.ToInt64(values["impressions"]),
+			Clicks:      cast.ToInt64(values["clicks"]),
+		})
+	}
+
+	return stats, nil
+}
+
+// PopTopCarCounters - o'zgargan hisoblagichlarni atomik o'qib, Redis'dan o'chirish (Mongo'ga flush qilish uchun)
+func (s RedisRepository) PopTopCarCounters(ctx context.Context, batch int64) ([]model.TopCarDailyStats, error) {
+	keys, err := s.Rdb.SPopN(ctx, topCarStatsDirtyKey, batch).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pop dirty top car counters from Redis")
+	}
+
+	var stats []model.TopCarDailyStats
+	for _, key := range keys {
+		// key: topcar:stats:<id>:<yyyy-mm-dd>
+		parts := strings.Split(key, ":")
+		if len(parts) != 4 {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", parts[3])
+		if err != nil {
+			continue
+		}
+
+		var values *redis.MapStringStringCmd
+		_, err = s.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			values = pipe.HGetAll(ctx, key)
+			pipe.Del(ctx, key)
+			return nil
+		})
+		if err != nil {
+			return stats, errors.Wrap(err, "failed to read top car counters from Redis")
+		}
+
+		st := model.TopCarDailyStats{
+			TopCarId:    parts[2],
+			Day:         day,
+			Impressions: cast.ToInt64(values.Val()["impressions"]),
+			Clicks:      cast.ToInt64(values.Val()["clicks"]),
+		}
+		if st.Impressions == 0 && st.Clicks == 0 {
+			continue
+		}
+		stats = append(stats, st)
+	}
+
+	return stats, nil
+}
+
+// RestoreTopCarCounters - Mongo'ga yozib bo'lmagan hisoblagichlarni Redis'ga qaytarish
+func (s RedisRepository) RestoreTopCarCounters(ctx context.Context, stats []model.TopCarDailyStats) error {
+	_, err := s.Rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, st := range stats {
+			key := topCarStatsKey(st.TopCarId, st.Day)
+			pipe.HIncrBy(ctx, key, "impressions", st.Impressions)
+			pipe.HIncrBy(ctx, key, "clicks", st.Clicks)
+			pipe.Expire(ctx, key, 7*24*time.Hour)
+			pipe.SAdd(ctx, topCarStatsDirtyKey, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to restore top car counters in Redis")
+	}
+	return nil
+}
+
+func carSummaryKey(carID string) string {
+	return "car:summary:" + carID
+}
+
+// GetCarSummaries - cache'dagi car ma'lumotlarini olish, cache'da yo'qlari natijada bo'lmaydi
+func (s RedisRepository) GetCarSummaries(ctx context.Context, carIDs []string) (map[string]*model.CarSummary, error) {
+	result := make(map[string]*model.CarSummary, len(carIDs))
+	if len(carIDs) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(carIDs))
+	for i, id := range carIDs {
+		keys[i] = carSummaryKey(id)
+	}
+
+	values, err := s.Rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get car summaries from Redis")
+	}
+
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var summary model.CarSummary
+		if err := json.Unmarshal([]byte(str), &summary); err != nil {
+			continue
+		}
+		result[summary.Id] = &summary
+	}
+
+	return result, nil
+}
+
+func (s RedisRepository) SetCarSummaries(ctx context.Context, summaries []*model.CarSummary, ttl time.Duration) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	_, err := s.Rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, summary := range summaries {
+			data, err := json.Marshal(summary)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, carSummaryKey(summary.Id), data, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to set car summaries in Redis")
+	}
+	return nil
+}
+
+func (s RedisRepository) DeleteCarSummary(ctx context.Context, carID string) error {
+	err := s.Rdb.Del(ctx, carSummaryKey(carID)).Err()
+	if err != nil {
+		return errors.Wrap(err, "failed to delete car summary from Redis")
+	}
+	return nil
+}
+
+// Muddati bo'yicha sessiyalar indeksi (sorted set, score - expires_at). Sessiya kalitining
+// o'zida TTL yo'q: aks holda u o'chib ketib, MinIO'dagi multipart upload abort qilinmay qolardi
+const uploadSessionsIndexKey = "upload:sessions"
+
+func uploadSessionKey(id string) string {
+	return "upload:session:" + id
+}
+
+func (s RedisRepository) CreateUploadSession(ctx context.Context, session *model.UploadSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, uploadSessionKey(session.Id), data, 0)
+		pipe.ZAdd(ctx, uploadSessionsIndexKey, redis.Z{Score: float64(session.ExpiresAt.Unix()), Member: session.Id})
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create upload session in Redis")
+	}
+	return nil
+}
+
+// GetUploadSession - sessiyani olish. Muddati o'tgan (hali tozalanmagan) sessiya ham topilmagan hisoblanadi
+func (s RedisRepository) GetUploadSession(ctx context.Context, id string) (*model.UploadSession, error) {
+	session, err := s.getUploadSession(ctx, s.Rdb, id)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, repo.ErrUploadSessionNotFound
+	}
+	return session, nil
+}
+
+func (s RedisRepository) getUploadSession(ctx context.Context, cmd redis.Cmdable, id string) (*model.UploadSession, error) {
+	data, err := cmd.Get(ctx, uploadSessionKey(id)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, repo.ErrUploadSessionNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get upload session from Redis")
+	}
+
+	var session model.UploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, errors.Wrap(err, "failed to decode upload session")
+	}
+	return &session, nil
+}
+
+// AdvanceUploadSession - yuklangan bo'lakni sessiyaga yozish. Offset WATCH ostida tekshiriladi:
+// parallel so'rovlardan faqat bittasi sessiyani oldinga suradi, qolganlari ErrUploadOffsetMismatch oladi
+func (s RedisRepository) AdvanceUploadSession(ctx context.Context, id string, expectedOffset, chunkSize int64, part model.UploadPart, expiresAt time.Time) (*model.UploadSession, error) {
+	key := uploadSessionKey(id)
+	var session *model.UploadSession
+
+	err := s.Rdb.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := s.getUploadSession(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return repo.ErrUploadSessionNotFound
+		}
+		if current.Offset != expectedOffset {
+			return repo.ErrUploadOffsetMismatch
+		}
+
+		current.Offset += chunkSize
+		current.Parts = append(current.Parts, part)
+		current.ExpiresAt = expiresAt
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			pipe.ZAdd(ctx, uploadSessionsIndexKey, redis.Z{Score: float64(expiresAt.Unix()), Member: id})
+			return nil
+		})
+		session = current
+		return err
+	}, key)
+
+	switch {
+	case err == nil:
+		return session, nil
+	case err == redis.TxFailedErr:
+		return nil, repo.ErrUploadOffsetMismatch
+	case errors.Is(err, repo.ErrUploadSessionNotFound), errors.Is(err, repo.ErrUploadOffsetMismatch):
+		return nil, err
+	default:
+		return nil, errors.Wrap(err, "failed to advance upload session in Redis")
+	}
+}
+
+// CompleteUploadSession - multipart upload yig'ilganini belgilash. Muddati o'zgarmaydi,
+// complete so'rovi shu muddat ichida qayta yuborilishi mumkin
+func (s RedisRepository) CompleteUploadSession(ctx context.Context, id string) error {
+	key := uploadSessionKey(id)
+
+	err := s.Rdb.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := s.getUploadSession(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		current.Completed = true
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			return nil
+		})
+		return err
+	}, key)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repo.ErrUploadSessionNotFound):
+		return err
+	default:
+		return errors.Wrap(err, "failed to complete upload session in Redis")
+	}
+}
+
+func (s RedisRepository) DeleteUploadSession(ctx context.Context, id string) error {
+	_, err := s.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, uploadSessionKey(id))
+		pipe.ZRem(ctx, uploadSessionsIndexKey, id)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete upload session from Redis")
+	}
+	return nil
+}
+
+// GetExpiredUploadSessions - before'dan oldin muddati tugagan sessiyalar (cleanup uchun).
+// Kaliti yo'qolgan indeks yozuvlari shu yerning o'zida tozalanadi
+func (s RedisRepository) GetExpiredUploadSessions(ctx context.Context, before time.Time, limit int64) ([]*model.UploadSession, error) {
+	ids, err := s.Rdb.ZRangeByScore(ctx, uploadSessionsIndexKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(before.Unix(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get expired upload sessions from Redis")
+	}
+
+	var sessions []*model.UploadSession
+	for _, id := range ids {
+		session, err := s.getUploadSession(ctx, s.Rdb, id)
+		if errors.Is(err, repo.ErrUploadSessionNotFound) {
+			s.Rdb.ZRem(ctx, uploadSessionsIndexKey, id)
+			continue
+		}
+		if err != nil {
+			return sessions, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
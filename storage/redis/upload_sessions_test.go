@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"wegugin/model"
+	"wegugin/storage/repo"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRepo - REDIS_TEST_ADDRESS (masalan localhost:6379) berilmasa test o'tkazib yuboriladi
+func testRepo(t *testing.T) *RedisRepository {
+	t.Helper()
+	addr := os.Getenv("REDIS_TEST_ADDRESS")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDRESS is not set")
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	return &RedisRepository{Rdb: rdb}
+}
+
+func newTestUploadSession(t *testing.T, r *RedisRepository, size, chunkSize int64) *model.UploadSession {
+	t.Helper()
+	session := &model.UploadSession{
+		Id:        uuid.NewString(),
+		Size:      size,
+		ChunkSize: chunkSize,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := r.CreateUploadSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.DeleteUploadSession(context.Background(), session.Id) })
+	return session
+}
+
+func TestAdvanceUploadSessionChecksOffset(t *testing.T) {
+	r := testRepo(t)
+	ctx := context.Background()
+	session := newTestUploadSession(t, r, 10, 4)
+	expires := time.Now().Add(2 * time.Hour)
+
+	got, err := r.AdvanceUploadSession(ctx, session.Id, 0, 4, model.UploadPart{Number: 1, ETag: "a"}, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Offset != 4 || len(got.Parts) != 1 {
+		t.Fatalf("offset = %d, parts = %d, want 4 and 1", got.Offset, len(got.Parts))
+	}
+
+	// Qayta yuborilgan bo'lak sessiyani o'zgartirmaydi
+	if _, err := r.AdvanceUploadSession(ctx, session.Id, 0, 4, model.UploadPart{Number: 1, ETag: "b"}, expires); !errors.Is(err, repo.ErrUploadOffsetMismatch) {
+		t.Fatalf("err = %v, want ErrUploadOffsetMismatch", err)
+	}
+	if _, err := r.AdvanceUploadSession(ctx, uuid.NewString(), 0, 4, model.UploadPart{Number: 1}, expires); !errors.Is(err, repo.ErrUploadSessionNotFound) {
+		t.Fatalf("unknown session: err = %v, want ErrUploadSessionNotFound", err)
+	}
+
+	stored, err := r.GetUploadSession(ctx, session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Offset != 4 || len(stored.Parts) != 1 || stored.Parts[0].ETag != "a" {
+		t.Fatalf("stored session = %+v", stored)
+	}
+	if !stored.ExpiresAt.Equal(expires) {
+		t.Fatalf("expires_at = %v, want %v", stored.ExpiresAt, expires)
+	}
+}
+
+func TestAdvanceUploadSessionConcurrentOnlyOneWins(t *testing.T) {
+	r := testRepo(t)
+	ctx := context.Background()
+	session := newTestUploadSession(t, r, 10, 4)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = r.AdvanceUploadSession(ctx, session.Id, 0, 4, model.UploadPart{Number: 1}, time.Now().Add(time.Hour))
+		}(i)
+	}
+	wg.Wait()
+
+	var advanced int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			advanced++
+		case errors.Is(err, repo.ErrUploadOffsetMismatch):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if advanced != 1 {
+		t.Fatalf("advanced = %d, want exactly 1", advanced)
+	}
+
+	stored, err := r.GetUploadSession(ctx, session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Offset != 4 || len(stored.Parts) != 1 {
+		t.Fatalf("offset = %d, parts = %d, want 4 and 1", stored.Offset, len(stored.Parts))
+	}
+}
+
+func TestCompleteUploadSessionKeepsExpiry(t *testing.T) {
+	r := testRepo(t)
+	ctx := context.Background()
+	session := newTestUploadSession(t, r, 4, 4)
+
+	if err := r.CompleteUploadSession(ctx, session.Id); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := r.GetUploadSession(ctx, session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Completed || !stored.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Fatalf("stored session = %+v", stored)
+	}
+	if err := r.CompleteUploadSession(ctx, uuid.NewString()); !errors.Is(err, repo.ErrUploadSessionNotFound) {
+		t.Fatalf("unknown session: err = %v, want ErrUploadSessionNotFound", err)
+	}
+}
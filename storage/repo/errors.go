@@ -0,0 +1,22 @@
+package repo
+
+import "errors"
+
+var (
+	// ErrTopCarOverlap - car uchun vaqt oralig'i ustma-ust tushadigan promotion allaqachon mavjud
+	ErrTopCarOverlap = errors.New("top car already has a promotion overlapping this time window")
+	// ErrTopCarBusy - shu car promotionlari boshqa so'rov tomonidan o'zgartirilmoqda
+	ErrTopCarBusy = errors.New("top car promotions of this car are being modified, please retry")
+	// ErrTopCarChangePending - promotion uchun boshqa o'zgarish to'lovi kutilmoqda
+	ErrTopCarChangePending = errors.New("another change of this top car is waiting for payment")
+)
+
+var (
+	// ErrUploadSessionNotFound - yuklash sessiyasi topilmadi yoki muddati tugagan
+	ErrUploadSessionNotFound = errors.New("upload session not found or expired")
+	// ErrUploadOffsetMismatch - bo'lak sessiyadagi joriy offset'dan boshlanmagan
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match the session offset")
+)
+
+// ErrPhotoObjectBusy - shu mazmundagi rasm obyektlari hozir o'chirilmoqda yoki boshqa yuklash tomonidan yozilmoqda
+var ErrPhotoObjectBusy = errors.New("photo object is being deleted or written, please retry")
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 	"wegugin/model"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,16 +18,92 @@ type ITopCarsStorage interface {
 	CreateTopCar(ctx context.Context, topCar *model.TopCars) error
 	GetListOfTopCars(ctx context.Context, filter model.TopCarsFilter) ([]*model.TopCars, error)
 	DeleteFinishedTopCars(ctx context.Context) (int64, error)
+	ClaimExpiryNotification(ctx context.Context, finishedAfter time.Time) (*model.TopCars, error)
 	DeleteByID(ctx context.Context, id primitive.ObjectID) error
 	DeleteByUserID(ctx context.Context, userID string) (int64, error)
 	DeleteByCarID(ctx context.Context, carID string) (int64, error)
 	GetByID(ctx context.Context, id primitive.ObjectID) (*model.TopCars, error)
 	UpdateTopCar(ctx context.Context, id primitive.ObjectID, updateData bson.M) error
 	CountTopCars(ctx context.Context, filter model.TopCarsFilter) (int64, error)
+	HasOverlappingTopCar(ctx context.Context, carID string, startsAt, finishedAt time.Time, excludeID primitive.ObjectID) (bool, error)
+	ExtendTopCar(ctx context.Context, id primitive.ObjectID, extension model.TopCarExtension) (*model.TopCars, error)
+	GetDeletedByID(ctx context.Context, id primitive.ObjectID) (*model.TopCars, error)
+	RescheduleTopCar(ctx context.Context, id primitive.ObjectID, category string, startsAt, finishedAt time.Time) (*model.TopCars, error)
+	RestoreTopCar(ctx context.Context, id primitive.ObjectID) (*model.TopCars, error)
+	GetDeletedBefore(ctx context.Context, before time.Time, limit int64) ([]bson.Raw, error)
+	PurgeTopCars(ctx context.Context, ids []primitive.ObjectID, deletedBefore time.Time) (int64, error)
+	GetTopCarAnalytics(ctx context.Context, filter model.TopCarAnalyticsFilter) (*model.TopCarAnalytics, error)
+	GetByPaymentID(ctx context.Context, paymentID string) (*model.TopCars, error)
+	MarkTopCarPaid(ctx context.Context, paymentID string, paidAt time.Time) (*model.TopCars, error)
+	CancelTopCarPayment(ctx context.Context, paymentID string) error
+	SetPendingChange(ctx context.Context, id primitive.ObjectID, change model.TopCarChange, staleBefore time.Time) (*model.TopCars, error)
+	ApplyPendingChange(ctx context.Context, paymentID string, paidAt time.Time) (*model.TopCars, error)
+	ClearPendingChange(ctx context.Context, paymentID string) error
+	CancelStalePendingTopCars(ctx context.Context, createdBefore time.Time) (int64, error)
+	GetActiveCarIDs(ctx context.Context) ([]string, error)
+	GetAllCarIDs(ctx context.Context) (carIDs, userIDs []string, err error)
+	RetireByCarID(ctx context.Context, carID, reason string) (int64, error)
+	CountActiveTopCars(ctx context.Context, category string) (int64, error)
+	ActivateStartedTopCars(ctx context.Context, slots map[string]int) (int64, error)
+}
+
+type IAdminAuditStorage interface {
+	CreateAuditLog(ctx context.Context, entry *model.AdminAuditLog) error
+	GetAuditLogs(ctx context.Context, filter model.AdminAuditFilter) ([]*model.AdminAuditLog, int64, error)
+}
+
+type IPaymentRefundsStorage interface {
+	RequireRefund(ctx context.Context, refund *model.PaymentRefund) error
+	GetRefunds(ctx context.Context, status string, limit, skip int64) ([]*model.PaymentRefund, int64, error)
+	MarkRefunded(ctx context.Context, paymentID, adminID string, refundedAt time.Time) (*model.PaymentRefund, error)
+}
+
+type ITopCarStatsStorage interface {
+	AddDailyStats(ctx context.Context, stats []model.TopCarDailyStats) ([]model.TopCarDailyStats, error)
+	GetDailyStats(ctx context.Context, topCarID string, from, to time.Time) ([]model.TopCarDailyStats, error)
+}
+
+type IPhotoOrdersStorage interface {
+	GetByCarID(ctx context.Context, carID string) (*model.PhotoOrder, error)
+	GetByCarIDs(ctx context.Context, carIDs []string) (map[string]*model.PhotoOrder, error)
+	SetOrder(ctx context.Context, carID string, imageIDs []string) (*model.PhotoOrder, error)
+	SetCover(ctx context.Context, carID, imageID string) (*model.PhotoOrder, error)
+	RemoveImage(ctx context.Context, carID, imageID string) error
+	DeleteByCarID(ctx context.Context, carID string) error
+	GetCarIDs(ctx context.Context) ([]string, error)
+}
+
+type IPhotoObjectsStorage interface {
+	Acquire(ctx context.Context, bucket, key, hash string) (bool, error)
+	Release(ctx context.Context, bucket, key string) (bool, error)
+	Forget(ctx context.Context, bucket, key string) error
+	Complete(ctx context.Context, bucket, key string) error
+	Abandon(ctx context.Context, bucket, key string) error
+	AwaitComplete(ctx context.Context, bucket, key string) (bool, error)
+	ReferencedKeys(ctx context.Context, bucket string) ([]string, error)
+}
+
+type INotificationPreferencesStorage interface {
+	GetByUserID(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+	Upsert(ctx context.Context, prefs *model.NotificationPreferences) error
 }
 
 type IRedisStorage interface {
 	StoreUserAsTyping(ctx context.Context, TyperId, UserId string) error
 	GetStatus(ctx context.Context, TyperId, UserId string) (bool, error)
 	DeleteStatus(ctx context.Context, TyperId string) error
+	IncrTopCarImpressions(ctx context.Context, topCarIDs []string, at time.Time) error
+	IncrTopCarClick(ctx context.Context, topCarID, visitor string, window time.Duration, at time.Time) (bool, error)
+	GetTopCarCounters(ctx context.Context, topCarID string, days []time.Time) ([]model.TopCarDailyStats, error)
+	PopTopCarCounters(ctx context.Context, batch int64) ([]model.TopCarDailyStats, error)
+	RestoreTopCarCounters(ctx context.Context, stats []model.TopCarDailyStats) error
+	GetCarSummaries(ctx context.Context, carIDs []string) (map[string]*model.CarSummary, error)
+	SetCarSummaries(ctx context.Context, summaries []*model.CarSummary, ttl time.Duration) error
+	DeleteCarSummary(ctx context.Context, carID string) error
+	CreateUploadSession(ctx context.Context, session *model.UploadSession) error
+	GetUploadSession(ctx context.Context, id string) (*model.UploadSession, error)
+	AdvanceUploadSession(ctx context.Context, id string, expectedOffset, chunkSize int64, part model.UploadPart, expiresAt time.Time) (*model.UploadSession, error)
+	CompleteUploadSession(ctx context.Context, id string) error
+	DeleteUploadSession(ctx context.Context, id string) error
+	GetExpiredUploadSessions(ctx context.Context, before time.Time, limit int64) ([]*model.UploadSession, error)
 }
@@ -12,6 +12,12 @@ import (
 
 type IStorage interface {
 	TopCars() repo.ITopCarsStorage
+	TopCarStats() repo.ITopCarStatsStorage
+	NotificationPreferences() repo.INotificationPreferencesStorage
+	AdminAudit() repo.IAdminAuditStorage
+	PaymentRefunds() repo.IPaymentRefundsStorage
+	PhotoOrders() repo.IPhotoOrdersStorage
+	PhotoObjects() repo.IPhotoObjectsStorage
 	Redis() repo.IRedisStorage
 	CloseRDB() error
 }
@@ -40,6 +46,30 @@ func (p *databaseStorage) TopCars() repo.ITopCarsStorage {
 	return mongosh.NewTopCarsRepository(p.mdb)
 }
 
+func (p *databaseStorage) TopCarStats() repo.ITopCarStatsStorage {
+	return mongosh.NewTopCarStatsRepository(p.mdb)
+}
+
+func (p *databaseStorage) NotificationPreferences() repo.INotificationPreferencesStorage {
+	return mongosh.NewNotificationPreferencesRepository(p.mdb)
+}
+
+func (p *databaseStorage) AdminAudit() repo.IAdminAuditStorage {
+	return mongosh.NewAdminAuditRepository(p.mdb)
+}
+
+func (p *databaseStorage) PaymentRefunds() repo.IPaymentRefundsStorage {
+	return mongosh.NewPaymentRefundsRepository(p.mdb)
+}
+
+func (p *databaseStorage) PhotoOrders() repo.IPhotoOrdersStorage {
+	return mongosh.NewPhotoOrdersRepository(p.mdb)
+}
+
+func (p *databaseStorage) PhotoObjects() repo.IPhotoObjectsStorage {
+	return mongosh.NewPhotoObjectsRepository(p.mdb)
+}
+
 func (p *databaseStorage) Redis() repo.IRedisStorage {
 	return redisnosql.NewRedisRepository(p.rdb)
 }
@@ -0,0 +1,122 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// BackfillReport - mavjud obyektlarni tozalash natijasi
+type BackfillReport struct {
+	Scanned   int
+	Sanitized int
+	Skipped   int // rasm emas, variant, content hash bo'yicha nomlangan yoki metadata'si yo'q
+	Failed    int
+}
+
+// SanitizeExisting - bucketdagi mavjud rasmlardan EXIF/GPS'ni olib tashlash va orientation'ni
+// pikselga qo'llash (UploadImage'dan oldin yuklangan fayllar uchun). Obyekt nomi va formati
+// o'zgarmaydi, shuning uchun saqlangan URL'lar ishlayveradi. Variantlari bor rasmlar uchun
+// variantlar ham qayta yaratiladi. Content hash bo'yicha nomlangan rasmlar yuklashda tozalangan
+// va qayta yozilsa nomi mazmuniga mos kelmay qoladi, shuning uchun ular o'tkazib yuboriladi.
+// dryRun bo'lsa hech narsa yozilmaydi
+func SanitizeExisting(ctx context.Context, store ObjectStore, bucketName string, keepExifTags, dryRun bool, logf func(format string, args ...any)) (BackfillReport, error) {
+	var report BackfillReport
+
+	objects, err := store.List(ctx, bucketName, "")
+	if err != nil {
+		return report, err
+	}
+
+	for _, object := range objects {
+		report.Scanned++
+
+		if isVariantKey(object.Key) || isContentAddressedKey(object.Key) {
+			report.Skipped++
+			continue
+		}
+
+		changed, err := sanitizeObject(ctx, store, bucketName, object.Key, keepExifTags, dryRun)
+		switch {
+		case err != nil:
+			report.Failed++
+			logf("failed to sanitize %s/%s: %v", bucketName, object.Key, err)
+		case changed:
+			report.Sanitized++
+			logf("sanitized %s/%s (dry run: %t)", bucketName, object.Key, dryRun)
+		default:
+			report.Skipped++
+		}
+	}
+
+	return report, nil
+}
+
+func sanitizeObject(ctx context.Context, store ObjectStore, bucketName, key string, keepExifTags, dryRun bool) (bool, error) {
+	data, err := ReadObject(ctx, store, bucketName, key, 0)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := ValidateImage(bytes.NewReader(data), int64(len(data)), ImageRules{})
+	if err != nil {
+		return false, nil // rasm emas
+	}
+	if !NeedsSanitize(data, info.Format, keepExifTags) {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	sanitized, sanitizedInfo, img, err := SanitizeImage(data, info, keepExifTags)
+	if err != nil {
+		return false, err
+	}
+	if sanitizedInfo.Format != info.Format {
+		return false, fmt.Errorf("format would change from %s to %s", info.Format, sanitizedInfo.Format)
+	}
+
+	if err := store.Put(ctx, bucketName, key, bytes.NewReader(sanitized), int64(len(sanitized)), sanitizedInfo.ContentType); err != nil {
+		return false, err
+	}
+
+	if len(VariantKeys(key)) > 1 {
+		variants, err := buildVariants(img, key)
+		if err != nil {
+			return true, err
+		}
+		for _, v := range variants {
+			if err := store.Put(ctx, bucketName, v.key, bytes.NewReader(v.data), int64(len(v.data)), v.contentType); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// isVariantKey - obyekt UploadImage yaratgan variantlardan biri ekanligini tekshirish
+func isVariantKey(key string) bool {
+	base := strings.TrimSuffix(key, path.Ext(key))
+	for _, v := range ImageVariants {
+		if strings.HasSuffix(base, "_"+v.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isContentAddressedKey - PutImage refcount bilan yaratgan nom: <sha256>_original.<ext>
+func isContentAddressedKey(key string) bool {
+	base := strings.TrimSuffix(key, path.Ext(key))
+	hash, ok := strings.CutSuffix(base, originalSuffix)
+	if !ok || len(hash) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
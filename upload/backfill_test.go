@@ -0,0 +1,125 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// testUnsafeExif - Make tegi va GPS IFD ko'rsatkichi bor EXIF
+func testUnsafeExif() []byte {
+	order := binary.LittleEndian
+	var tiff bytes.Buffer
+	tiff.WriteString("II*\x00")
+	binary.Write(&tiff, order, uint32(8))
+	binary.Write(&tiff, order, uint16(2))
+	for _, e := range []struct {
+		tag, typ uint16
+		value    []byte
+	}{
+		{exifTagMake, 2, []byte("Cam\x00")},
+		{0x8825, 4, []byte{0, 0, 0, 0}}, // GPS IFD
+	} {
+		binary.Write(&tiff, order, e.tag)
+		binary.Write(&tiff, order, e.typ)
+		binary.Write(&tiff, order, uint32(1))
+		tiff.Write(e.value)
+	}
+	binary.Write(&tiff, order, uint32(0))
+	return tiff.Bytes()
+}
+
+func putTestObject(t *testing.T, store ObjectStore, key string, data []byte) {
+	t.Helper()
+	if err := store.Put(context.Background(), "photos", key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 30; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 8), B: 90, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNeedsSanitizeAcceptsOwnSafeTags(t *testing.T) {
+	data := insertJPEGExif(testJPEG(t), testUnsafeExif())
+	info := &ImageInfo{Format: "jpeg", ContentType: "image/jpeg", Ext: ".jpg", Size: int64(len(data))}
+
+	if !NeedsSanitize(data, "jpeg", true) {
+		t.Fatal("EXIF with a GPS IFD reported as clean")
+	}
+
+	sanitized, _, _, err := SanitizeImage(data, info, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tiff := findExif(sanitized, "jpeg"); tiff == nil || !hasOnlySafeExif(tiff) {
+		t.Fatal("sanitized image does not keep only the safe tags")
+	}
+	// Backfill qayta ishga tushsa xavfsiz teglar qolgan fayl yana qayta siqilmaydi
+	if NeedsSanitize(sanitized, "jpeg", true) {
+		t.Fatal("sanitized image with safe tags needs sanitizing again")
+	}
+	if !NeedsSanitize(sanitized, "jpeg", false) {
+		t.Fatal("EXIF kept although safe tags are disabled")
+	}
+}
+
+func TestSanitizeExistingIsIdempotentWithSafeTags(t *testing.T) {
+	store := NewMemoryStore("http://media.test")
+	putTestObject(t, store, "legacy.jpg", insertJPEGExif(testJPEG(t), testUnsafeExif()))
+
+	for run, want := range []int{1, 0} {
+		report, err := SanitizeExisting(context.Background(), store, "photos", true, false, t.Logf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Sanitized != want || report.Failed != 0 {
+			t.Fatalf("run %d: report = %+v, want %d sanitized", run+1, report, want)
+		}
+	}
+
+	data, err := ReadObject(context.Background(), store, "photos", "legacy.jpg", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tiff := findExif(data, "jpeg"); tiff == nil || !hasOnlySafeExif(tiff) {
+		t.Fatal("sanitized object does not keep only the safe tags")
+	}
+}
+
+func TestSanitizeExistingSkipsContentAddressedKeys(t *testing.T) {
+	store := NewMemoryStore("http://media.test")
+	data := insertJPEGExif(testJPEG(t), testUnsafeExif())
+	key := sha256Hex(data) + originalSuffix + ".jpg"
+	putTestObject(t, store, key, data)
+
+	report, err := SanitizeExisting(context.Background(), store, "photos", false, false, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Sanitized != 0 || report.Skipped != 1 {
+		t.Fatalf("report = %+v, want the object skipped", report)
+	}
+	stored, err := ReadObject(context.Background(), store, "photos", key, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Fatal("content-addressed object was rewritten in place")
+	}
+}
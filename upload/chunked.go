@@ -0,0 +1,68 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// S3/MinIO multipart'da oxirgisidan boshqa part'lar kamida 5 MiB bo'lishi kerak
+const MinChunkSize = 5 << 20
+
+// UploadedPart - multipart upload'ga yozilgan part
+type UploadedPart struct {
+	Number int
+	ETag   string
+}
+
+func (m *MinioUploader) core() minio.Core {
+	return minio.Core{Client: m.client}
+}
+
+// StartMultipartUpload - staging bucketda resumable yuklash uchun multipart upload ochish
+func (m *MinioUploader) StartMultipartUpload(ctx context.Context, bucketName, objectName, contentType string) (string, error) {
+	if err := m.ensureStagingBucket(ctx, bucketName); err != nil {
+		return "", err
+	}
+
+	uploadID, err := m.core().NewMultipartUpload(ctx, bucketName, objectName, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart - bitta bo'lakni yozish. Bir xil raqamli part qayta yuborilsa avvalgisi almashtiriladi
+func (m *MinioUploader) UploadPart(ctx context.Context, bucketName, objectName, uploadID string, partNumber int, data io.Reader, size int64) (UploadedPart, error) {
+	part, err := m.core().PutObjectPart(ctx, bucketName, objectName, uploadID, partNumber, data, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return UploadedPart{}, fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return UploadedPart{Number: part.PartNumber, ETag: part.ETag}, nil
+}
+
+// CompleteMultipartUpload - part'larni bitta obyektga birlashtirish
+func (m *MinioUploader) CompleteMultipartUpload(ctx context.Context, bucketName, objectName, uploadID string, parts []UploadedPart) error {
+	completeParts := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		completeParts[i] = minio.CompletePart{PartNumber: p.Number, ETag: p.ETag}
+	}
+
+	if _, err := m.core().CompleteMultipartUpload(ctx, bucketName, objectName, uploadID, completeParts, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return nil
+}
+
+// AbortMultipartUpload - tugallanmagan yuklashni bekor qilish, yozilgan part'lar o'chiriladi
+func (m *MinioUploader) AbortMultipartUpload(ctx context.Context, bucketName, objectName, uploadID string) error {
+	err := m.core().AbortMultipartUpload(ctx, bucketName, objectName, uploadID)
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"sort"
+)
+
+const sanitizedJPEGQuality = 90
+
+// EXIF IFD0 teglari
+const (
+	exifTagMake        = 0x010F
+	exifTagModel       = 0x0110
+	exifTagOrientation = 0x0112
+	exifTagSoftware    = 0x0131
+	exifTagDateTime    = 0x0132
+	exifTagCopyright   = 0x8298
+)
+
+// Saqlab qolish mumkin bo'lgan (joylashuv va shaxsiy ma'lumot bo'lmagan) ASCII teglar.
+// GPS va Exif sub-IFD'lari (seriya raqamlari, aniq vaqt) hech qachon saqlanmaydi
+var safeExifTags = []uint16{exifTagMake, exifTagModel, exifTagSoftware, exifTagDateTime, exifTagCopyright}
+
+type exifTag struct {
+	tag   uint16
+	value []byte // NUL bilan tugaydigan ASCII
+}
+
+// SanitizeImage - rasmni qayta encode qilish: barcha metadata (EXIF/GPS, XMP va h.k.) olib tashlanadi,
+// EXIF orientation pikselga qo'llaniladi. keepSafeTags bo'lsa jpeg/png uchun faqat kamera
+// nomi, dastur, sana va copyright teglari qaytarib yoziladi. gif va bmp png'ga aylantiriladi.
+// WebP encoder faqat lossless bo'lgani uchun webp shaffof bo'lmasa jpeg'ga, aks holda png'ga aylantiriladi.
+// Qaytadi: yangi fayl, yangilangan info va orientation qo'llangan rasm (variantlar uchun)
+func SanitizeImage(data []byte, info *ImageInfo, keepSafeTags bool) ([]byte, *ImageInfo, image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, nil, ErrImageCorrupt
+	}
+
+	orientation, tags := readExif(findExif(data, info.Format))
+	img = applyOrientation(img, orientation)
+
+	out := *info
+	var buf bytes.Buffer
+	switch {
+	case info.Format == "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: sanitizedJPEGQuality})
+	case info.Format == "webp" && isOpaque(img):
+		out.Format, out.ContentType, out.Ext = "jpeg", "image/jpeg", ".jpg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: sanitizedJPEGQuality})
+	default:
+		out.Format, out.ContentType, out.Ext = "png", "image/png", ".png"
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	encoded := buf.Bytes()
+	if keepSafeTags && len(tags) > 0 {
+		switch out.Format {
+		case "jpeg":
+			encoded = insertJPEGExif(encoded, buildExif(tags))
+		case "png":
+			encoded = insertPNGExif(encoded, buildExif(tags))
+		}
+	}
+
+	out.Size = int64(len(encoded))
+	out.Width, out.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	return encoded, &out, img, nil
+}
+
+// isOpaque - rasmda shaffof piksel yo'q (aniqlab bo'lmasa shaffof deb hisoblanadi)
+func isOpaque(img image.Image) bool {
+	o, ok := img.(interface{ Opaque() bool })
+	return ok && o.Opaque()
+}
+
+// NeedsSanitize - faylda SanitizeImage olib tashlaydigan metadata bormi (backfill'da toza fayllarni
+// qayta siqmaslik uchun). keepSafeTags bo'lsa
+// faqat xavfsiz teglar (va orientation 1) qolgan EXIF toza hisoblanadi, aks holda har qanday EXIF
+func NeedsSanitize(data []byte, format string, keepSafeTags bool) bool {
+	tiff := findExif(data, format)
+	if tiff == nil {
+		return false
+	}
+	return !keepSafeTags || !hasOnlySafeExif(tiff)
+}
+
+// hasOnlySafeExif - EXIF faqat bitta IFD'dan iborat va unda faqat xavfsiz ASCII teglar
+// hamda orientation 1 bor (buildExif yozadigan ko'rinish). GPS/Exif sub-IFD va thumbnail IFD'si xavfli
+func hasOnlySafeExif(tiff []byte) bool {
+	if len(tiff) < 8 {
+		return false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return false
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return false
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	next := ifd + 2 + count*12
+	if next+4 > len(tiff) || order.Uint32(tiff[next:]) != 0 {
+		return false
+	}
+
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		tag := order.Uint16(tiff[entry:])
+		typ := order.Uint16(tiff[entry+2:])
+		switch {
+		case tag == exifTagOrientation && typ == 3:
+			if order.Uint16(tiff[entry+8:]) != 1 {
+				return false
+			}
+		case typ == 2 && isSafeExifTag(tag):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// findExif - formatga qarab EXIF (TIFF) blokini topish: jpeg APP1, png eXIf, webp EXIF chunk
+func findExif(data []byte, format string) []byte {
+	switch format {
+	case "jpeg":
+		for i := 2; i+4 <= len(data); {
+			if data[i] != 0xFF {
+				return nil
+			}
+			marker := data[i+1]
+			if marker == 0xDA || marker == 0xD9 { // SOS yoki EOI - metadata tugadi
+				return nil
+			}
+			size := int(binary.BigEndian.Uint16(data[i+2:]))
+			end := i + 2 + size
+			if size < 2 || end > len(data) {
+				return nil
+			}
+			segment := data[i+4 : end]
+			if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+				return segment[6:]
+			}
+			i = end
+		}
+	case "png":
+		for i := 8; i+12 <= len(data); {
+			size := int(binary.BigEndian.Uint32(data[i:]))
+			if size < 0 || i+12+size > len(data) {
+				return nil
+			}
+			if string(data[i+4:i+8]) == "eXIf" {
+				return data[i+8 : i+8+size]
+			}
+			i += 12 + size
+		}
+	case "webp":
+		for i := 12; i+8 <= len(data); {
+			size := int(binary.LittleEndian.Uint32(data[i+4:]))
+			if size < 0 || i+8+size > len(data) {
+				return nil
+			}
+			if string(data[i:i+4]) == "EXIF" {
+				return bytes.TrimPrefix(data[i+8:i+8+size], []byte("Exif\x00\x00"))
+			}
+			i += 8 + size + size%2
+		}
+	}
+	return nil
+}
+
+// readExif - IFD0'dan orientation va xavfsiz ASCII teglarni o'qish
+func readExif(tiff []byte) (orientation int, tags []exifTag) {
+	orientation = 1
+	if len(tiff) < 8 {
+		return orientation, nil
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientation, nil
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return orientation, nil
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry:])
+		typ := order.Uint16(tiff[entry+2:])
+		cnt := int(order.Uint32(tiff[entry+4:]))
+
+		if tag == exifTagOrientation && typ == 3 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				orientation = o
+			}
+			continue
+		}
+
+		if typ != 2 || cnt <= 0 || !isSafeExifTag(tag) {
+			continue
+		}
+		value := tiff[entry+8 : entry+12]
+		if cnt > 4 {
+			offset := int(order.Uint32(tiff[entry+8:]))
+			if offset < 0 || offset+cnt > len(tiff) {
+				continue
+			}
+			value = tiff[offset : offset+cnt]
+		}
+		value = append(bytes.TrimRight(value[:min(cnt, len(value))], "\x00"), 0)
+		tags = append(tags, exifTag{tag: tag, value: value})
+	}
+
+	return orientation, tags
+}
+
+func isSafeExifTag(tag uint16) bool {
+	for _, t := range safeExifTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// buildExif - faqat berilgan ASCII teglar va Orientation=1 dan iborat TIFF blok (little endian)
+func buildExif(tags []exifTag) []byte {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].tag < tags[j].tag })
+
+	order := binary.LittleEndian
+	count := len(tags) + 1
+	dataOffset := 8 + 2 + count*12 + 4
+
+	var ifd, extra bytes.Buffer
+	entry := make([]byte, 12)
+	writeEntry := func(tag, typ uint16, cnt uint32, value []byte) {
+		order.PutUint16(entry[0:], tag)
+		order.PutUint16(entry[2:], typ)
+		order.PutUint32(entry[4:], cnt)
+		copy(entry[8:], make([]byte, 4))
+		if len(value) <= 4 {
+			copy(entry[8:], value)
+		} else {
+			order.PutUint32(entry[8:], uint32(dataOffset+extra.Len()))
+			extra.Write(value)
+			if extra.Len()%2 == 1 {
+				extra.WriteByte(0)
+			}
+		}
+		ifd.Write(entry)
+	}
+
+	written := false
+	for _, t := range tags {
+		if !written && t.tag > exifTagOrientation {
+			writeEntry(exifTagOrientation, 3, 1, []byte{1, 0})
+			written = true
+		}
+		writeEntry(t.tag, 2, uint32(len(t.value)), t.value)
+	}
+	if !written {
+		writeEntry(exifTagOrientation, 3, 1, []byte{1, 0})
+	}
+
+	var out bytes.Buffer
+	out.WriteString("II*\x00")
+	binary.Write(&out, order, uint32(8))
+	binary.Write(&out, order, uint16(count))
+	out.Write(ifd.Bytes())
+	binary.Write(&out, order, uint32(0)) // keyingi IFD yo'q
+	out.Write(extra.Bytes())
+	return out.Bytes()
+}
+
+// insertJPEGExif - SOI'dan keyin APP1 Exif segmentini qo'shish
+func insertJPEGExif(data, tiff []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	if len(data) < 2 || len(payload)+2 > 0xFFFF {
+		return data
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+// insertPNGExif - IHDR'dan keyin eXIf chunk qo'shish (IDAT'dan oldin bo'lishi kerak)
+func insertPNGExif(data, tiff []byte) []byte {
+	const ihdrEnd = 8 + 12 + 13 // signature + IHDR chunk
+	if len(data) < ihdrEnd {
+		return data
+	}
+
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(len(tiff)))
+	chunk.WriteString("eXIf")
+	chunk.Write(tiff)
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(chunk.Bytes()[4:]))
+
+	var out bytes.Buffer
+	out.Write(data[:ihdrEnd])
+	out.Write(chunk.Bytes())
+	out.Write(data[ihdrEnd:])
+	return out.Bytes()
+}
+
+// applyOrientation - EXIF orientation (1-8) bo'yicha rasmni aylantirish/akslantirish
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // gorizontal akslantirish
+				sx, sy = w-1-x, y
+			case 3: // 180 gradus
+				sx, sy = w-1-x, h-1-y
+			case 4: // vertikal akslantirish
+				sx, sy = x, h-1-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // soat yo'nalishida 90 gradus
+				sx, sy = y, h-1-x
+			case 7: // transverse
+				sx, sy = w-1-y, h-1-x
+			case 8: // soat yo'nalishiga teskari 90 gradus
+				sx, sy = w-1-y, x
+			}
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,99 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// OrphanObject - hech bir cruds rasmiga tegishli bo'lmagan obyekt
+type OrphanObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	Deletable    bool      `json:"deletable"` // grace period o'tgan
+}
+
+// GCOptions - orphan obyektlarni tozalash sozlamalari
+type GCOptions struct {
+	GracePeriod    time.Duration // shundan yangi obyektlar o'chirilmaydi (yuklanayotgan rasmlar)
+	DryRun         bool
+	MaxDeleteRatio float64    // orphanlar bucketning shuncha qismidan ko'p bo'lsa o'chirish to'xtatiladi (0 - cheklanmagan)
+	Refs           RefCounter // berilsa o'chirilgan obyektlarning refcount yozuvlari ham olib tashlanadi
+}
+
+// GCReport - bitta bucket bo'yicha hisobot
+type GCReport struct {
+	Bucket      string         `json:"bucket"`
+	DryRun      bool           `json:"dry_run"`
+	Scanned     int            `json:"scanned"`
+	Referenced  int            `json:"referenced"`
+	Orphans     []OrphanObject `json:"orphans"`
+	OrphanBytes int64          `json:"orphan_bytes"`
+	Deleted     int            `json:"deleted"`
+	Failed      int            `json:"failed"`
+	Aborted     string         `json:"aborted,omitempty"` // o'chirish nima uchun bajarilmadi
+}
+
+// CollectOrphans - bucketdagi obyektlarni referenced kalitlar (rasmlar va ularning variantlari)
+// bilan solishtirish. DryRun bo'lmasa grace period'dan eski orphanlar o'chiriladi
+func CollectOrphans(ctx context.Context, store ObjectStore, bucketName string, referenced map[string]bool, opts GCOptions) (*GCReport, error) {
+	report := &GCReport{Bucket: bucketName, DryRun: opts.DryRun, Orphans: []OrphanObject{}}
+
+	objects, err := store.List(ctx, bucketName, "")
+	if err != nil {
+		return report, err
+	}
+	report.Scanned = len(objects)
+
+	cutoff := time.Now().Add(-opts.GracePeriod)
+	var deletable []string
+	for _, obj := range objects {
+		if referenced[obj.Key] {
+			report.Referenced++
+			continue
+		}
+		orphan := OrphanObject{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+			Deletable:    obj.LastModified.Before(cutoff),
+		}
+		report.Orphans = append(report.Orphans, orphan)
+		report.OrphanBytes += obj.Size
+		if orphan.Deletable {
+			deletable = append(deletable, obj.Key)
+		}
+	}
+
+	switch {
+	case opts.DryRun || len(deletable) == 0:
+		return report, nil
+	case report.Referenced == 0:
+		// Bo'sh ro'yxat ko'pincha cruds bilan muammo belgisi, hamma narsani o'chirib yubormaslik uchun
+		report.Aborted = "no referenced objects found in bucket"
+		return report, nil
+	case opts.MaxDeleteRatio > 0 && float64(len(deletable)) > opts.MaxDeleteRatio*float64(report.Scanned):
+		report.Aborted = fmt.Sprintf("%d of %d objects would be deleted, above the %.0f%% limit",
+			len(deletable), report.Scanned, opts.MaxDeleteRatio*100)
+		return report, nil
+	}
+
+	for _, key := range deletable {
+		if err := store.Delete(ctx, bucketName, key); err != nil {
+			fmt.Printf("Warning: failed to remove orphaned object %s/%s: %v\n", bucketName, key, err)
+			report.Failed++
+			continue
+		}
+		report.Deleted++
+
+		// Content hash nomli obyektning refs=0 yozuvi qolib ketmasligi uchun (variantlar uchun no-op)
+		if opts.Refs != nil {
+			if err := opts.Refs.Forget(ctx, bucketName, key); err != nil {
+				fmt.Printf("Warning: failed to forget refcount of %s/%s: %v\n", bucketName, key, err)
+			}
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,239 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// RefCounter - content-addressed obyektlarga havolalar hisoblagichi (repo.IPhotoObjectsStorage)
+type RefCounter interface {
+	// Acquire - havola qo'shish, created=true bo'lsa obyektlarni chaqiruvchi yozadi
+	Acquire(ctx context.Context, bucket, key, hash string) (created bool, err error)
+	// Release - havolani olib tashlash, true bo'lsa obyektlar o'chirilib Forget chaqiriladi
+	Release(ctx context.Context, bucket, key string) (bool, error)
+	Forget(ctx context.Context, bucket, key string) error
+	// Complete - created=true bo'lgan (yoki yozishni olgan) chaqiruvchi obyektlarni yozib bo'lgandan keyin
+	Complete(ctx context.Context, bucket, key string) error
+	// Abandon - obyektlarni yozish muvaffaqiyatsiz tugadi, yozishni kutayotgan boshqa yuklash oladi
+	Abandon(ctx context.Context, bucket, key string) error
+	// AwaitComplete - boshqa yuklash obyektlarni yozib bo'lishini kutish. claimed=true bo'lsa
+	// yozuvchi voz kechgan va obyektlarni endi chaqiruvchi yozishi kerak
+	AwaitComplete(ctx context.Context, bucket, key string) (claimed bool, err error)
+}
+
+// ImageStore - rasmlarni variantlari bilan ObjectStore'ga yozish va o'chirish.
+// Refs berilsa rasmlar mazmunining SHA-256 hash'i bo'yicha nomlanadi va bir xil fayl bir marta saqlanadi
+type ImageStore struct {
+	Store        ObjectStore
+	Refs         RefCounter // nil - har bir yuklash alohida uuid nomi bilan saqlanadi
+	KeepExifTags bool
+}
+
+// StoredImage - PutImage natijasi
+type StoredImage struct {
+	URL       string
+	Sha256    string
+	Duplicate bool // shu mazmundagi rasm avval yuklangan, obyektlar qayta yozilmadi
+}
+
+// PutImage - ValidateImage'dan o'tgan rasmni metadata'siz qayta encode qilib, o'lcham
+// variantlari bilan yuklash. Fayl nomi va content type fayl kengaytmasidan emas, aniqlangan formatdan olinadi.
+// Biror variant yuklanmasa havola qaytariladi (yoki yuklangan obyektlar o'chiriladi) va xato qaytadi
+func (s *ImageStore) PutImage(ctx context.Context, bucket string, data []byte, info *ImageInfo) (*StoredImage, error) {
+	// Bucket public bo'lgani uchun EXIF (GPS va h.k.) olib tashlanadi, orientation pikselga qo'llanadi
+	data, info, img, err := SanitizeImage(data, info, s.KeepExifTags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hash saqlanadigan baytlardan olinadi, shunda kalit obyekt mazmuniga mos keladi
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if s.Refs == nil {
+		newFileName := uuid.NewString() + originalSuffix + info.Ext
+		if err := s.putImageObjects(ctx, bucket, newFileName, data, info, img); err != nil {
+			return nil, err
+		}
+		return &StoredImage{URL: s.Store.URL(bucket, newFileName), Sha256: hash}, nil
+	}
+
+	// Xuddi shu fayl bir xil kalitga tushadi, kengaytma esa sanitize qilingan formatdan
+	key := hash + originalSuffix + info.Ext
+	created, err := s.Refs.Acquire(ctx, bucket, key, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register image: %w", err)
+	}
+
+	stored := &StoredImage{URL: s.Store.URL(bucket, key), Sha256: hash, Duplicate: !created}
+	if !created {
+		// Birinchi yuklash hali variantlarni yozayotgan bo'lishi mumkin: u tugashini kutamiz
+		claimed, err := s.Refs.AwaitComplete(ctx, bucket, key)
+		if err != nil {
+			s.release(ctx, bucket, key)
+			return nil, err
+		}
+		if !claimed {
+			// Obyekt GC tomonidan o'chirilgan bo'lsa qayta yoziladi
+			if _, err := s.Store.Stat(ctx, bucket, key); err == nil {
+				return stored, nil
+			} else if !IsNotFound(err) {
+				s.release(ctx, bucket, key)
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.putImageObjects(ctx, bucket, key, data, info, img); err != nil {
+		s.abandon(ctx, bucket, key)
+		return nil, err
+	}
+	if err := s.Refs.Complete(ctx, bucket, key); err != nil {
+		// Dublikatlar yozish vaqti tugagach obyektlarni qayta yozadi
+		fmt.Printf("Warning: failed to complete image %s/%s: %v\n", bucket, key, err)
+	}
+	return stored, nil
+}
+
+// putImageObjects - original va variantlarni yozish. Biror variant yozilmasa yozilganlari o'chiriladi
+func (s *ImageStore) putImageObjects(ctx context.Context, bucket, key string, data []byte, info *ImageInfo, img image.Image) error {
+	variants, err := buildVariants(img, key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Store.Put(ctx, bucket, key, bytes.NewReader(data), int64(len(data)), info.ContentType); err != nil {
+		return err
+	}
+
+	uploaded := []string{key}
+	for _, v := range variants {
+		err := s.Store.Put(ctx, bucket, v.key, bytes.NewReader(v.data), int64(len(v.data)), v.contentType)
+		if err != nil {
+			removeObjects(ctx, s.Store, bucket, uploaded)
+			return err
+		}
+		uploaded = append(uploaded, v.key)
+	}
+	return nil
+}
+
+// DeleteImage - rasmga havolani olib tashlash. Obyektlar (original va variantlar) faqat oxirgi
+// havola o'chirilganda o'chiriladi. Original topilmasa xato qaytadi, variantlar best-effort o'chiriladi.
+// Original o'chirilmasa refcount yozuvi (refs 0) qoladi va obyektlarni orphan GC tozalaydi
+func (s *ImageStore) DeleteImage(ctx context.Context, bucket, key string) error {
+	if s.Refs != nil {
+		last, err := s.Refs.Release(ctx, bucket, key)
+		if err != nil {
+			return fmt.Errorf("failed to release image: %v", err)
+		}
+		if !last {
+			return nil
+		}
+	}
+
+	if _, err := s.Store.Stat(ctx, bucket, key); err != nil {
+		if !IsNotFound(err) {
+			return fmt.Errorf("failed to check file existence (left for GC): %v", err)
+		}
+		s.removeVariants(ctx, bucket, key)
+		return fmt.Errorf("file not found: %s", key)
+	}
+	if err := s.Store.Delete(ctx, bucket, key); err != nil {
+		return fmt.Errorf("failed to delete file (left for GC): %v", err)
+	}
+	s.removeVariants(ctx, bucket, key)
+	return nil
+}
+
+// removeVariants - original o'chirilgandan keyin variantlarni best-effort o'chirish va refcount yozuvini olib tashlash
+func (s *ImageStore) removeVariants(ctx context.Context, bucket, key string) {
+	var variants []string
+	for name, k := range VariantKeys(key) {
+		if name != "original" {
+			variants = append(variants, k)
+		}
+	}
+	removeObjects(ctx, s.Store, bucket, variants)
+
+	if s.Refs != nil {
+		if err := s.Refs.Forget(ctx, bucket, key); err != nil {
+			fmt.Printf("Warning: failed to forget image %s/%s: %v\n", bucket, key, err)
+		}
+	}
+}
+
+// DeleteImageByURL - URL orqali rasmga havolani olib tashlash
+func (s *ImageStore) DeleteImageByURL(ctx context.Context, fileURL string) error {
+	bucket, key, err := s.Store.ParseURL(fileURL)
+	if err != nil {
+		return err
+	}
+	return s.DeleteImage(ctx, bucket, key)
+}
+
+// abandon - obyektlarni yozish muvaffaqiyatsiz tugadi: yozishni kutayotganlarga berib, havolani qaytarish
+func (s *ImageStore) abandon(ctx context.Context, bucket, key string) {
+	if err := s.Refs.Abandon(context.WithoutCancel(ctx), bucket, key); err != nil {
+		fmt.Printf("Warning: failed to abandon image %s/%s: %v\n", bucket, key, err)
+	}
+	s.release(ctx, bucket, key)
+}
+
+// release - muvaffaqiyatsiz yuklashda olingan havolani qaytarish. Oxirgi havola bo'lsa
+// yozilgan bo'lishi mumkin bo'lgan obyektlar tekshiruvsiz o'chiriladi
+func (s *ImageStore) release(ctx context.Context, bucket, key string) {
+	ctx = context.WithoutCancel(ctx)
+	last, err := s.Refs.Release(ctx, bucket, key)
+	if err != nil {
+		fmt.Printf("Warning: failed to release image %s/%s: %v\n", bucket, key, err)
+		return
+	}
+	if !last {
+		return
+	}
+
+	var keys []string
+	for _, k := range VariantKeys(key) {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		if err := s.Store.Delete(ctx, bucket, k); err != nil && !IsNotFound(err) {
+			fmt.Printf("Warning: failed to remove object %s/%s: %v\n", bucket, k, err)
+		}
+	}
+	if err := s.Refs.Forget(ctx, bucket, key); err != nil {
+		fmt.Printf("Warning: failed to forget image %s/%s: %v\n", bucket, key, err)
+	}
+}
+
+// VariantURLs - rasm URL'idan uning variantlari URL'lari (nom bo'yicha, "original" ham kiradi).
+// Variantlarsiz eski rasmlar uchun faqat "original" qaytadi
+func VariantURLs(fileURL string) map[string]string {
+	idx := strings.LastIndex(fileURL, "/")
+	if idx == -1 {
+		return map[string]string{"original": fileURL}
+	}
+
+	prefix := fileURL[:idx+1]
+	urls := make(map[string]string)
+	for name, key := range VariantKeys(fileURL[idx+1:]) {
+		urls[name] = prefix + key
+	}
+	return urls
+}
+
+func removeObjects(ctx context.Context, store ObjectStore, bucket string, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, bucket, key); err != nil {
+			fmt.Printf("Warning: failed to remove object %s/%s: %v\n", bucket, key, err)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRefs - xotiradagi RefCounter (repo.IPhotoObjectsStorage semantikasi bilan)
+type fakeRefs struct {
+	mu   sync.Mutex
+	refs map[string]int
+	// writing - obyektlari yozilayotgan kalitlar, false bo'lsa yozuvchi voz kechgan
+	writing map[string]bool
+}
+
+func newFakeRefs() *fakeRefs {
+	return &fakeRefs{refs: make(map[string]int), writing: make(map[string]bool)}
+}
+
+func (f *fakeRefs) Acquire(ctx context.Context, bucket, key, hash string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refs[bucket+"/"+key]++
+	if f.refs[bucket+"/"+key] == 1 {
+		f.writing[bucket+"/"+key] = true
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *fakeRefs) Complete(ctx context.Context, bucket, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.writing, bucket+"/"+key)
+	return nil
+}
+
+func (f *fakeRefs) Abandon(ctx context.Context, bucket, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.writing[bucket+"/"+key]; ok {
+		f.writing[bucket+"/"+key] = false
+	}
+	return nil
+}
+
+func (f *fakeRefs) AwaitComplete(ctx context.Context, bucket, key string) (bool, error) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		writing, ok := f.writing[bucket+"/"+key]
+		if !ok {
+			f.mu.Unlock()
+			return false, nil
+		}
+		if !writing {
+			f.writing[bucket+"/"+key] = true
+			f.mu.Unlock()
+			return true, nil
+		}
+		f.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false, errors.New("timed out waiting for the image to be written")
+}
+
+func (f *fakeRefs) Release(ctx context.Context, bucket, key string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	refs, ok := f.refs[bucket+"/"+key]
+	if !ok {
+		// Hisobga olinmagan (dedup'dan oldin yuklangan) obyekt
+		return true, nil
+	}
+	if refs == 0 {
+		return false, nil
+	}
+	f.refs[bucket+"/"+key] = refs - 1
+	return refs == 1, nil
+}
+
+func (f *fakeRefs) Forget(ctx context.Context, bucket, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.refs[bucket+"/"+key] == 0 {
+		delete(f.refs, bucket+"/"+key)
+	}
+	return nil
+}
+
+func (f *fakeRefs) get(bucket, key string) (int, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	refs, ok := f.refs[bucket+"/"+key]
+	return refs, ok
+}
+
+// failingStore - tanlangan kalitlarga Put/Delete xato qaytaradigan MemoryStore
+type failingStore struct {
+	*MemoryStore
+	failPut    string
+	failDelete string
+}
+
+var errStoreFailed = errors.New("store failed")
+
+func (s *failingStore) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	if s.failPut != "" && strings.HasSuffix(key, s.failPut) {
+		return errStoreFailed
+	}
+	return s.MemoryStore.Put(ctx, bucket, key, r, size, contentType)
+}
+
+func (s *failingStore) Delete(ctx context.Context, bucket, key string) error {
+	if key == s.failDelete {
+		return errStoreFailed
+	}
+	return s.MemoryStore.Delete(ctx, bucket, key)
+}
+
+// gatedStore - tanlangan variantni yozish gate yopilguncha kutib, keyin xato qaytaradigan MemoryStore
+type gatedStore struct {
+	*MemoryStore
+	suffix  string
+	mu      sync.Mutex
+	gate    chan struct{}
+	waiting chan struct{} // yozuvchi gate'ga yetib kelganda yopiladi
+}
+
+func (s *gatedStore) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	s.mu.Lock()
+	gate := s.gate
+	if strings.HasSuffix(key, s.suffix) {
+		s.gate = nil // faqat birinchi yozish to'xtatiladi
+	} else {
+		gate = nil
+	}
+	s.mu.Unlock()
+
+	if gate != nil {
+		close(s.waiting)
+		<-gate
+		return errStoreFailed
+	}
+	return s.MemoryStore.Put(ctx, bucket, key, r, size, contentType)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func putTestImage(t *testing.T, s *ImageStore, data []byte) (*StoredImage, error) {
+	t.Helper()
+	info, err := ValidateImage(bytes.NewReader(data), int64(len(data)), ImageRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.PutImage(context.Background(), "photos", data, info)
+}
+
+func objectKey(t *testing.T, url string) string {
+	t.Helper()
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+func assertObjects(t *testing.T, store ObjectStore, key string, exist bool) {
+	t.Helper()
+	for name, k := range VariantKeys(key) {
+		_, err := store.Stat(context.Background(), "photos", k)
+		if exist && err != nil {
+			t.Fatalf("%s variant %s: %v", name, k, err)
+		}
+		if !exist && !IsNotFound(err) {
+			t.Fatalf("%s variant %s still exists (err = %v)", name, k, err)
+		}
+	}
+}
+
+func TestPutImageDeduplicatesAndDeleteReleasesRefs(t *testing.T) {
+	store := NewMemoryStore("http://media.test")
+	refs := newFakeRefs()
+	s := &ImageStore{Store: store, Refs: refs}
+	data := testJPEG(t)
+
+	first, err := putTestImage(t, s, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := putTestImage(t, s, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Duplicate || !second.Duplicate {
+		t.Fatalf("duplicate = %v, %v, want false, true", first.Duplicate, second.Duplicate)
+	}
+	if first.URL != second.URL {
+		t.Fatalf("same image stored under %s and %s", first.URL, second.URL)
+	}
+
+	key := objectKey(t, first.URL)
+	if refs, _ := refs.get("photos", key); refs != 2 {
+		t.Fatalf("refs = %d, want 2", refs)
+	}
+	assertObjects(t, store, key, true)
+
+	// Boshqa havola qolganda obyektlar o'chirilmaydi
+	if err := s.DeleteImageByURL(context.Background(), first.URL); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := refs.get("photos", key); refs != 1 {
+		t.Fatalf("refs after first delete = %d, want 1", refs)
+	}
+	assertObjects(t, store, key, true)
+
+	if err := s.DeleteImageByURL(context.Background(), second.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := refs.get("photos", key); ok {
+		t.Fatal("refcount record was not forgotten after the last delete")
+	}
+	assertObjects(t, store, key, false)
+}
+
+func TestPutImageKeysBySanitizedContent(t *testing.T) {
+	store := NewMemoryStore("http://media.test")
+	s := &ImageStore{Store: store, Refs: newFakeRefs()}
+
+	plain := testJPEG(t)
+	// Faqat metadata bilan farq qiladigan fayl sanitize'dan keyin bir xil bo'ladi
+	withExif := insertJPEGExif(plain, buildExif([]exifTag{{tag: exifTagMake, value: []byte("Camera\x00")}}))
+	if bytes.Equal(plain, withExif) {
+		t.Fatal("test image without a difference in metadata")
+	}
+
+	a, err := putTestImage(t, s, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := putTestImage(t, s, withExif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.URL != b.URL || !b.Duplicate {
+		t.Fatalf("urls = %s, %s (duplicate %v), want the same object", a.URL, b.URL, b.Duplicate)
+	}
+
+	// Kalit saqlangan obyekt mazmunining hash'i
+	obj, err := store.Get(context.Background(), "photos", objectKey(t, a.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Close()
+	var stored bytes.Buffer
+	stored.ReadFrom(obj)
+	if !strings.HasPrefix(objectKey(t, a.URL), sha256Hex(stored.Bytes())) || a.Sha256 != sha256Hex(stored.Bytes()) {
+		t.Fatalf("key %s is not the hash of the stored bytes", objectKey(t, a.URL))
+	}
+}
+
+func TestPutImageReleasesRefOnFailure(t *testing.T) {
+	refs := newFakeRefs()
+	store := &failingStore{MemoryStore: NewMemoryStore("http://media.test")}
+	s := &ImageStore{Store: store, Refs: refs}
+	store.failPut = "_medium.jpg"
+
+	if _, err := putTestImage(t, s, testJPEG(t)); err == nil {
+		t.Fatal("PutImage succeeded with a failing variant upload")
+	}
+	if len(refs.refs) != 0 {
+		t.Fatalf("refs = %v, want the failed upload released and forgotten", refs.refs)
+	}
+	if objects, _ := store.List(context.Background(), "photos", ""); len(objects) != 0 {
+		t.Fatalf("%d objects left after a failed upload", len(objects))
+	}
+}
+
+func TestDeleteImageFailureLeavesRecordForGC(t *testing.T) {
+	refs := newFakeRefs()
+	store := &failingStore{MemoryStore: NewMemoryStore("http://media.test")}
+	s := &ImageStore{Store: store, Refs: refs}
+
+	stored, err := putTestImage(t, s, testJPEG(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := objectKey(t, stored.URL)
+	store.failDelete = key
+
+	if err := s.DeleteImage(context.Background(), "photos", key); err == nil {
+		t.Fatal("DeleteImage succeeded with a failing store")
+	}
+	// Havola qaytarilmaydi, yozuv refs 0 bilan qoladi: obyekt orphan sifatida GC'da o'chiriladi va Forget qilinadi
+	if n, ok := refs.get("photos", key); !ok || n != 0 {
+		t.Fatalf("refs = %d (exists %v), want a record with 0 refs", n, ok)
+	}
+
+	store.failDelete = ""
+	other := []byte("referenced")
+	if err := store.Put(context.Background(), "photos", "other.jpg", bytes.NewReader(other), int64(len(other)), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	// Manfiy grace period - hozirgina yozilgan obyektlar ham o'chiriladi
+	opts := GCOptions{GracePeriod: -time.Minute, Refs: refs}
+	report, err := CollectOrphans(context.Background(), store, "photos", map[string]bool{"other.jpg": true}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != len(VariantKeys(key)) {
+		t.Fatalf("gc deleted %d objects, want %d", report.Deleted, len(VariantKeys(key)))
+	}
+	if _, ok := refs.get("photos", key); ok {
+		t.Fatal("gc did not forget the refcount record")
+	}
+}
+
+func TestPutImageDuplicateWaitsForFirstUpload(t *testing.T) {
+	refs := newFakeRefs()
+	gate := make(chan struct{})
+	store := &gatedStore{
+		MemoryStore: NewMemoryStore("http://media.test"),
+		suffix:      "_large.jpg",
+		gate:        gate,
+		waiting:     make(chan struct{}),
+	}
+	s := &ImageStore{Store: store, Refs: refs}
+	data := testJPEG(t)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := putTestImage(t, s, data)
+		first <- err
+	}()
+	// Birinchi yuklash original yozilgandan keyin variant yozishda to'xtagan
+	<-store.waiting
+
+	second := make(chan *StoredImage, 1)
+	go func() {
+		stored, err := putTestImage(t, s, data)
+		if err != nil {
+			t.Error(err)
+		}
+		second <- stored
+	}()
+
+	select {
+	case <-second:
+		t.Fatal("duplicate returned before the first upload finished writing variants")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Birinchi yuklash xato bilan tugaydi, dublikat yozishni o'z zimmasiga oladi
+	close(gate)
+	if err := <-first; err == nil {
+		t.Fatal("first upload succeeded with a failing variant")
+	}
+	stored := <-second
+	if stored == nil {
+		t.FailNow()
+	}
+
+	key := objectKey(t, stored.URL)
+	assertObjects(t, store, key, true)
+	if n, _ := refs.get("photos", key); n != 1 {
+		t.Fatalf("refs = %d, want 1", n)
+	}
+}
+
+// 2x2 lossless WebP rasmlar: biri to'liq opaque, ikkinchisida shaffof piksellar bor
+const (
+	testOpaqueWebP      = "RIFFF\x00\x00\x00WEBPVP8L:\x00\x00\x00/\x01@\x00\x00\x8dRF\xf4?$\x02\b\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\b\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00 \x00\x00\x00\x00\x00\x00\x00\x00\x00 \x01\xe0Bh\xb7R\x8d\x10>"
+	testTransparentWebP = "RIFFH\x00\x00\x00WEBPVP8L<\x00\x00\x00/\x01@\x00\x10\x8dRF\xf4?$\x02\b\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\b\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00 \x00\x00\x00\x00\x00\x00\x00\x00\x00 \x00\xf0Bh\xb7R\x9d\x80\x10\xfc\x00"
+)
+
+func TestPutImageConvertsWebP(t *testing.T) {
+	s := &ImageStore{Store: NewMemoryStore("http://media.test"), Refs: newFakeRefs()}
+
+	for _, tt := range []struct {
+		name string
+		data string
+		ext  string
+	}{
+		{"opaque", testOpaqueWebP, ".jpg"},
+		{"transparent", testTransparentWebP, ".png"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, err := putTestImage(t, s, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, key := range VariantKeys(objectKey(t, stored.URL)) {
+				if !strings.HasSuffix(key, tt.ext) {
+					t.Fatalf("%s variant %s, want %s", name, key, tt.ext)
+				}
+			}
+			assertObjects(t, s.Store, objectKey(t, stored.URL), true)
+		})
+	}
+}
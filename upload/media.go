@@ -0,0 +1,91 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"wegugin/config"
+)
+
+var (
+	ErrMediaUnauthorized = errors.New("authorization is required to access this object")
+	ErrMediaForbidden    = errors.New("access to this object is forbidden")
+)
+
+// MediaAccess - obyektni so'rayotgan foydalanuvchi. UserID bo'sh bo'lsa anonim
+type MediaAccess struct {
+	UserID string
+	Role   string
+}
+
+// SignedMedia - obyektni o'qish uchun URL. Public bucketlarda muddatsiz (ExpiresAt nol)
+type SignedMedia struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// MediaSigner - bucket ko'rinishi bo'yicha ruxsatni tekshirib, obyektlar uchun
+// muddatli imzolangan GET URL beradi
+type MediaSigner struct {
+	store  ObjectStore
+	cfg    *config.Config
+	expiry time.Duration
+}
+
+func NewMediaSigner(cfg *config.Config, store ObjectStore) *MediaSigner {
+	expiry := time.Duration(cfg.Storage.SIGNED_URL_MINUTES) * time.Minute
+	if expiry <= 0 {
+		expiry = 10 * time.Minute
+	}
+	return &MediaSigner{store: store, cfg: cfg, expiry: expiry}
+}
+
+// CheckAccess - foydalanuvchi obyektni o'qiy oladimi. Admin hamma obyektni o'qiydi.
+// Anonim foydalanuvchiga ErrMediaUnauthorized, qolganlarga ErrMediaForbidden qaytadi.
+// Owner bucketlarda kalit normal ko'rinishda bo'lishi kerak ("u1/../u2/x" boshqa userning obyekti)
+func (s *MediaSigner) CheckAccess(bucket, key string, access MediaAccess) error {
+	v := BucketVisibility(s.cfg, bucket)
+	if v == VisibilityPublic || access.Role == "admin" {
+		return nil
+	}
+	if access.UserID == "" {
+		return ErrMediaUnauthorized
+	}
+
+	switch v {
+	case VisibilityAuthenticated:
+		return nil
+	case VisibilityOwner:
+		if strings.HasPrefix(key, access.UserID+"/") && path.Clean("/"+key) == "/"+key {
+			return nil
+		}
+	}
+	return ErrMediaForbidden
+}
+
+// SignGet - ruxsat tekshirilgandan keyin obyekt URL'i: public bucketlar uchun doimiy URL,
+// qolganlari uchun muddatli imzolangan URL. Ruxsat obyekt mavjudligidan oldin tekshiriladi
+// (ruxsatsiz foydalanuvchi obyekt bor-yo'qligini bilmasligi uchun)
+func (s *MediaSigner) SignGet(ctx context.Context, bucket, key string, access MediaAccess) (*SignedMedia, error) {
+	if err := s.CheckAccess(bucket, key, access); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.Stat(ctx, bucket, key); err != nil {
+		return nil, err
+	}
+
+	if BucketVisibility(s.cfg, bucket) == VisibilityPublic {
+		return &SignedMedia{URL: s.store.URL(bucket, key)}, nil
+	}
+
+	expiresAt := time.Now().Add(s.expiry)
+	u, err := s.store.Presign(ctx, http.MethodGet, bucket, key, s.expiry, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedMedia{URL: u, ExpiresAt: expiresAt}, nil
+}
@@ -0,0 +1,89 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"wegugin/config"
+)
+
+func newTestMediaSigner() (*MediaSigner, *MemoryStore) {
+	cfg := &config.Config{}
+	cfg.Storage.BUCKET_VISIBILITY = "photos:public,chat:authenticated,docs:owner,audit:private,misc:unknown"
+	store := NewMemoryStore("http://media.test")
+	return NewMediaSigner(cfg, store), store
+}
+
+func TestMediaSignerCheckAccess(t *testing.T) {
+	s, _ := newTestMediaSigner()
+	anonymous := MediaAccess{}
+	owner := MediaAccess{UserID: "u1", Role: "user"}
+	other := MediaAccess{UserID: "u2", Role: "user"}
+	admin := MediaAccess{UserID: "a1", Role: "admin"}
+
+	tests := []struct {
+		name   string
+		bucket string
+		key    string
+		access MediaAccess
+		want   error
+	}{
+		{"public anonymous", "photos", "x.jpg", anonymous, nil},
+		{"authenticated anonymous", "chat", "x.jpg", anonymous, ErrMediaUnauthorized},
+		{"authenticated user", "chat", "x.jpg", other, nil},
+		{"owner anonymous", "docs", "u1/x.pdf", anonymous, ErrMediaUnauthorized},
+		{"owner own object", "docs", "u1/x.pdf", owner, nil},
+		{"owner nested object", "docs", "u1/a/b/x.pdf", owner, nil},
+		{"owner other user's object", "docs", "u1/x.pdf", other, ErrMediaForbidden},
+		{"owner id prefix", "docs", "u10/x.pdf", owner, ErrMediaForbidden},
+		{"owner key without folder", "docs", "u1", owner, ErrMediaForbidden},
+		{"owner path traversal", "docs", "u1/../u2/x.pdf", owner, ErrMediaForbidden},
+		{"owner dot segment", "docs", "u1/./x.pdf", owner, ErrMediaForbidden},
+		{"owner double slash", "docs", "u1//x.pdf", owner, ErrMediaForbidden},
+		{"owner admin", "docs", "u1/x.pdf", admin, nil},
+		{"private user", "audit", "u1/x.log", owner, ErrMediaForbidden},
+		{"private admin", "audit", "u1/x.log", admin, nil},
+		{"unknown visibility is private", "misc", "u1/x", owner, ErrMediaForbidden},
+		{"unlisted bucket is private", "other", "u1/x", owner, ErrMediaForbidden},
+		{"unlisted bucket anonymous", "other", "x", anonymous, ErrMediaUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.CheckAccess(tt.bucket, tt.key, tt.access); !errors.Is(err, tt.want) {
+				t.Fatalf("CheckAccess(%s, %s) = %v, want %v", tt.bucket, tt.key, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaSignerSignGetChecksAccessBeforeExistence(t *testing.T) {
+	s, _ := newTestMediaSigner()
+
+	// Ruxsatsiz foydalanuvchi obyekt yo'qligini ham bilmaydi
+	_, err := s.SignGet(context.Background(), "docs", "u1/missing.pdf", MediaAccess{UserID: "u2"})
+	if !errors.Is(err, ErrMediaForbidden) {
+		t.Fatalf("err = %v, want ErrMediaForbidden", err)
+	}
+	_, err = s.SignGet(context.Background(), "docs", "u1/missing.pdf", MediaAccess{UserID: "u1"})
+	if !IsNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+}
+
+func TestMediaSignerSignGetPublicURL(t *testing.T) {
+	s, store := newTestMediaSigner()
+	if err := store.Put(context.Background(), "photos", "x.jpg", strings.NewReader("x"), 1, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := s.SignGet(context.Background(), "photos", "x.jpg", MediaAccess{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.URL != store.URL("photos", "x.jpg") || !signed.ExpiresAt.IsZero() {
+		t.Fatalf("signed = %+v, want the permanent public URL", signed)
+	}
+}
@@ -3,25 +3,28 @@ package upload
 import (
 	"context"
 	"fmt"
-	"mime/multipart"
 	"net/url"
-	"path/filepath"
 	"strings"
+	"sync"
 	"wegugin/config"
 
-	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
 type MinioUploader struct {
 	client *minio.Client
-	cfg    *config.Config
+	// presigner - MINIO_PUBLIC_URL host'i bilan imzolaydigan client: imzo host'ni ham qamraydi,
+	// shuning uchun ichki MINIO_ENDPOINT bilan imzolangan URL tashqaridan ishlamaydi
+	presigner  *minio.Client
+	publicPath string // MINIO_PUBLIC_URL'ning path qismi (proxy prefiksi)
+	cfg        *config.Config
+
+	ensured sync.Map // mavjudligi tekshirilgan bucketlar
 }
 
-func NewMinioUploader() (*MinioUploader, error) {
+func NewMinioUploader(cfg *config.Config) (*MinioUploader, error) {
 	fmt.Println("Minio client yaratilmoqda...")
-	cfg := config.Load()
 
 	client, err := minio.New(cfg.Minio.MINIO_ENDPOINT, &minio.Options{
 		Creds:  credentials.NewStaticV4(cfg.Minio.MINIO_ACCESS_KEY_ID, cfg.Minio.MINIO_SECRET_ACCESS_KEY, ""),
@@ -31,58 +34,38 @@ func NewMinioUploader() (*MinioUploader, error) {
 		return nil, fmt.Errorf("failed to create minio client: %v", err)
 	}
 
-	fmt.Println("Minio client muvaffaqiyatli yaratildi")
-	return &MinioUploader{client: client, cfg: cfg}, nil
-}
-
-func (m *MinioUploader) UploadFile(bucketName string, file multipart.File, header *multipart.FileHeader) (string, error) {
-	fmt.Println("Fayl yuklash boshlandi...")
-	ctx := context.Background()
-
-	// Bucket mavjudligini tekshirish va yaratish
-	exists, err := m.client.BucketExists(ctx, bucketName)
-	if err != nil {
-		return "", fmt.Errorf("failed to check bucket existence: %v", err)
-	}
-
-	if !exists {
-		err = m.client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{})
+	m := &MinioUploader{client: client, presigner: client, cfg: cfg}
+	if public, err := url.Parse(cfg.Minio.MINIO_PUBLIC_URL); err == nil && public.Host != "" {
+		// Region berilgani uchun imzolashda public endpoint'ga so'rov yuborilmaydi
+		m.presigner, err = minio.New(public.Host, &minio.Options{
+			Creds:  credentials.NewStaticV4(cfg.Minio.MINIO_ACCESS_KEY_ID, cfg.Minio.MINIO_SECRET_ACCESS_KEY, ""),
+			Secure: public.Scheme == "https",
+			Region: cfg.Minio.MINIO_REGION,
+		})
 		if err != nil {
-			return "", fmt.Errorf("failed to create bucket: %v", err)
+			return nil, fmt.Errorf("failed to create public minio client: %v", err)
 		}
-		fmt.Printf("Bucket '%s' yaratildi\n", bucketName)
+		m.publicPath = strings.TrimSuffix(public.Path, "/")
 	}
 
-	// Fayl nomi generatsiya qilish
-	fileExt := filepath.Ext(header.Filename)
-	newFileName := uuid.NewString() + fileExt
-
-	// Faylni yuklash
-	_, err = m.client.PutObject(ctx, bucketName, newFileName, file, header.Size, minio.PutObjectOptions{
-		ContentType: getContentType(fileExt),
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %v", err)
-	}
-
-	// Bucket policy o'rnatish (faqat bir marta kerak)
-	err = m.setBucketPolicyIfNeeded(ctx, bucketName)
-	if err != nil {
-		// Policy o'rnatish xatosi bo'lsa ham, fayl yuklandi, shuning uchun logga yozamiz
-		fmt.Printf("Warning: failed to set bucket policy: %v\n", err)
-	}
-
-	// Public URL yaratish
-	url := fmt.Sprintf("%s/%s/%s", m.cfg.Minio.MINIO_PUBLIC_URL, bucketName, newFileName)
-	fmt.Printf("Fayl muvaffaqiyatli yuklandi: %s\n", url)
-
-	return url, nil
+	fmt.Println("Minio client muvaffaqiyatli yaratildi")
+	return m, nil
 }
 
+// setBucketPolicyIfNeeded - bucket policy'ni STORAGE_BUCKET_VISIBILITY'ga moslash: public
+// bucketlarga hamma uchun o'qish ruxsati beriladi, qolganlaridan shunday ruxsat olib tashlanadi
 func (m *MinioUploader) setBucketPolicyIfNeeded(ctx context.Context, bucketName string) error {
 	// Avval mavjud policy tekshiramiz
-	_, err := m.client.GetBucketPolicy(ctx, bucketName)
-	if err == nil {
+	current, err := m.client.GetBucketPolicy(ctx, bucketName)
+	if BucketVisibility(m.cfg, bucketName) != VisibilityPublic {
+		if err != nil || !isPublicReadPolicy(current) {
+			return nil
+		}
+		// Bo'sh policy bucket policy'sini o'chiradi
+		fmt.Printf("Bucket '%s' private, public policy olib tashlanmoqda\n", bucketName)
+		return m.client.SetBucketPolicy(ctx, bucketName, "")
+	}
+	if err == nil && current != "" {
 		// Policy allaqachon o'rnatilgan
 		return nil
 	}
@@ -105,55 +88,25 @@ func (m *MinioUploader) setBucketPolicyIfNeeded(ctx context.Context, bucketName
 	return m.client.SetBucketPolicy(ctx, bucketName, policy)
 }
 
-// URL dan fayl nomini ajratib olish
-func (m *MinioUploader) extractFileNameFromURL(fileURL string) (string, error) {
-	parsedURL, err := url.Parse(fileURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse URL: %v", err)
-	}
-
-	// Path dan oxirgi qismni olish (/bucket/filename.ext -> filename.ext)
-	pathParts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
-	if len(pathParts) < 2 {
-		return "", fmt.Errorf("invalid URL format")
-	}
-
-	return pathParts[len(pathParts)-1], nil
-}
-
-// URL orqali faylni o'chirish
-func (m *MinioUploader) DeleteFileByURL(bucketName, fileURL string) error {
-	fileName, err := m.extractFileNameFromURL(fileURL)
-	if err != nil {
-		return err
-	}
-
-	return m.DeleteFile(bucketName, fileName)
+// isPublicReadPolicy - policy anonim foydalanuvchilarga obyektlarni o'qishga ruxsat beradimi
+func isPublicReadPolicy(policy string) bool {
+	compact := strings.Join(strings.Fields(policy), "")
+	return strings.Contains(compact, `"s3:GetObject"`) &&
+		(strings.Contains(compact, `"AWS":["*"]`) || strings.Contains(compact, `"AWS":"*"`) || strings.Contains(compact, `"Principal":"*"`))
 }
 
-// Fayl nomini bilgan holda o'chirish
-func (m *MinioUploader) DeleteFile(bucketName, fileName string) error {
-	fmt.Printf("Fayl o'chirilmoqda: %s/%s\n", bucketName, fileName)
-	ctx := context.Background()
-
-	// Avval fayl mavjudligini tekshirish
-	_, err := m.client.StatObject(ctx, bucketName, fileName, minio.StatObjectOptions{})
-	if err != nil {
-		// Agar fayl mavjud bo'lmasa
-		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
-			return fmt.Errorf("file not found: %s", fileName)
+// ApplyBucketPolicies - config'dagi mavjud bucketlar policy'sini ko'rinishiga moslash
+// (avval public qilingan private bucketlar ishga tushishda yopiladi)
+func (m *MinioUploader) ApplyBucketPolicies(ctx context.Context) {
+	for bucket := range BucketVisibilities(m.cfg) {
+		exists, err := m.client.BucketExists(ctx, bucket)
+		if err != nil || !exists {
+			continue
+		}
+		if err := m.setBucketPolicyIfNeeded(ctx, bucket); err != nil {
+			fmt.Printf("Warning: failed to set bucket policy on %s: %v\n", bucket, err)
 		}
-		return fmt.Errorf("failed to check file existence: %v", err)
-	}
-
-	// Faylni o'chirish
-	err = m.client.RemoveObject(ctx, bucketName, fileName, minio.RemoveObjectOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to delete file: %v", err)
 	}
-
-	fmt.Printf("Fayl muvaffaqiyatli o'chirildi: %s\n", fileName)
-	return nil
 }
 
 func getContentType(fileExt string) string {
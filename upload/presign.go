@@ -0,0 +1,91 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// Staging bucketdagi tugallanmagan yuklashlar shuncha kundan keyin MinIO tomonidan o'chiriladi
+const stagingExpirationDays = 1
+
+// MaxStagedUploadAge - resumable yuklash sessiyasining boshlanganidan keyingi eng uzun umri.
+// Lifecycle qoidasi multipart upload'ni boshlanganidan stagingExpirationDays kun o'tib abort qiladi,
+// sessiya esa har bir bo'lakda uzaytiriladi, shuning uchun undan oldin tugashi kerak
+const MaxStagedUploadAge = stagingExpirationDays*24*time.Hour - time.Hour
+
+// ContentTypeFormat - content type'ga mos rasm formati ("image/jpeg" -> "jpeg")
+func ContentTypeFormat(contentType string) (string, bool) {
+	known, ok := imageFormats[contentType]
+	return known.format, ok
+}
+
+// FormatAllowed - format ruxsat etilganlar ro'yxatida bormi (bo'sh ro'yxat - hammasi ruxsat)
+func FormatAllowed(format string, allowed []string) bool {
+	return formatAllowed(format, allowed)
+}
+
+// PresignedUpload - client to'g'ridan-to'g'ri MinIO'ga yuborishi kerak bo'lgan PUT so'rovi
+type PresignedUpload struct {
+	URL       string
+	Headers   map[string]string // so'rovda aynan shu qiymatlar bilan yuborilishi shart (imzoga kiradi)
+	ExpiresAt time.Time
+}
+
+// PresignUpload - private staging bucketga PUT uchun imzolangan URL. Content-Type va
+// Content-Length imzoga qo'shiladi, shuning uchun client boshqa turdagi yoki hajmdagi
+// faylni shu URL bilan yuklay olmaydi
+func PresignUpload(ctx context.Context, store ObjectStore, bucketName, objectName, contentType string, size int64, expiry time.Duration) (*PresignedUpload, error) {
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Length", strconv.FormatInt(size, 10))
+
+	u, err := store.Presign(ctx, http.MethodPut, bucketName, objectName, expiry, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PresignedUpload{
+		URL: u,
+		Headers: map[string]string{
+			"Content-Type":   contentType,
+			"Content-Length": strconv.FormatInt(size, 10),
+		},
+		ExpiresAt: time.Now().Add(expiry),
+	}, nil
+}
+
+// ensureStagingBucket - private staging bucket va eskirgan yuklashlarni o'chiruvchi lifecycle qoidasi
+func (m *MinioUploader) ensureStagingBucket(ctx context.Context, bucketName string) error {
+	exists, err := m.client.BucketExists(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket existence: %v", err)
+	}
+	if exists {
+		return nil
+	}
+
+	if err := m.client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{}); err != nil {
+		return fmt.Errorf("failed to create bucket: %v", err)
+	}
+
+	config := lifecycle.NewConfiguration()
+	config.Rules = []lifecycle.Rule{{
+		ID:         "expire-staged-uploads",
+		Status:     "Enabled",
+		Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(stagingExpirationDays)},
+		AbortIncompleteMultipartUpload: lifecycle.AbortIncompleteMultipartUpload{
+			DaysAfterInitiation: lifecycle.ExpirationDays(stagingExpirationDays),
+		},
+	}}
+	if err := m.client.SetBucketLifecycle(ctx, bucketName, config); err != nil {
+		fmt.Printf("Warning: failed to set lifecycle on bucket %s: %v\n", bucketName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"wegugin/config"
+)
+
+var (
+	ErrObjectNotFound      = errors.New("object not found")
+	ErrPresignNotSupported = errors.New("presigned URLs are not supported by this storage driver")
+)
+
+// ObjectStat - saqlangan obyekt haqida ma'lumot
+type ObjectStat struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// ObjectStore - fayllar saqlanadigan joy (MinIO, lokal disk yoki xotira).
+// Bucket birinchi yozishda yaratiladi, topilmagan obyekt uchun ErrObjectNotFound qaytadi
+type ObjectStore interface {
+	Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	// Delete - obyekt mavjud bo'lmasa ham xato qaytmaydi
+	Delete(ctx context.Context, bucket, key string) error
+	Stat(ctx context.Context, bucket, key string) (*ObjectStat, error)
+	// Presign - imzolangan URL; headers imzoga kiradi va so'rovda aynan shunday yuborilishi kerak
+	Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, headers http.Header) (string, error)
+	List(ctx context.Context, bucket, prefix string) ([]ObjectStat, error)
+	// URL - obyektning public URL'i, ParseURL - teskarisi
+	URL(bucket, key string) string
+	ParseURL(fileURL string) (bucket, key string, err error)
+}
+
+// NewObjectStore - config'dagi STORAGE_DRIVER bo'yicha store yaratish
+func NewObjectStore(cfg *config.Config) (ObjectStore, error) {
+	switch cfg.Storage.DRIVER {
+	case "", "minio":
+		return NewMinioUploader(cfg)
+	case "local":
+		return NewLocalStore(cfg.Storage.LOCAL_ROOT, cfg.Storage.PUBLIC_URL)
+	case "memory":
+		return NewMemoryStore(cfg.Storage.PUBLIC_URL), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.Storage.DRIVER)
+	}
+}
+
+// ReadObject - obyektni to'liq o'qish. maxBytes'dan katta bo'lsa ErrImageTooLarge
+func ReadObject(ctx context.Context, store ObjectStore, bucket, key string, maxBytes int64) ([]byte, error) {
+	stat, err := store.Stat(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes > 0 && stat.Size > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, max %d", ErrImageTooLarge, stat.Size, maxBytes)
+	}
+
+	r, err := store.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// IsNotFound - obyekt yoki bucket topilmadi
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrObjectNotFound)
+}
+
+// publicURL - base URL va bucket/key'dan URL yasash (local va memory driverlar uchun)
+func publicURL(base, bucket, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + bucket + "/" + key
+}
+
+// parsePublicURL - publicURL'ning teskarisi, base'ning path qismi tashlab yuboriladi
+func parsePublicURL(base, fileURL string) (bucket, key string, err error) {
+	rest := strings.TrimPrefix(fileURL, strings.TrimSuffix(base, "/")+"/")
+	if rest == fileURL {
+		return "", "", fmt.Errorf("URL does not belong to this storage: %s", fileURL)
+	}
+	bucket, key, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid URL format: %s", fileURL)
+	}
+	return bucket, key, nil
+}
@@ -0,0 +1,165 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocalStore - obyektlarni <root>/<bucket>/<key> fayllari sifatida saqlovchi ObjectStore.
+// Public bucketlar router orqali publicURL ostida static fayl sifatida beriladi
+type LocalStore struct {
+	root      string
+	publicURL string
+}
+
+func NewLocalStore(root, publicURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %v", err)
+	}
+	return &LocalStore{root: root, publicURL: publicURL}, nil
+}
+
+// Root - saqlash papkasi
+func (s *LocalStore) Root() string {
+	return s.root
+}
+
+// objectPath - bucket va key'ni fayl yo'liga aylantirish. ".." bilan root'dan chiqib bo'lmaydi
+func (s *LocalStore) objectPath(bucket, key string) (string, error) {
+	if bucket == "" || strings.ContainsAny(bucket, `/\`) || bucket == "." || bucket == ".." {
+		return "", fmt.Errorf("invalid bucket name: %q", bucket)
+	}
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid object key: %q", key)
+	}
+	return filepath.Join(s.root, bucket, filepath.FromSlash(clean)), nil
+}
+
+// Put - vaqtinchalik faylga yozib rename qilinadi, o'quvchilar yarim yozilgan faylni ko'rmaydi
+func (s *LocalStore) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("object size mismatch: got %d bytes, expected %d", written, size)
+	}
+
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("failed to store file: %v", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Delete(ctx context.Context, bucket, key string) error {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %v", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Stat(ctx context.Context, bucket, key string) (*ObjectStat, error) {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && fi.IsDir()) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectStat{Key: key, Size: fi.Size(), ContentType: getContentType(path.Ext(key)), LastModified: fi.ModTime()}, nil
+}
+
+func (s *LocalStore) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, headers http.Header) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+func (s *LocalStore) List(ctx context.Context, bucket, prefix string) ([]ObjectStat, error) {
+	dir := filepath.Join(s.root, bucket)
+	var objects []ObjectStat
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectStat{Key: key, Size: fi.Size(), ContentType: getContentType(path.Ext(key)), LastModified: fi.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %v", err)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *LocalStore) URL(bucket, key string) string {
+	return publicURL(s.publicURL, bucket, key)
+}
+
+func (s *LocalStore) ParseURL(fileURL string) (string, string, error) {
+	return parsePublicURL(s.publicURL, fileURL)
+}
@@ -0,0 +1,101 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// MemoryStore - xotiradagi ObjectStore (testlar va lokal ishlab chiqish uchun)
+type MemoryStore struct {
+	mu        sync.RWMutex
+	buckets   map[string]map[string]memoryObject
+	publicURL string
+}
+
+func NewMemoryStore(publicURL string) *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]map[string]memoryObject), publicURL: publicURL}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read object: %v", err)
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return fmt.Errorf("object size mismatch: got %d bytes, expected %d", len(data), size)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string]memoryObject)
+	}
+	s.buckets[bucket][key] = memoryObject{data: data, contentType: contentType, modified: time.Now()}
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+func (s *MemoryStore) Stat(ctx context.Context, bucket, key string) (*ObjectStat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return &ObjectStat{Key: key, Size: int64(len(obj.data)), ContentType: obj.contentType, LastModified: obj.modified}, nil
+}
+
+func (s *MemoryStore) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, headers http.Header) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+func (s *MemoryStore) List(ctx context.Context, bucket, prefix string) ([]ObjectStat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var objects []ObjectStat
+	for key, obj := range s.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, ObjectStat{Key: key, Size: int64(len(obj.data)), ContentType: obj.contentType, LastModified: obj.modified})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *MemoryStore) URL(bucket, key string) string {
+	return publicURL(s.publicURL, bucket, key)
+}
+
+func (s *MemoryStore) ParseURL(fileURL string) (string, string, error) {
+	return parsePublicURL(s.publicURL, fileURL)
+}
@@ -0,0 +1,155 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MinioUploader ObjectStore'ni MinIO (S3) ustida amalga oshiradi
+
+func (m *MinioUploader) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	if err := m.ensureBucket(ctx, bucket); err != nil {
+		return err
+	}
+
+	_, err := m.client.PutObject(ctx, bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	return nil
+}
+
+func (m *MinioUploader) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	// GetObject so'rovni birinchi o'qishgacha yubormaydi, shuning uchun "topilmadi" Stat orqali aniqlanadi
+	if _, err := m.Stat(ctx, bucket, key); err != nil {
+		return nil, err
+	}
+	obj, err := m.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, minioError(err)
+	}
+	return obj, nil
+}
+
+func (m *MinioUploader) Delete(ctx context.Context, bucket, key string) error {
+	err := m.client.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{})
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchBucket" {
+		return fmt.Errorf("failed to delete object: %v", err)
+	}
+	return nil
+}
+
+func (m *MinioUploader) Stat(ctx context.Context, bucket, key string) (*ObjectStat, error) {
+	info, err := m.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, minioError(err)
+	}
+	return &ObjectStat{Key: info.Key, Size: info.Size, ContentType: info.ContentType, LastModified: info.LastModified}, nil
+}
+
+// Presign - MINIO_PUBLIC_URL orqali ishlaydigan imzolangan URL. PUT faqat staging yuklashlar uchun
+// ishlatiladi, shuning uchun bucket bo'lmasa private va eskirgan fayllarni o'chiruvchi lifecycle bilan yaratiladi.
+// Public URL path prefiksi bilan bo'lsa proxy uni MinIO'ga yuborishdan oldin olib tashlashi va Host'ni saqlashi kerak
+func (m *MinioUploader) Presign(ctx context.Context, method, bucket, key string, expiry time.Duration, headers http.Header) (string, error) {
+	if method == http.MethodPut {
+		if err := m.ensureStagingBucket(ctx, bucket); err != nil {
+			return "", err
+		}
+	}
+
+	u, err := m.presigner.PresignHeader(ctx, method, bucket, key, expiry, nil, headers)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign URL: %v", err)
+	}
+	if m.publicPath != "" {
+		u.Path = m.publicPath + u.Path
+		if u.RawPath != "" {
+			u.RawPath = m.publicPath + u.RawPath
+		}
+	}
+	return u.String(), nil
+}
+
+func (m *MinioUploader) List(ctx context.Context, bucket, prefix string) ([]ObjectStat, error) {
+	var objects []ObjectStat
+	for obj := range m.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			if minio.ToErrorResponse(obj.Err).Code == "NoSuchBucket" {
+				return nil, nil
+			}
+			return objects, fmt.Errorf("failed to list objects: %v", obj.Err)
+		}
+		objects = append(objects, ObjectStat{Key: obj.Key, Size: obj.Size, ContentType: obj.ContentType, LastModified: obj.LastModified})
+	}
+	return objects, nil
+}
+
+func (m *MinioUploader) URL(bucket, key string) string {
+	return fmt.Sprintf("%s/%s/%s", m.cfg.Minio.MINIO_PUBLIC_URL, bucket, key)
+}
+
+// ParseURL - public URL'dan bucket va obyekt nomini ajratish
+// (MINIO_PUBLIC_URL'ning o'z path qismi bo'lsa tashlab yuboriladi)
+func (m *MinioUploader) ParseURL(fileURL string) (string, string, error) {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse URL: %v", err)
+	}
+
+	objectPath := parsed.Path
+	if public, err := url.Parse(m.cfg.Minio.MINIO_PUBLIC_URL); err == nil && public.Host == parsed.Host {
+		objectPath = strings.TrimPrefix(objectPath, strings.TrimSuffix(public.Path, "/"))
+	}
+
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(objectPath, "/"), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid URL format: %s", fileURL)
+	}
+	return bucket, key, nil
+}
+
+// ensureBucket - bucket bo'lmasa yaratish, policy'ni bucket ko'rinishiga moslash.
+// Har bir bucket jarayon davomida bir marta tekshiriladi
+func (m *MinioUploader) ensureBucket(ctx context.Context, bucket string) error {
+	if _, ok := m.ensured.Load(bucket); ok {
+		return nil
+	}
+
+	exists, err := m.client.BucketExists(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket existence: %v", err)
+	}
+	if !exists {
+		if err := m.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		fmt.Printf("Bucket '%s' yaratildi\n", bucket)
+	}
+
+	if err := m.setBucketPolicyIfNeeded(ctx, bucket); err != nil {
+		// Policy o'rnatilmasa ham fayl yuklanadi, keyingi safar qayta uriniladi
+		fmt.Printf("Warning: failed to set bucket policy: %v\n", err)
+		return nil
+	}
+
+	m.ensured.Store(bucket, true)
+	return nil
+}
+
+// minioError - "topilmadi" xatolarini ErrObjectNotFound'ga aylantirish
+func minioError(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	return err
+}
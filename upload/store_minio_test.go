@@ -0,0 +1,56 @@
+package upload
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"wegugin/config"
+)
+
+func TestMinioPresignUsesPublicEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		publicURL  string
+		wantScheme string
+		wantHost   string
+		wantPath   string
+	}{
+		{"public host", "https://media.example.com", "https", "media.example.com", "/docs/u1/x.pdf"},
+		{"public host with path", "http://localhost:9000/minio/", "http", "localhost:9000", "/minio/docs/u1/x.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Minio.MINIO_ENDPOINT = "minio:9000"
+			cfg.Minio.MINIO_ACCESS_KEY_ID = "access"
+			cfg.Minio.MINIO_SECRET_ACCESS_KEY = "secret"
+			cfg.Minio.MINIO_PUBLIC_URL = tt.publicURL
+			cfg.Minio.MINIO_REGION = "us-east-1"
+			m, err := NewMinioUploader(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Region berilgani uchun imzolash tarmoqqa murojaat qilmaydi
+			signed, err := m.Presign(context.Background(), http.MethodGet, "docs", "u1/x.pdf", time.Minute, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.Scheme != tt.wantScheme || u.Host != tt.wantHost || u.Path != tt.wantPath {
+				t.Fatalf("presigned URL = %s, want %s://%s%s", signed, tt.wantScheme, tt.wantHost, tt.wantPath)
+			}
+			if !strings.Contains(u.Query().Get("X-Amz-Credential"), "/us-east-1/") {
+				t.Fatalf("presigned URL %s is not signed for the configured region", signed)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // image.DecodeConfig uchun
+	_ "image/jpeg" // image.DecodeConfig uchun
+	_ "image/png"  // image.DecodeConfig uchun
+	"io"
+	"net/http"
+	"strings"
+
+	_ "golang.org/x/image/bmp"  // image.DecodeConfig uchun
+	_ "golang.org/x/image/webp" // image.DecodeConfig uchun
+)
+
+var (
+	ErrImageTooLarge         = errors.New("image file is too large")
+	ErrImageFormatNotAllowed = errors.New("image format is not allowed")
+	ErrImageCorrupt          = errors.New("file is not a valid image")
+	ErrImageDimensions       = errors.New("image dimensions are too large")
+)
+
+// Sniff qilingan content type bo'yicha format va saqlanadigan kengaytma
+var imageFormats = map[string]struct {
+	format string
+	ext    string
+}{
+	"image/jpeg": {"jpeg", ".jpg"},
+	"image/png":  {"png", ".png"},
+	"image/gif":  {"gif", ".gif"},
+	"image/webp": {"webp", ".webp"},
+	"image/bmp":  {"bmp", ".bmp"},
+}
+
+// ImageRules - yuklanadigan rasmlar uchun cheklovlar (0 - cheklanmagan)
+type ImageRules struct {
+	MaxBytes       int64
+	MaxWidth       int
+	MaxHeight      int
+	AllowedFormats []string // "jpeg", "png", "gif", "webp", "bmp"
+}
+
+// ImageInfo - fayl mazmunidan aniqlangan rasm ma'lumotlari
+type ImageInfo struct {
+	Format      string
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Size        int64
+}
+
+// ValidateImage - fayl kengaytmasiga emas, mazmuniga qarab rasmni tekshirish: magic bytes,
+// ruxsat etilgan format, header'ni decode qilish va o'lchamlar. Tekshiruvdan keyin
+// reader boshiga qaytariladi
+func ValidateImage(r io.ReadSeeker, size int64, rules ImageRules) (*ImageInfo, error) {
+	if rules.MaxBytes > 0 && size > rules.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, max %d", ErrImageTooLarge, size, rules.MaxBytes)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	known, ok := imageFormats[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: detected %s", ErrImageFormatNotAllowed, contentType)
+	}
+	if !formatAllowed(known.format, rules.AllowedFormats) {
+		return nil, fmt.Errorf("%w: %s", ErrImageFormatNotAllowed, known.format)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %v", err)
+	}
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil || format != known.format {
+		return nil, ErrImageCorrupt
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrImageCorrupt
+	}
+	if (rules.MaxWidth > 0 && cfg.Width > rules.MaxWidth) || (rules.MaxHeight > 0 && cfg.Height > rules.MaxHeight) {
+		return nil, fmt.Errorf("%w: %dx%d, max %dx%d", ErrImageDimensions, cfg.Width, cfg.Height, rules.MaxWidth, rules.MaxHeight)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %v", err)
+	}
+
+	return &ImageInfo{
+		Format:      known.format,
+		ContentType: contentType,
+		Ext:         known.ext,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Size:        size,
+	}, nil
+}
+
+func formatAllowed(format string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == format || (a == "jpg" && format == "jpeg") {
+			return true
+		}
+	}
+	return false
+}
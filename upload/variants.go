@@ -0,0 +1,117 @@
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Original rasm nomidagi belgi: <sha256 yoki uuid>_original.<ext>. Variantlar shu nomdan hosil qilinadi
+// (<uuid>_thumb.jpg, <uuid>_medium.jpg, ...), shu belgisiz eski rasmlarda variantlar yo'q
+const originalSuffix = "_original"
+
+const variantJPEGQuality = 82
+
+// ImageVariant - uzun tomoni MaxSize'dan oshmaydigan qilib kichraytirilgan nusxa
+type ImageVariant struct {
+	Name    string
+	MaxSize int
+}
+
+var ImageVariants = []ImageVariant{
+	{Name: "thumb", MaxSize: 320},
+	{Name: "medium", MaxSize: 800},
+	{Name: "large", MaxSize: 1600},
+}
+
+type encodedVariant struct {
+	key         string
+	data        []byte
+	contentType string
+}
+
+// variantExt - variantlar formati: png shaffofligi saqlanadi, qolganlari jpeg
+func variantExt(originalExt string) string {
+	if strings.ToLower(originalExt) == ".png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// VariantKeys - original obyekt nomidan barcha variantlar nomini hosil qilish.
+// Kalitlar: "original", "thumb", "medium", "large".
+// Eski (belgisiz) rasmlar uchun faqat "original" qaytadi
+func VariantKeys(originalKey string) map[string]string {
+	keys := map[string]string{"original": originalKey}
+
+	ext := path.Ext(originalKey)
+	base := strings.TrimSuffix(originalKey, ext)
+	if !strings.HasSuffix(base, originalSuffix) {
+		return keys
+	}
+	base = strings.TrimSuffix(base, originalSuffix)
+
+	for _, v := range ImageVariants {
+		keys[v.Name] = base + "_" + v.Name + variantExt(ext)
+	}
+	return keys
+}
+
+// buildVariants - decode qilingan rasmdan barcha o'lchamlarni tayyorlash.
+// WebP variantlar yo'q: mavjud encoder faqat lossless, natija jpeg'dan bir necha barobar katta.
+// Rasm variantdan kichik bo'lsa kattalashtirilmaydi
+func buildVariants(img image.Image, originalKey string) ([]encodedVariant, error) {
+	keys := VariantKeys(originalKey)
+	ext := variantExt(path.Ext(originalKey))
+
+	var variants []encodedVariant
+	for _, v := range ImageVariants {
+		resized := resizeToFit(img, v.MaxSize, ext != ".png")
+
+		var buf bytes.Buffer
+		contentType := "image/jpeg"
+		if ext == ".png" {
+			contentType = "image/png"
+			if err := png.Encode(&buf, resized); err != nil {
+				return nil, fmt.Errorf("failed to encode %s variant: %v", v.Name, err)
+			}
+		} else if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: variantJPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s variant: %v", v.Name, err)
+		}
+		variants = append(variants, encodedVariant{key: keys[v.Name], data: buf.Bytes(), contentType: contentType})
+	}
+
+	return variants, nil
+}
+
+// resizeToFit - uzun tomoni maxSize bo'ladigan qilib proporsional kichraytirish.
+// flatten bo'lsa shaffof joylar oq fon bilan to'ldiriladi (jpeg'da alpha yo'q)
+func resizeToFit(img image.Image, maxSize int, flatten bool) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		maxSize = max(w, h)
+	}
+
+	nw, nh := maxSize, maxSize
+	if w >= h {
+		nh = max(1, h*maxSize/w)
+	} else {
+		nw = max(1, w*maxSize/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	op := draw.Src
+	if flatten {
+		draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+		op = draw.Over
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, op, nil)
+	return dst
+}
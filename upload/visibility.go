@@ -0,0 +1,57 @@
+package upload
+
+import (
+	"strings"
+
+	"wegugin/config"
+)
+
+// Visibility - bucketdagi obyektlarni kim o'qiy olishi
+type Visibility string
+
+const (
+	VisibilityPublic        Visibility = "public"        // bucket policy orqali hamma o'qiydi
+	VisibilityAuthenticated Visibility = "authenticated" // login qilgan har qanday foydalanuvchi
+	VisibilityOwner         Visibility = "owner"         // kalit "<user_id>/" bilan boshlansa faqat egasi
+	VisibilityPrivate       Visibility = "private"       // faqat admin
+)
+
+// BucketVisibilities - config'dagi STORAGE_BUCKET_VISIBILITY ("photos:public,chat:authenticated").
+// Noma'lum daraja private hisoblanadi
+func BucketVisibilities(cfg *config.Config) map[string]Visibility {
+	visibilities := make(map[string]Visibility)
+	for _, entry := range strings.Split(cfg.Storage.BUCKET_VISIBILITY, ",") {
+		bucket, level, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		bucket = strings.TrimSpace(bucket)
+		if bucket == "" {
+			continue
+		}
+
+		switch v := Visibility(strings.ToLower(strings.TrimSpace(level))); v {
+		case VisibilityPublic, VisibilityAuthenticated, VisibilityOwner:
+			visibilities[bucket] = v
+		default:
+			visibilities[bucket] = VisibilityPrivate
+		}
+	}
+	return visibilities
+}
+
+// BucketVisibility - bucket ko'rinishi, config'da bo'lmasa private
+func BucketVisibility(cfg *config.Config, bucket string) Visibility {
+	if v, ok := BucketVisibilities(cfg)[bucket]; ok {
+		return v
+	}
+	return VisibilityPrivate
+}
+
+// PublicBuckets - hamma o'qiy oladigan bucketlar ro'yxati
+func PublicBuckets(cfg *config.Config) []string {
+	var buckets []string
+	for bucket, v := range BucketVisibilities(cfg) {
+		if v == VisibilityPublic {
+			buckets = append(buckets, bucket)
+		}
+	}
+	return buckets
+}